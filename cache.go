@@ -0,0 +1,105 @@
+//
+//  a cache of the nodes that own ranges of the ring, so that operations on
+//  keys near ones that were recently looked up can skip the lookup
+//
+
+package gmaj
+
+import (
+	"sync"
+
+	"github.com/r-medina/gmaj/gmajpb"
+)
+
+// locationCache maps ranges of IDs to the nodes that own them. It holds up to
+// size ranges, evicting the least recently used one when it is full. A nil
+// *locationCache caches nothing.
+type locationCache struct {
+	size    int
+	entries []locationEntry // most recently used first
+	mtx     sync.Mutex
+}
+
+// locationEntry records that node owns the IDs in (from : to].
+type locationEntry struct {
+	from, to ID
+	node     *gmajpb.Node
+}
+
+// newLocationCache creates a cache for size ranges. It returns nil if size is
+// 0.
+func newLocationCache(size int) *locationCache {
+	if size == 0 {
+		return nil
+	}
+
+	return &locationCache{
+		size:    size,
+		entries: make([]locationEntry, 0, size),
+	}
+}
+
+// get returns the node that owns id, or nil if the cache does not know.
+func (cache *locationCache) get(id ID) *gmajpb.Node {
+	if cache == nil {
+		return nil
+	}
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	for i, entry := range cache.entries {
+		if betweenRightIncl(id, entry.from, entry.to) {
+			copy(cache.entries[1:i+1], cache.entries[:i])
+			cache.entries[0] = entry
+			return entry.node
+		}
+	}
+
+	return nil
+}
+
+// add records that node owns (from : node.Id]. Any other range node was
+// thought to own is forgotten.
+func (cache *locationCache) add(from ID, node *gmajpb.Node) {
+	if cache == nil {
+		return
+	}
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	cache.remove(node)
+	if len(cache.entries) == cache.size {
+		cache.entries = cache.entries[:cache.size-1]
+	}
+
+	cache.entries = append(cache.entries, locationEntry{})
+	copy(cache.entries[1:], cache.entries)
+	cache.entries[0] = locationEntry{from: from, to: newID(node.Id), node: node}
+}
+
+// invalidate forgets the range node was thought to own, when it fails or
+// turns out not to own it anymore. It returns whether there was one.
+func (cache *locationCache) invalidate(node *gmajpb.Node) bool {
+	if cache == nil {
+		return false
+	}
+
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	return cache.remove(node)
+}
+
+// remove removes the entry for node. It must be called with the lock held.
+func (cache *locationCache) remove(node *gmajpb.Node) bool {
+	for i, entry := range cache.entries {
+		if idsEqual(entry.node.Id, node.Id) {
+			cache.entries = append(cache.entries[:i], cache.entries[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package gmaj
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+)
+
+func TestLocationCache(t *testing.T) {
+	t.Parallel()
+
+	node := func(id byte) *gmajpb.Node {
+		return &gmajpb.Node{Id: []byte{id}, Addr: fmt.Sprint(id)}
+	}
+
+	cache := newLocationCache(2)
+	cache.add(newID([]byte{10}), node(20))
+	cache.add(newID([]byte{200}), node(5))
+
+	tests := []struct {
+		id  byte
+		exp *gmajpb.Node
+	}{
+		{id: 10, exp: nil},
+		{id: 11, exp: node(20)},
+		{id: 20, exp: node(20)},
+		{id: 21, exp: nil},
+		{id: 250, exp: node(5)},
+		{id: 0, exp: node(5)},
+		{id: 5, exp: node(5)},
+	}
+	for i, test := range tests {
+		if want, got := test.exp, cache.get(newID([]byte{test.id})); !reflect.DeepEqual(got, want) {
+			t.Fatalf("[%02d] expected %v to be owned by %v, got %v", i, test.id, want, got)
+		}
+	}
+
+	// node(5) was used last, so node(20) is evicted.
+	cache.add(newID([]byte{100}), node(150))
+	if got := cache.get(newID([]byte{15})); got != nil {
+		t.Fatalf("expected range of %v to be evicted, got %v", node(20), got)
+	}
+	if got := cache.get(newID([]byte{0})); !reflect.DeepEqual(got, node(5)) {
+		t.Fatalf("expected 0 to be owned by %v, got %v", node(5), got)
+	}
+
+	// A node only owns one range.
+	cache.add(newID([]byte{140}), node(150))
+	if got := cache.get(newID([]byte{120})); got != nil {
+		t.Fatalf("expected old range of %v to be forgotten, got %v", node(150), got)
+	}
+
+	if !cache.invalidate(node(5)) {
+		t.Fatalf("expected range of %v to be invalidated", node(5))
+	}
+	if got := cache.get(newID([]byte{0})); got != nil {
+		t.Fatalf("expected 0 to be unknown, got %v", got)
+	}
+	if cache.invalidate(node(5)) {
+		t.Fatalf("expected nothing to invalidate for %v", node(5))
+	}
+
+	var disabled *locationCache
+	disabled.add(newID([]byte{10}), node(20))
+	if got := disabled.get(newID([]byte{15})); got != nil {
+		t.Fatalf("expected disabled cache to be empty, got %v", got)
+	}
+}
+
+func TestLocationCacheOwnerChange(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+
+	<-time.After(testTimeout)
+
+	// Look up a key that node3 owns, so that node1 caches node3's range.
+	keyIn := func(from, to byte) string {
+		for i := 0; ; i++ {
+			key := fmt.Sprintf("key%d", i)
+			hashed, err := config.hashKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if betweenRightIncl(newID(hashed), newID([]byte{from}), newID([]byte{to})) {
+				return key
+			}
+		}
+	}
+	if err := Put(node1, keyIn(55, 0x80), []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if got := node1.locations.get(newID([]byte{0x80})); got == nil || !idsEqual(got.Id, node3.Id) {
+		t.Fatalf("Expected node1 to have cached %v, got %v", node3.Node, got)
+	}
+
+	// node4 takes over part of node3's range, which node1 does not know.
+	node4 := createDefinedNode(t, node2.Node, []byte{0x90})
+
+	<-time.After(testTimeout)
+
+	key, want := keyIn(55, 0x90), []byte("b")
+	if err := Put(node1, key, want); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if got, err := node4.getKey(key); err != nil {
+		t.Fatalf("Expected node4 to own %q: %v", key, err)
+	} else if !reflect.DeepEqual(got.Val, want) {
+		t.Fatalf("Unexpected value. Expected %q got %q", want, got.Val)
+	}
+	if got := node1.locations.get(newID([]byte{0x80})); got == nil || !idsEqual(got.Id, node4.Id) {
+		t.Fatalf("Expected node1 to have cached %v, got %v", node4.Node, got)
+	}
+}
@@ -2,7 +2,6 @@ package gmaj
 
 import (
 	"errors"
-	"math/big"
 	"sync"
 
 	"github.com/r-medina/gmaj/gmajcfg"
@@ -12,12 +11,31 @@ import (
 
 var errSetConfig = errors.New("gmaj: cannot set configuration more than once")
 
-// the configuration for whole package
-var config struct {
+// nodeConfig is a validated configuration along with the values derived from
+// it.
+type nodeConfig struct {
 	gmajcfg.Config
-	// the largest possible ID value
-	max *big.Int
-	o   sync.Once
+	// the IDs on the ring are the IDs with only the bits in mask set
+	mask ID
+}
+
+// newNodeConfig validates cfg and derives the values needed by nodes from it.
+// Zero values that have defaults get them.
+func newNodeConfig(cfg *gmajcfg.Config) (*nodeConfig, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	nodeCfg := &nodeConfig{Config: *cfg, mask: getMask(cfg.KeySize)}
+	nodeCfg.SetDefaults()
+
+	return nodeCfg, nil
+}
+
+// the default configuration for nodes that are not created with WithConfig
+var config struct {
+	nodeConfig
+	o sync.Once
 }
 
 // Log allows clients to log with logger in configuration.
@@ -27,42 +45,41 @@ func init() {
 	mustInit(gmajcfg.DefaultConfig)
 }
 
-// Init allows consumers of this package to set the configuration. This has to
+// Init allows consumers of this package to set the default configuration,
+// which is used by nodes that are not created with WithConfig. This has to
 // happen before any other functionality of the package is used.
 // Should only be called once.
 func Init(cfg *gmajcfg.Config) error {
 	err := errSetConfig
 	config.o.Do(func() {
-		if err = cfg.Validate(); err != nil {
+		var nodeCfg *nodeConfig
+		if nodeCfg, err = newNodeConfig(cfg); err != nil {
 			return
 		}
 
-		config.Config = *cfg
-		config.max = getMax()
+		config.nodeConfig = *nodeCfg
 		Log = config.Log
-
 	})
 
 	return err
 }
 
 func mustInit(cfg *gmajcfg.Config) {
-	if err := cfg.Validate(); err != nil {
-		config.Log.Fatalf("error setting configuration: %v", err)
+	nodeCfg, err := newNodeConfig(cfg)
+	if err != nil {
+		cfg.Log.Fatalf("error setting configuration: %v", err)
 	}
 
-	config.Config = *cfg
-	config.max = getMax()
+	config.nodeConfig = *nodeCfg
 	Log = config.Log
 }
 
-func getMax() *big.Int {
-	max := big.NewInt(2)
-
-	b2 := big.NewInt(2)
-	for i := 0; i < config.KeySize; i++ {
-		max.Mul(max, b2)
+// getMask returns the ID with the lowest keySize bits set.
+func getMask(keySize int) ID {
+	var mask ID
+	for i := 0; i < keySize; i++ {
+		mask[idWords-1-i/64] |= 1 << uint(i%64)
 	}
 
-	return max
+	return mask
 }
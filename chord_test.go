@@ -1,21 +1,177 @@
 package gmaj
 
-import "testing"
+import (
+	"bytes"
+	"testing"
 
-func TestSimple(t *testing.T) {
-	t.Parallel()
+	"github.com/r-medina/gmaj/gmajpb"
 
-	_, err := NewNode(nil)
+	"golang.org/x/net/context"
+)
+
+// TestSimple creates a node over gRPC with the default options. Like
+// TestGRPCTransport, it does not run in parallel with the other tests, so that
+// its RPCs do not time out when the tests are slow, e.g. under the race
+// detector.
+func TestSimple(t *testing.T) {
+	node, err := NewNode(nil)
 	if err != nil {
-		t.Errorf("Unable to create node, received error:%v\n", err)
+		t.Fatalf("Unable to create node, received error:%v\n", err)
 	}
+	node.Shutdown()
 }
 
 func TestErrorCreationNodeExistingID(t *testing.T) {
 	t.Parallel()
 
 	node := createSimpleNode(t, nil)
-	if _, err := NewNode(node.Node, WithID(node.Id)); err == nil {
+	_, err := NewNode(node.Node, WithID(node.Id), WithTransport(testNetwork.Transport()))
+	if err == nil {
 		t.Errorf("Unexpected success creating a node with invalid id")
 	}
 }
+
+func TestRejoinAfterCrash(t *testing.T) {
+	t.Parallel()
+
+	forEachLookupMode(t, func(t *testing.T, opt NodeOption) {
+		node1, node2, node3 := create3SuccessiveNodes(t, opt)
+		defer node1.Shutdown()
+		defer node3.Shutdown()
+
+		waitForRing(t, node1, node2, node3)
+
+		// A node that crashes and comes back at the same address with the
+		// same ID, before the ring notices it was gone, takes its place
+		// again.
+		id, addr := node2.Id, node2.Addr
+		crashNode(node2)
+		node2.closeStores()
+
+		node2, err := NewNode(node1.Node, opt,
+			WithID(id), WithAddress(addr), WithTransport(testNetwork.Transport()),
+		)
+		if err != nil {
+			t.Fatalf("Unexpected error restarting node: %v", err)
+		}
+		defer node2.Shutdown()
+
+		// It joins at its successor, rather than at its old self.
+		succ, _ := node2.GetSuccessor(context.Background(), &gmajpb.MT{})
+		if !idsEqual(succ.Id, node3.Id) {
+			t.Fatalf("Expected successor %v, got %v", IDToString(node3.Id), IDToString(succ.Id))
+		}
+		waitForRing(t, node1, node2, node3)
+	})
+}
+
+func TestWithConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.KeySize = 16
+	cfg.IDLength = 2
+
+	node1 := createDefinedNode(t, nil, nil, WithConfig(&cfg))
+	defer node1.Shutdown()
+	node2 := createDefinedNode(t, node1.Node, nil, WithConfig(&cfg))
+	defer node2.Shutdown()
+	// a node with the default configuration in its own ring
+	node3 := createSimpleNode(t, nil)
+	defer node3.Shutdown()
+
+	waitForRing(t, node1, node2)
+
+	for _, node := range []*Node{node1, node2} {
+		if want, got := cfg.IDLength, len(node.Id); got != want {
+			t.Fatalf("Expected ID of length %d, got %d", want, got)
+		}
+		if want, got := cfg.KeySize, len(node.fingerTable); got != want {
+			t.Fatalf("Expected finger table of length %d, got %d", want, got)
+		}
+	}
+	if want, got := config.KeySize, len(node3.fingerTable); got != want {
+		t.Fatalf("Expected finger table of length %d, got %d", want, got)
+	}
+
+	key, want := "config", []byte("value")
+	if err := Put(node1, key, want); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if got, err := Get(node2, key); err != nil {
+		t.Fatalf("Unexpected error getting value: %v", err)
+	} else if !bytes.Equal(got, want) {
+		t.Fatalf("Unexpected value. Expected %q got %q", want, got)
+	}
+
+	cfg.SuccessorListSize = -1
+	if _, err := NewNode(nil, WithConfig(&cfg)); err == nil {
+		t.Fatal("Unexpected success creating a node with an invalid configuration")
+	}
+}
+
+func TestLocateTrace(t *testing.T) {
+	t.Parallel()
+
+	forEachLookupMode(t, testLocateTrace)
+}
+
+func testLocateTrace(t *testing.T, opt NodeOption) {
+	node1, node2, node3 := create3SuccessiveNodes(t, opt)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	// The path of a lookup depends on the fingers, so they have to be fixed.
+	waitForRing(t, node1, node2, node3)
+
+	// The fingers of node1 from 2^7 on point to node3, which is right before
+	// 200 on the ring.
+	trace := &lookupTrace{}
+	_, succ, err := node1.findPredecessor(context.Background(), newID([]byte{200}), trace)
+	if err != nil {
+		t.Fatalf("Unexpected error finding predecessor: %v", err)
+	}
+	if !idsEqual(succ.Id, node1.Id) {
+		t.Fatalf("Unexpected successor. Expected %v got %v", node1.Node, succ)
+	}
+
+	want := []struct {
+		node   *Node
+		finger int32
+	}{
+		{node: node1, finger: -1},
+		{node: node3, finger: 7},
+	}
+	if len(trace.hops) != len(want) {
+		t.Fatalf("Expected %d hops, got %v", len(want), trace.hops)
+	}
+	for i, hop := range trace.hops {
+		if !idsEqual(hop.Node.Id, want[i].node.Id) || hop.Finger != want[i].finger {
+			t.Fatalf("Unexpected hop %d. Expected %v via %d, got %v via %d",
+				i, want[i].node.Node, want[i].finger, hop.Node, hop.Finger,
+			)
+		}
+		if hop.LatencyNs <= 0 {
+			t.Fatalf("Expected hop %d to have a latency, got %d", i, hop.LatencyNs)
+		}
+	}
+
+	resp, err := node3.Locate(context.Background(), &gmajpb.LocateRequest{Key: "trace", Trace: true})
+	if err != nil {
+		t.Fatalf("Unexpected error locating key: %v", err)
+	}
+	untraced, err := node3.Locate(context.Background(), &gmajpb.LocateRequest{Key: "trace"})
+	if err != nil {
+		t.Fatalf("Unexpected error locating key: %v", err)
+	}
+	if !idsEqual(resp.Node.Id, untraced.Node.Id) {
+		t.Fatalf("Traced lookup found %v, untraced lookup found %v", resp.Node, untraced.Node)
+	}
+	if len(resp.Path) == 0 || !idsEqual(resp.Path[0].Node.Id, node3.Id) {
+		t.Fatalf("Expected path to start at %v, got %v", node3.Node, resp.Path)
+	}
+	if len(untraced.Path) != 0 {
+		t.Fatalf("Expected no path for an untraced lookup, got %v", untraced.Path)
+	}
+}
@@ -0,0 +1,54 @@
+package gmaj
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock schedules the background tasks of nodes. Nodes use the wall clock by
+// default, but a simulation can give them a virtual clock (see WithClock) to
+// control exactly when their tasks run.
+type Clock interface {
+	// Every calls f every d until the returned function is called.
+	Every(d time.Duration, f func()) (stop func())
+	// After waits for d to pass and then sends the current time on the
+	// returned channel.
+	After(d time.Duration) <-chan time.Time
+	// Now returns the current time, which is used to expire keys.
+	Now() time.Time
+}
+
+// wallClock is the Clock that uses the actual time.
+type wallClock struct{}
+
+var _ Clock = wallClock{}
+
+// Every calls f from a new goroutine every d.
+func (wallClock) Every(d time.Duration, f func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(d)
+		for {
+			select {
+			case <-ticker.C:
+				f()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// After is time.After.
+func (wallClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Now is time.Now.
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
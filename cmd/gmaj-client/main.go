@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/r-medina/gmaj"
 	"github.com/r-medina/gmaj/gmajpb"
@@ -14,16 +16,42 @@ import (
 
 var config struct {
 	parentAddr string
+	timeout    time.Duration
 	client     gmajpb.GMajClient
 
 	put struct {
-		key string
-		val string
+		key     string
+		val     string
+		update  bool
+		version uint64
+		ttl     time.Duration
 	}
 
 	get struct {
+		key     string
+		version bool
+	}
+
+	delete struct {
 		key string
 	}
+
+	list struct {
+		limit     uint32
+		values    bool
+		pageToken string
+		all       bool
+	}
+
+	locate struct {
+		key   string
+		trace bool
+	}
+
+	watch struct {
+		key    string
+		prefix bool
+	}
 }
 
 var (
@@ -32,14 +60,41 @@ var (
 
 func init() {
 	app.Flag("addr", "address of node to contact").StringVar(&config.parentAddr)
+	app.Flag("timeout", "how long to wait for a request").Default("10s").DurationVar(&config.timeout)
 
 	put := app.Command("put", "put a key - if value argument is missing, reads from stdin").
 		PreAction(getClient).Action(putKeyVal)
 	put.Arg("key", "the key to put").StringVar(&config.put.key)
 	put.Arg("value", "the key to put").StringVar(&config.put.val)
+	put.Flag("update", "overwrite the key if it exists").BoolVar(&config.put.update)
+	put.Flag("version", "only overwrite the key if it has this version - implies --update").
+		Uint64Var(&config.put.version)
+	put.Flag("ttl", "how long the key lives - forever if unset").DurationVar(&config.put.ttl)
 
 	get := app.Command("get", "get a key").PreAction(getClient).Action(getKey)
 	get.Arg("key", "the key to get").StringVar(&config.get.key)
+	get.Flag("version", "print the version of the value before it").BoolVar(&config.get.version)
+
+	del := app.Command("delete", "delete a key").PreAction(getClient).Action(deleteKey)
+	del.Arg("key", "the key to delete").StringVar(&config.delete.key)
+
+	list := app.Command("list", "list the keys in order of their hashed IDs").
+		PreAction(getClient).Action(listKeys)
+	list.Flag("limit", "the most keys to list per page").Uint32Var(&config.list.limit)
+	list.Flag("values", "print the values of the keys as well").BoolVar(&config.list.values)
+	list.Flag("page-token", "the page token printed by a previous listing").
+		StringVar(&config.list.pageToken)
+	list.Flag("all", "list every page").BoolVar(&config.list.all)
+
+	locate := app.Command("locate", "find the node a key belongs to").
+		PreAction(getClient).Action(locateKey)
+	locate.Arg("key", "the key to locate").StringVar(&config.locate.key)
+	locate.Flag("trace", "print the path the lookup took").BoolVar(&config.locate.trace)
+
+	watch := app.Command("watch", "print the changes to a key as they happen").
+		PreAction(getClient).Action(watchKeys)
+	watch.Arg("key", "the key to watch").StringVar(&config.watch.key)
+	watch.Flag("prefix", "watch all the keys that start with key").BoolVar(&config.watch.prefix)
 }
 
 func main() {
@@ -59,33 +114,171 @@ func getClient(*kingpin.ParseContext) error {
 
 func putKeyVal(*kingpin.ParseContext) error {
 	key := config.put.key
-	strVal := config.put.val
-	var val []byte
 
-	if strVal == "" {
-		var err error
-		val, err = ioutil.ReadAll(os.Stdin)
-		app.FatalIfError(err, "failed to read from stdin")
-	} else {
-		val = []byte(strVal)
+	var r io.Reader = os.Stdin
+	if config.put.val != "" {
+		r = strings.NewReader(config.put.val)
 	}
 
-	_, err := config.client.Put(context.Background(), &gmajpb.PutRequest{
-		Key: key, Value: val,
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
+	defer cancel()
+
+	mode := gmajpb.PutMode_CREATE
+	if config.put.update || config.put.version != 0 {
+		mode = gmajpb.PutMode_UPDATE
+	}
+
+	// The value is streamed in chunks, so that it does not have to fit in
+	// memory or in one message.
+	stream, err := config.client.PutStream(ctx)
+	app.FatalIfError(err, "putting key %q failed", key)
+
+	req := &gmajpb.PutRequest{
+		Key: key, Mode: mode, ExpectedVersion: config.put.version,
+		TtlNs: int64(config.put.ttl),
+	}
+	buf := make([]byte, gmaj.ChunkSize)
+	for sent := false; ; sent = true {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			app.FatalIfError(err, "failed to read value")
+		}
+
+		// The first request is sent even if the value is empty.
+		if n > 0 || !sent {
+			req.Value = buf[:n]
+			if err := stream.Send(req); err != nil {
+				break // CloseAndRecv returns the error
+			}
+			req = &gmajpb.PutRequest{}
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
 	app.FatalIfError(err, "putting key %q failed", key)
 
-	fmt.Println("put succeded")
+	fmt.Printf("put succeded at version %d\n", resp.Version)
 
 	return nil
 }
 
 func getKey(*kingpin.ParseContext) error {
 	key := config.get.key
-	resp, err := config.client.Get(context.Background(), &gmajpb.GetRequest{Key: key})
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
+	defer cancel()
+
+	stream, err := config.client.GetStream(ctx, &gmajpb.GetRequest{Key: key})
 	app.FatalIfError(err, "getting key %q failed", key)
 
-	fmt.Printf("%s", resp.Value)
+	for first := true; ; first = false {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		app.FatalIfError(err, "getting key %q failed", key)
+
+		if first && config.get.version {
+			fmt.Printf("%d ", resp.Version)
+		}
+		_, err = os.Stdout.Write(resp.Value)
+		app.FatalIfError(err, "failed to write value")
+	}
+}
+
+func deleteKey(*kingpin.ParseContext) error {
+	key := config.delete.key
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
+	defer cancel()
+
+	_, err := config.client.Delete(ctx, &gmajpb.DeleteRequest{Key: key})
+	app.FatalIfError(err, "deleting key %q failed", key)
+
+	fmt.Println("delete succeded")
+
+	return nil
+}
+
+func listKeys(*kingpin.ParseContext) error {
+	req := &gmajpb.ListRequest{
+		Limit: config.list.limit, Values: config.list.values, PageToken: config.list.pageToken,
+	}
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
+		resp, err := config.client.List(ctx, req)
+		cancel()
+		app.FatalIfError(err, "listing keys failed")
+
+		for _, item := range resp.Items {
+			if config.list.values {
+				fmt.Printf("%s: %s\n", item.Key, item.Value)
+			} else {
+				fmt.Println(item.Key)
+			}
+		}
+
+		if resp.NextPageToken == "" {
+			return nil
+		}
+		if !config.list.all {
+			fmt.Fprintf(os.Stderr, "next page: %s\n", resp.NextPageToken)
+			return nil
+		}
+
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+func locateKey(*kingpin.ParseContext) error {
+	key := config.locate.key
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout)
+	defer cancel()
+
+	resp, err := config.client.Locate(ctx, &gmajpb.LocateRequest{
+		Key: key, Trace: config.locate.trace,
+	})
+	app.FatalIfError(err, "locating key %q failed", key)
+
+	fmt.Printf("%v %v\n", gmaj.IDToString(resp.Node.Id), resp.Node.Addr)
+
+	for i, hop := range resp.Path {
+		via := "successor"
+		if hop.Finger >= 0 {
+			via = fmt.Sprintf("finger %d", hop.Finger)
+		}
+
+		fmt.Printf("  %2d: %v %v via %v in %v\n",
+			i, gmaj.IDToString(hop.Node.Id), hop.Node.Addr, via, time.Duration(hop.LatencyNs),
+		)
+	}
 
 	return nil
 }
+
+func watchKeys(*kingpin.ParseContext) error {
+	key := config.watch.key
+
+	// The watch runs until it is interrupted, so it has no timeout.
+	stream, err := config.client.Watch(context.Background(), &gmajpb.WatchRequest{
+		Key: key, Prefix: config.watch.prefix,
+	})
+	app.FatalIfError(err, "watching key %q failed", key)
+
+	for {
+		event, err := stream.Recv()
+		app.FatalIfError(err, "watching key %q failed", key)
+
+		switch event.Type {
+		case gmajpb.EventType_RESYNC:
+			fmt.Printf("%v %s: changes may have been missed\n", event.Type, event.Key)
+		case gmajpb.EventType_DELETED:
+			fmt.Printf("%v %s (version %d)\n", event.Type, event.Key, event.Version)
+		default:
+			fmt.Printf("%v %s (version %d): %s\n", event.Type, event.Key, event.Version, event.Value)
+		}
+	}
+}
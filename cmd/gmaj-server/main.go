@@ -1,13 +1,18 @@
 package main
 
 import (
+	"encoding/hex"
+	"io/ioutil"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/r-medina/gmaj"
+	"github.com/r-medina/gmaj/gmajcfg"
 	"github.com/r-medina/gmaj/gmajpb"
 
 	"golang.org/x/net/context"
@@ -19,8 +24,11 @@ var config struct {
 	id         string
 	addr       string
 	parentAddr string
+	vnodes     int
 	debug      bool
 	pprofAddr  string
+	recursive  bool
+	dataDir    string
 }
 
 var (
@@ -34,8 +42,11 @@ func init() {
 	app.Flag("id", "custom ID to use instead of than hashing address").StringVar(&config.id)
 	app.Flag("addr", "address on which to start server").StringVar(&config.addr)
 	app.Flag("parent-addr", "address of node to join").StringVar(&config.parentAddr)
+	app.Flag("vnodes", "number of virtual nodes to run").Default("1").IntVar(&config.vnodes)
 	app.Flag("debug", "whether debug mode is on").Default("false").BoolVar(&config.debug)
 	app.Flag("pprof-addr", "address for running pprof tools").StringVar(&config.pprofAddr)
+	app.Flag("recursive", "whether to use recursive lookups").Default("false").BoolVar(&config.recursive)
+	app.Flag("data-dir", "directory in which to keep keys across restarts").StringVar(&config.dataDir)
 
 	log = gmaj.Log
 }
@@ -77,18 +88,51 @@ func runServer(_ *kingpin.ParseContext) error {
 		opts = append(opts, gmaj.WithID(id))
 	}
 
-	node, err := gmaj.NewNode(parent, opts...)
+	if config.dataDir != "" {
+		opts = append(opts, gmaj.WithStore(gmaj.DiskStore(config.dataDir)))
+
+		// Rejoin with the ID we had before, so that we own the same keys. The
+		// IDs of the other virtual nodes, whose keys are kept under their
+		// IDs, are derived from it.
+		if config.id == "" {
+			id, err := readID(config.dataDir)
+			if err != nil {
+				log.Fatalf("reading ID failed: %v", err)
+			}
+			if id != nil {
+				opts = append(opts, gmaj.WithID(id))
+			}
+		}
+	}
+
+	if config.recursive {
+		cfg := *gmajcfg.DefaultConfig
+		cfg.LookupMode = gmajcfg.RecursiveLookup
+		opts = append(opts, gmaj.WithConfig(&cfg))
+	}
+
+	nodes, err := gmaj.NewVirtualNodes(parent, config.vnodes, opts...)
 	if err != nil {
 		log.Fatalf("faild to instantiate node: %v", err)
 	}
 
-	log.Printf("%+v", node)
+	for _, node := range nodes {
+		log.Printf("%+v", node)
+	}
+
+	if config.dataDir != "" {
+		if err := writeID(config.dataDir, nodes[0].Id); err != nil {
+			log.Fatalf("writing ID failed: %v", err)
+		}
+	}
 
 	if config.debug {
 		go func() {
 			for range time.Tick(5 * time.Second) {
-				log.Println(node)
-				log.Println(node.DatastoreString())
+				for _, node := range nodes {
+					log.Println(node)
+					log.Println(node.DatastoreString())
+				}
 			}
 		}()
 	}
@@ -99,11 +143,34 @@ func runServer(_ *kingpin.ParseContext) error {
 	log.Printf("received signal %v", sig)
 
 	log.Println("shutting down")
-	node.Shutdown()
+	for _, node := range nodes {
+		node.Shutdown()
+	}
 
 	return nil
 }
 
+// idFile is the file in the data directory that has the ID of the first node,
+// from which the IDs of the other virtual nodes are derived.
+const idFile = "id"
+
+// readID reads the ID saved in dir, if there is one.
+func readID(dir string) ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, idFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(strings.TrimSpace(string(b)))
+}
+
+// writeID saves id in dir.
+func writeID(dir string, id []byte) error {
+	return ioutil.WriteFile(filepath.Join(dir, idFile), []byte(hex.EncodeToString(id)+"\n"), 0644)
+}
+
 func startPprof(_ *kingpin.ParseContext) error {
 	if config.pprofAddr == "" {
 		return nil
@@ -12,46 +12,182 @@ import (
 	"time"
 
 	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 var errNoDatastore = errors.New("gmaj: node does not have a datastore")
 
+// errDeleted is returned for keys with tombstones. Unlike a key that is not
+// found, which may be on its way to the node, it is final.
+var errDeleted = grpc.Errorf(codes.NotFound, "gmaj: key was deleted")
+
+// errExpired is returned for keys whose TTL has passed. It is final for the
+// same reason as errDeleted.
+var errExpired = grpc.Errorf(codes.NotFound, "gmaj: key expired")
+
+// errExists is returned when creating a key that exists.
+var errExists = grpc.Errorf(codes.AlreadyExists, "gmaj: cannot modify an existing value")
+
+// isDeleted returns if err, which may have come over an RPC, is errDeleted or
+// errExpired.
+func isDeleted(err error) bool {
+	return grpc.Code(err) == codes.NotFound
+}
+
+// isConflict returns if err, which may have come over an RPC, means that a
+// write conflicted with the value that was there.
+func isConflict(err error) bool {
+	code := grpc.Code(err)
+	return code == codes.AlreadyExists || code == codes.Aborted
+}
+
 //
 // External API Into Datastore
 //
 
 // Get a value in the datastore, provided an abitrary node in the ring
 func Get(node *Node, key string) ([]byte, error) {
+	val, _, err := GetVersion(node, key)
+	return val, err
+}
+
+// GetVersion is like Get, but also returns the version of the value.
+func GetVersion(node *Node, key string) ([]byte, uint64, error) {
 	if node == nil {
-		return nil, errors.New("Node cannot be nil")
+		return nil, 0, errors.New("Node cannot be nil")
 	}
 
-	return node.get(key)
+	entry, err := node.get(context.Background(), key)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entry.Val, entry.Version, nil
 }
 
 // Put a key/value in the datastore, provided an abitrary node in the ring.
-// This is useful for testing.
+// It fails if the key exists. This is useful for testing.
 func Put(node *Node, key string, val []byte) error {
 	if node == nil {
 		return errors.New("Node cannot be nil")
 	}
 
-	return node.put(key, val)
+	_, err := node.put(context.Background(), &gmajpb.KeyVal{Key: key, Val: val})
+	return err
+}
+
+// Update sets the value of a key in the datastore whether or not it exists,
+// provided an abitrary node in the ring. It returns the version of the new
+// value.
+func Update(node *Node, key string, val []byte) (uint64, error) {
+	if node == nil {
+		return 0, errors.New("Node cannot be nil")
+	}
+
+	return node.put(context.Background(), &gmajpb.KeyVal{
+		Key: key, Val: val, Mode: gmajpb.PutMode_UPDATE,
+	})
+}
+
+// CompareAndSwap sets the value of a key in the datastore if its current value
+// has the given version, provided an abitrary node in the ring. It returns the
+// version of the new value.
+func CompareAndSwap(node *Node, key string, val []byte, version uint64) (uint64, error) {
+	if node == nil {
+		return 0, errors.New("Node cannot be nil")
+	}
+	if version == 0 {
+		return 0, errors.New("gmaj: version must be set")
+	}
+
+	return node.put(context.Background(), &gmajpb.KeyVal{
+		Key: key, Val: val, Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: version,
+	})
+}
+
+// UpdateWithTTL is like Update, but the key expires after ttl.
+func UpdateWithTTL(node *Node, key string, val []byte, ttl time.Duration) (uint64, error) {
+	if node == nil {
+		return 0, errors.New("Node cannot be nil")
+	}
+	if ttl <= 0 {
+		return 0, errors.New("gmaj: TTL must be positive")
+	}
+
+	return node.put(context.Background(), &gmajpb.KeyVal{
+		Key: key, Val: val, Mode: gmajpb.PutMode_UPDATE, TtlNs: int64(ttl),
+	})
+}
+
+// Delete a key from the datastore, provided an abitrary node in the ring.
+func Delete(node *Node, key string) error {
+	if node == nil {
+		return errors.New("Node cannot be nil")
+	}
+
+	return node.delete(context.Background(), key)
 }
 
-// locate helps find the appropriate node in the ring.
-func (node *Node) locate(key string) (*gmajpb.Node, error) {
-	hashed, err := hashKey(key)
+// locate helps find the appropriate node in the ring. Nodes found by lookups
+// are cached along with the range of IDs they own, so keys in the same range
+// are located without a lookup.
+func (node *Node) locate(ctx context.Context, key string) (*gmajpb.Node, error) {
+	hashed, err := node.config.hashKey(key)
 	if err != nil {
 		return nil, err
 	}
 
-	return node.findSuccessor(hashed)
+	_, owner, err := node.locateID(ctx, newID(hashed))
+	return owner, err
+}
+
+// locateID is locate for the ID of a key. If the owner is looked up rather
+// than cached, its predecessor is returned as well, so that the range the owner
+// has, (pred : owner], is known.
+func (node *Node) locateID(ctx context.Context, id ID) (pred, owner *gmajpb.Node, err error) {
+	if owner := node.locations.get(id); owner != nil {
+		return nil, owner, nil
+	}
+
+	pred, succ, err := node.lookup(ctx, id, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// When pred is succ, the lookup ended before finding a range (e.g. the
+	// node has no successor yet), or there is only one node, which does not
+	// need a cache.
+	if !idsEqual(pred.Id, succ.Id) {
+		node.locations.add(newID(pred.Id), succ)
+	}
+
+	return pred, succ, nil
+}
+
+// traceLocate is like locate, but also returns the path the lookup took.
+func (node *Node) traceLocate(
+	ctx context.Context, key string,
+) (*gmajpb.Node, []*gmajpb.Hop, error) {
+	hashed, err := node.config.hashKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	trace := &lookupTrace{}
+	_, succ, err := node.lookup(ctx, newID(hashed), trace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return succ, trace.hops, nil
 }
 
 // obtainNewKeys is called when a node joins a ring and wants to request keys
 // from its successor.
-func (node *Node) obtainNewKeys() error {
+func (node *Node) obtainNewKeys(ctx context.Context) error {
 	node.succMtx.RLock()
 	succ := node.successor
 	node.succMtx.RUnlock()
@@ -59,13 +195,13 @@ func (node *Node) obtainNewKeys() error {
 	// TODO(asubiotto): Test the case where there are two nodes floating around
 	// that need keys.
 	// Assume new predecessor has been set.
-	prevPredecessor, err := node.getPredecessorRPC(succ)
+	prevPredecessor, err := node.getPredecessorRPC(ctx, succ)
 	if err != nil {
 		return err
 	}
 
 	return node.transferKeysRPC(
-		succ, node.Id, prevPredecessor,
+		ctx, succ, node.Id, prevPredecessor,
 	) // implicitly correct even when prevPredecessor.ID == nil
 }
 
@@ -73,103 +209,445 @@ func (node *Node) obtainNewKeys() error {
 // RPCs to assist with interfacing with the datastore ring
 //
 
-func (node *Node) getKey(key string) ([]byte, error) {
-	node.dsMtx.RLock()
-	val, ok := node.datastore[key]
-	node.dsMtx.RUnlock()
+func (node *Node) getKey(key string) (Entry, error) {
+	if node.datastore == nil {
+		return Entry{}, errNoDatastore
+	}
+
+	entry, ok, err := node.datastore.Get(key)
+	if err != nil {
+		return Entry{}, err
+	}
 	if !ok {
-		return nil, errors.New("key does not exist")
+		return Entry{}, errors.New("key does not exist")
+	}
+	if entry.Deleted {
+		return Entry{}, errDeleted
+	}
+	if entry.expired(node.clock.Now()) {
+		return Entry{}, errExpired
 	}
 
-	return val, nil
+	return entry, nil
 }
 
-func (node *Node) putKeyVal(keyVal *gmajpb.KeyVal) error {
-	key := keyVal.Key
-	val := keyVal.Val
+// checkOwner returns an error if the node does not own key, as far as it knows.
+// A node that does not know its predecessor assumes that it does.
+func (node *Node) checkOwner(key string) error {
+	hashed, err := node.config.hashKey(key)
+	if err != nil {
+		return err
+	}
 
-	node.dsMtx.RLock()
-	_, exists := node.datastore[key]
-	node.dsMtx.RUnlock()
-	if exists {
-		return errors.New("cannot modify an existing value")
+	node.predMtx.RLock()
+	pred := node.predecessor
+	node.predMtx.RUnlock()
+
+	if pred == nil || pred.Addr == "" || betweenRightIncl(newID(hashed), newID(pred.Id), node.id) {
+		return nil
+	}
+
+	return grpc.Errorf(codes.FailedPrecondition, "gmaj: node %v does not own key %q",
+		IDToString(node.Id), key,
+	)
+}
+
+// putKeyVal writes a key/value to the datastore, and returns the version the
+// key has.
+func (node *Node) putKeyVal(ctx context.Context, keyVal *gmajpb.KeyVal) (uint64, error) {
+	if node.datastore == nil {
+		return 0, errNoDatastore
+	}
+
+	id, err := node.config.keyID(keyVal.Key)
+	if err != nil {
+		return 0, err
 	}
 
 	node.dsMtx.Lock()
-	node.datastore[key] = val
+	entry, stored, err := node.storeKeyVal(id, keyVal)
 	node.dsMtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if stored {
+		node.replicate(ctx, keyVal.Key, entry)
+	}
+
+	return entry.Version, nil
+}
+
+// storeKeyVal puts the entry for a key/value in the datastore, and returns the
+// entry the key ends up with and whether it was stored. Transferred keys keep
+// their versions, and only replace older ones. Keys written by clients get the
+// next version. Keys locked by prepared transactions are not written either
+// way. It must be called with dsMtx held.
+func (node *Node) storeKeyVal(id ID, keyVal *gmajpb.KeyVal) (Entry, bool, error) {
+	if err := node.checkLock(keyVal.Key); err != nil {
+		return Entry{}, false, err
+	}
+
+	now := node.clock.Now()
+	if keyVal.Transfer {
+		entry := keyValEntry(keyVal, now)
+		old, exists, err := node.datastore.Get(keyVal.Key)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		if exists && !entry.newer(old) {
+			return old, false, nil
+		}
+
+		return entry, true, node.storeEntry(id, keyVal.Key, entry, false, false)
+	}
+
+	if err := checkValueSize(len(keyVal.Val), node.config.MaxValueSize); err != nil {
+		return Entry{}, false, err
+	}
+
+	old, exists, err := node.latest(keyVal.Key)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	replaced, retry, err := checkWrite(keyVal, old, exists, now)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if retry {
+		return old, false, nil
+	}
+
+	entry := node.nextEntry(keyVal, old, now)
+
+	return entry, true, node.storeEntry(id, keyVal.Key, entry, true, replaced)
+}
+
+// nextEntry returns the entry a client's write of keyVal replaces old, the
+// latest entry for the key, with.
+func (node *Node) nextEntry(keyVal *gmajpb.KeyVal, old Entry, now time.Time) Entry {
+	entry := keyValEntry(keyVal, now)
+
+	// Tombstones have versions too, so a key that is written again after it
+	// is deleted replaces the tombstone everywhere.
+	entry.Version = old.Version + 1
+
+	// Tombstones expire once the delete has had time to reach every copy of
+	// the key, e.g. through anti-entropy, so that they do not pile up. The
+	// copies of a tombstone expire along with it.
+	if entry.Deleted && node.config.TombstoneGracePeriod > 0 {
+		entry.Expires = now.Add(node.config.TombstoneGracePeriod)
+	}
+
+	return entry
+}
+
+// storeEntry puts an entry in the datastore in place of the key's copy, if
+// any. Changes, as opposed to transferred keys, are sent to the watchers of
+// the key; replaced is whether the entry replaced a live value. It must be
+// called with dsMtx held.
+func (node *Node) storeEntry(id ID, key string, entry Entry, change, replaced bool) error {
+	if err := node.datastore.Put(id, key, entry); err != nil {
+		return err
+	}
+
+	if change {
+		switch {
+		case entry.Deleted && replaced:
+			node.watchers.notify(id, key, gmajpb.EventType_DELETED, entry)
+		case entry.Deleted:
+		case replaced:
+			node.watchers.notify(id, key, gmajpb.EventType_UPDATED, entry)
+		default:
+			node.watchers.notify(id, key, gmajpb.EventType_CREATED, entry)
+		}
+	}
+
+	return node.replicas.Delete(key)
+}
+
+// checkWrite checks that a client's write of keyVal may replace old, the
+// latest entry for the key, if it exists. It returns whether old is a live
+// value, and whether the write is a retry that has already been done.
+func checkWrite(keyVal *gmajpb.KeyVal, old Entry, exists bool, now time.Time) (bool, bool, error) {
+	live := exists && !old.Deleted && !old.expired(now)
+	var version uint64
+	if live {
+		version = old.Version
+	}
+
+	switch {
+	case keyVal.ExpectedVersion != 0 && keyVal.ExpectedVersion != version:
+		return live, false, grpc.Errorf(codes.Aborted,
+			"gmaj: key %q has version %d, not %d", keyVal.Key, version, keyVal.ExpectedVersion,
+		)
+	case live && keyVal.Mode == gmajpb.PutMode_CREATE && bytes.Equal(old.Val, keyVal.Val):
+		// Creating the same value again is allowed so that retried puts
+		// succeed.
+		return live, true, nil
+	case live && keyVal.Mode == gmajpb.PutMode_CREATE:
+		return live, false, errExists
+	}
+
+	return live, false, nil
+}
+
+// latest returns the newest entry the node has for key, which may be in its
+// replicas if it took over the key recently. It must be called with dsMtx
+// held.
+func (node *Node) latest(key string) (Entry, bool, error) {
+	entry, ok, err := node.datastore.Get(key)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	replica, replicaOK, err := node.replicas.Get(key)
+	if err != nil {
+		return Entry{}, false, err
+	}
+
+	if replicaOK && (!ok || replica.newer(entry)) {
+		return replica, true, nil
+	}
+
+	return entry, ok, nil
+}
+
+// deleteKey replaces a key in the datastore with a tombstone. Deleting a key
+// the node does not have leaves a tombstone as well, since other nodes may
+// still have copies of it.
+func (node *Node) deleteKey(ctx context.Context, key string) error {
+	if node.datastore == nil {
+		return errNoDatastore
+	}
+
+	id, err := node.config.keyID(key)
+	if err != nil {
+		return err
+	}
+
+	// A tombstone is written like a value, so that it gets the next version.
+	node.dsMtx.Lock()
+	tombstone, _, err := node.storeKeyVal(id, &gmajpb.KeyVal{
+		Key: key, Deleted: true, Mode: gmajpb.PutMode_UPDATE,
+	})
+	node.dsMtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	node.replicate(ctx, key, tombstone)
 
 	return nil
 }
 
-func (node *Node) get(key string) ([]byte, error) {
-	remoteNode, err := node.locate(key)
+// keyValEntry returns the entry for a key/value sent over an RPC, which
+// arrived at now.
+func keyValEntry(keyVal *gmajpb.KeyVal, now time.Time) Entry {
+	entry := Entry{Val: keyVal.Val, Version: keyVal.Version, Deleted: keyVal.Deleted}
+	if keyVal.TtlNs > 0 {
+		entry.Expires = now.Add(time.Duration(keyVal.TtlNs))
+	}
+
+	return entry
+}
+
+// entryKeyVal returns the key/value to send an entry over an RPC with at now.
+func entryKeyVal(key string, entry Entry, now time.Time) *gmajpb.KeyVal {
+	keyVal := &gmajpb.KeyVal{
+		Key: key, Val: entry.Val, Version: entry.Version, Deleted: entry.Deleted,
+	}
+	if !entry.Expires.IsZero() {
+		// An expired key is sent with the shortest TTL rather than none, so
+		// that it expires on arrival.
+		keyVal.TtlNs = int64(entry.Expires.Sub(now))
+		if keyVal.TtlNs < 1 {
+			keyVal.TtlNs = 1
+		}
+	}
+
+	return keyVal
+}
+
+func (node *Node) get(ctx context.Context, key string) (Entry, error) {
+	remoteNode, err := node.locate(ctx, key)
 	if err != nil {
-		return nil, err
+		return Entry{}, err
 	}
 
 	// TODO(asubiotto): Smart retries on not found error. Implement channel
 	// that notifies when stabilize has been called.
 
-	// Retry on error because it might be due to temporary unavailability
-	// (e.g. write happened while transferring nodes).
-	val, err := node.getKeyRPC(remoteNode, key)
+	// Fall back to the copies on the successors of remoteNode, and then retry
+	// on error because it might be due to temporary unavailability (e.g. write
+	// happened while transferring nodes). Deleted keys are not retried.
+	entry, err := node.getKeyRPC(ctx, remoteNode, key)
+	if err != nil && !isDeleted(err) {
+		node.locations.invalidate(remoteNode)
+		entry, err = node.getFromReplicas(ctx, remoteNode, key)
+	}
+	if isDeleted(err) {
+		return Entry{}, err
+	}
 	if err != nil {
-		<-time.After(config.RetryInterval)
-		remoteNode, err = node.locate(key)
+		select {
+		case <-node.clock.After(node.config.RetryInterval):
+		case <-ctx.Done():
+			return Entry{}, ctx.Err()
+		}
+
+		remoteNode, err = node.locate(ctx, key)
 		if err != nil {
-			return nil, err
+			return Entry{}, err
 		}
 
-		val, err = node.getKeyRPC(remoteNode, key)
+		entry, err = node.getKeyRPC(ctx, remoteNode, key)
 		if err != nil {
-			return nil, err
+			return Entry{}, err
 		}
 	}
 
-	return val, nil
+	return entry, nil
 }
 
-func (node *Node) put(key string, val []byte) error {
-	remoteNode, err := node.locate(key)
+// put writes a key/value from a client (i.e. with a mode and maybe an expected
+// version) to the node that owns it, and returns the version it gets.
+func (node *Node) put(ctx context.Context, keyVal *gmajpb.KeyVal) (uint64, error) {
+	remoteNode, err := node.locate(ctx, keyVal.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	keyVal.CheckOwner = true
+	version, err := node.putKeyValRPC(ctx, remoteNode, keyVal)
+	if err == nil || isConflict(err) || !node.locations.invalidate(remoteNode) {
+		return version, err
+	}
+
+	// remoteNode may have come from the cache, so the owner of the key may
+	// have changed since it was looked up.
+	remoteNode, err = node.locate(ctx, keyVal.Key)
+	if err != nil {
+		return 0, err
+	}
+
+	return node.putKeyValRPC(ctx, remoteNode, keyVal)
+}
+
+func (node *Node) delete(ctx context.Context, key string) error {
+	remoteNode, err := node.locate(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	err = node.deleteKeyRPC(ctx, remoteNode, key)
+	if err == nil || !node.locations.invalidate(remoteNode) {
+		return err
+	}
+
+	// Like in put, the owner of the key may have changed.
+	remoteNode, err = node.locate(ctx, key)
 	if err != nil {
 		return err
 	}
 
-	return node.putKeyValRPC(remoteNode, key, val)
+	return node.deleteKeyRPC(ctx, remoteNode, key)
 }
 
-func (node *Node) transferKeys(fromID []byte, toNode *gmajpb.Node) error {
-	if idsEqual(toNode.Id, node.Id) {
+func (node *Node) transferKeys(
+	ctx context.Context, fromID []byte, toNode *gmajpb.Node,
+) error {
+	// toNode is empty when it is the predecessor of a node that has not found
+	// its own predecessor yet.
+	if toNode.Addr == "" || idsEqual(toNode.Id, node.Id) {
 		return nil
 	}
 
-	node.dsMtx.Lock()
-	defer node.dsMtx.Unlock()
+	// The watches on the keys have to move with them.
+	defer node.watchers.moved(newID(fromID), newID(toNode.Id))
 
-	toDelete := []string{}
-	for key, val := range node.datastore {
-		hashedKey, err := hashKey(key)
-		if err != nil {
+	// Find the keys to transfer first, since toNode may call back into this
+	// node to replicate them. Keys locked by prepared transactions are handed
+	// off when the transactions end instead.
+	toTransfer := make(map[string]Entry)
+	node.dsMtx.RLock()
+	err := node.datastore.Range(newID(fromID), newID(toNode.Id), func(key string, entry Entry) bool {
+		if node.checkLock(key) == nil {
+			toTransfer[key] = entry
+		}
+		return true
+	})
+	node.dsMtx.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for key, entry := range toTransfer {
+		if err := node.transferKey(ctx, key, entry, toNode); err != nil {
 			return err
 		}
+	}
 
-		// Check that the hashed_key lies in the correct range before putting
-		// the value in our predecessor.
-		if betweenRightIncl(hashedKey, fromID, toNode.Id) {
-			if err := node.putKeyValRPC(toNode, key, val); err != nil {
-				return err
-			}
+	return nil
+}
 
-			toDelete = append(toDelete, key)
+// transferKey sends a key to toNode, which now owns it, and keeps a copy of
+// it in the replicas.
+func (node *Node) transferKey(
+	ctx context.Context, key string, entry Entry, toNode *gmajpb.Node,
+) error {
+	for {
+		keyVal := entryKeyVal(key, entry, node.clock.Now())
+		keyVal.Transfer = true
+		if _, err := node.putKeyValRPC(ctx, toNode, keyVal); err != nil {
+			return err
 		}
+
+		// toNode is our predecessor, so we keep a copy of the key.
+		demoted, current, err := node.demoteKey(key, entry)
+		if err != nil {
+			return err
+		}
+		if demoted {
+			return nil
+		}
+
+		// The key changed (e.g. it was deleted) while it was being
+		// transferred, so the new entry is transferred as well.
+		entry = current
 	}
+}
 
-	for _, key := range toDelete {
-		delete(node.datastore, key)
+// demoteKey moves a key that the node no longer owns from the datastore to the
+// replicas, if its entry is still the one that was sent. Otherwise, it returns
+// the current entry.
+func (node *Node) demoteKey(key string, sent Entry) (bool, Entry, error) {
+	id, err := node.config.keyID(key)
+	if err != nil {
+		return false, Entry{}, err
 	}
 
-	return nil
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	entry, ok, err := node.datastore.Get(key)
+	if err != nil {
+		return false, Entry{}, err
+	}
+	if ok && !entry.equal(sent) {
+		return false, entry, nil
+	}
+
+	if err := node.datastore.Delete(key); err != nil {
+		return false, Entry{}, err
+	}
+
+	if node.config.ReplicationFactor == 0 {
+		return true, Entry{}, nil
+	}
+
+	return true, Entry{}, node.replicas.Put(id, key, sent)
 }
 
 // DatastoreString write the contents of a node's data store to stdout.
@@ -184,13 +662,13 @@ func (node *Node) DatastoreString() (str string) {
 
 	const maxLen = 64
 
-	node.dsMtx.RLock()
-	if len(node.datastore) == 0 {
-		defer node.dsMtx.RUnlock()
-		return
-	}
+	now := node.clock.Now()
+	err := node.datastore.Range(node.id, node.id, func(key string, entry Entry) bool {
+		if entry.Deleted || entry.expired(now) {
+			return true
+		}
 
-	for key, val := range node.datastore {
+		val := entry.Val
 		buf.WriteString("\n")
 		buf.WriteString(key)
 		buf.WriteString(": ")
@@ -199,8 +677,11 @@ func (node *Node) DatastoreString() (str string) {
 		} else {
 			buf.WriteString(fmt.Sprintf("%s", val))
 		}
+		return true
+	})
+	if err != nil {
+		buf.WriteString(fmt.Sprintf("\n(error: %v)", err))
 	}
-	node.dsMtx.RUnlock()
 
 	return
 }
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/r-medina/gmaj/gmajpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestGetNilNode(t *testing.T) {
@@ -20,7 +23,7 @@ func TestGetNilNode(t *testing.T) {
 func TestGetNoDataStore(t *testing.T) {
 	t.Parallel()
 
-	node := &Node{Node: new(gmajpb.Node)}
+	node := &Node{Node: new(gmajpb.Node), config: &config.nodeConfig}
 	_, err := node.getKey("")
 	if err == nil {
 		t.Fatal("Unexpected success getting value from nil datastore")
@@ -30,7 +33,7 @@ func TestGetNoDataStore(t *testing.T) {
 func TestGetNonExistentKey(t *testing.T) {
 	t.Parallel()
 
-	node, err := NewNode(nil)
+	node, err := NewNode(nil, WithTransport(testNetwork.Transport()))
 	if err != nil {
 		t.Fatalf("unexpected error making node: %v", err)
 	}
@@ -39,7 +42,7 @@ func TestGetNonExistentKey(t *testing.T) {
 	}
 
 	// Make sure entry was not created.
-	if _, exists := node.datastore["test"]; exists {
+	if _, exists, _ := node.datastore.Get("test"); exists {
 		t.Fatal("Unexpected entry in node datastore")
 	}
 }
@@ -47,7 +50,7 @@ func TestGetNonExistentKey(t *testing.T) {
 func TestGetKey(t *testing.T) {
 	t.Parallel()
 
-	node, err := NewNode(nil)
+	node, err := NewNode(nil, WithTransport(testNetwork.Transport()))
 	if err != nil {
 		t.Fatalf("unexpected error making node: %v", err)
 	}
@@ -77,7 +80,7 @@ func TestPutNilNode(t *testing.T) {
 func TestPutNoDataStore(t *testing.T) {
 	t.Parallel()
 
-	node := &Node{Node: new(gmajpb.Node)}
+	node := &Node{Node: new(gmajpb.Node), config: &config.nodeConfig}
 	err := Put(node, "", nil)
 	if err == nil {
 		t.Fatal("Unexpected success putting value in nil datastore")
@@ -87,7 +90,7 @@ func TestPutNoDataStore(t *testing.T) {
 func TestPutModifyExistingKey(t *testing.T) {
 	t.Parallel()
 
-	node, err := NewNode(nil)
+	node, err := NewNode(nil, WithTransport(testNetwork.Transport()))
 	if err != nil {
 		t.Fatalf("unexpected error making node: %v", err)
 	}
@@ -95,7 +98,7 @@ func TestPutModifyExistingKey(t *testing.T) {
 		t.Fatalf("Unexpected failure putting value: %v", err)
 	}
 
-	if _, exists := node.datastore["test"]; !exists {
+	if _, exists, _ := node.datastore.Get("test"); !exists {
 		t.Fatal("Unexpected error value not set")
 	}
 
@@ -112,7 +115,7 @@ func TestPutModifyExistingKey(t *testing.T) {
 func TestPutKey(t *testing.T) {
 	t.Parallel()
 
-	node, err := NewNode(nil)
+	node, err := NewNode(nil, WithTransport(testNetwork.Transport()))
 	if err != nil {
 		t.Fatalf("unexpected error making new node: %v", err)
 	}
@@ -132,10 +135,186 @@ func TestPutKey(t *testing.T) {
 	}
 }
 
+func TestDeleteNilNode(t *testing.T) {
+	t.Parallel()
+
+	if err := Delete(nil, ""); err == nil {
+		t.Fatal("Unexpected success deleting key from nil node")
+	}
+}
+
+func TestDeleteKey(t *testing.T) {
+	t.Parallel()
+
+	node, err := NewNode(nil, WithTransport(testNetwork.Transport()))
+	if err != nil {
+		t.Fatalf("unexpected error making node: %v", err)
+	}
+	if err := Put(node, "test", []byte("value")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	if err := Delete(node, "test"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	if _, err := Get(node, "test"); !isDeleted(err) {
+		t.Fatalf("Expected key to be deleted, got %v", err)
+	}
+
+	// Deleted keys can be written again.
+	if err := Put(node, "test", []byte("value2")); err != nil {
+		t.Fatalf("Unexpected error putting value after delete: %v", err)
+	}
+	if got, err := Get(node, "test"); err != nil {
+		t.Fatalf("Unexpected error getting value: %v", err)
+	} else if !reflect.DeepEqual(got, []byte("value2")) {
+		t.Fatalf("Unexpected value. Expected %q got %q", "value2", got)
+	}
+
+	// Deleting a key that does not exist is not an error.
+	if err := Delete(node, "missing"); err != nil {
+		t.Fatalf("Unexpected error deleting missing key: %v", err)
+	}
+}
+
+func TestStoreKeyVal(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	val := Entry{Val: []byte("value"), Version: 1}
+	other := Entry{Val: []byte("other"), Version: 1}
+	newer := Entry{Val: []byte("other"), Version: 2}
+	tombstone := Entry{Version: 2, Deleted: true}
+
+	tests := []struct {
+		old      *Entry
+		keyVal   gmajpb.KeyVal
+		transfer bool
+		exp      Entry
+		stored   bool
+		err      bool
+	}{
+		{old: nil, keyVal: gmajpb.KeyVal{Val: val.Val}, exp: val, stored: true},
+		{old: &val, keyVal: gmajpb.KeyVal{Val: val.Val}, exp: val, stored: false},
+		{old: &val, keyVal: gmajpb.KeyVal{Val: other.Val}, err: true},
+		{
+			old:    &tombstone,
+			keyVal: gmajpb.KeyVal{Val: val.Val},
+			exp:    Entry{Val: val.Val, Version: 3},
+			stored: true,
+		},
+		{
+			old:    &val,
+			keyVal: gmajpb.KeyVal{Val: other.Val, Mode: gmajpb.PutMode_UPDATE},
+			exp:    newer,
+			stored: true,
+		},
+		{
+			old:    nil,
+			keyVal: gmajpb.KeyVal{Val: val.Val, Mode: gmajpb.PutMode_UPDATE},
+			exp:    val,
+			stored: true,
+		},
+		{
+			old: &val,
+			keyVal: gmajpb.KeyVal{
+				Val: other.Val, Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 1,
+			},
+			exp:    newer,
+			stored: true,
+		},
+		{
+			old: &newer,
+			keyVal: gmajpb.KeyVal{
+				Val: val.Val, Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 1,
+			},
+			err: true,
+		},
+		// The live version of a deleted key is 0.
+		{
+			old: &tombstone,
+			keyVal: gmajpb.KeyVal{
+				Val: val.Val, Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 2,
+			},
+			err: true,
+		},
+		// Transferred keys keep their versions, and only replace older ones.
+		{old: nil, keyVal: *entryKeyVal("", newer, now), transfer: true, exp: newer, stored: true},
+		{old: &tombstone, keyVal: *entryKeyVal("", val, now), transfer: true, exp: tombstone, stored: false},
+		{old: &val, keyVal: *entryKeyVal("", tombstone, now), transfer: true, exp: tombstone, stored: true},
+		{old: &newer, keyVal: *entryKeyVal("", newer, now), transfer: true, exp: newer, stored: false},
+	}
+	for i, test := range tests {
+		node := &Node{
+			Node: new(gmajpb.Node), config: &config.nodeConfig, clock: wallClock{},
+			datastore: newMemStore(), replicas: newMemStore(),
+		}
+		if test.old != nil {
+			_ = node.datastore.Put(ID{}, "key", *test.old)
+		}
+
+		keyVal := test.keyVal
+		keyVal.Key = "key"
+		keyVal.Transfer = test.transfer
+
+		entry, stored, err := node.storeKeyVal(ID{}, &keyVal)
+		if (err != nil) != test.err {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if err != nil {
+			continue
+		}
+		if stored != test.stored {
+			t.Fatalf("[%02d] expected stored to be %v", i, test.stored)
+		}
+		if !reflect.DeepEqual(entry, test.exp) {
+			t.Fatalf("[%02d] expected %v, got %v", i, test.exp, entry)
+		}
+		if got, _, _ := node.datastore.Get("key"); !reflect.DeepEqual(got, test.exp) {
+			t.Fatalf("[%02d] expected %v to be stored, got %v", i, test.exp, got)
+		}
+	}
+}
+
+func TestVersions(t *testing.T) {
+	t.Parallel()
+
+	node := createDefinedNode(t, nil, []byte{0x31})
+	defer node.Shutdown()
+
+	if err := Put(node, "key", []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if err := Put(node, "key", []byte("b")); grpc.Code(err) != codes.AlreadyExists {
+		t.Fatalf("Expected putting an existing key to fail, got %v", err)
+	}
+
+	version, err := Update(node, "key", []byte("b"))
+	if err != nil {
+		t.Fatalf("Unexpected error updating value: %v", err)
+	}
+	if version != 2 {
+		t.Fatalf("Expected version 2 after update, got %v", version)
+	}
+
+	if _, err := CompareAndSwap(node, "key", []byte("c"), 1); grpc.Code(err) != codes.Aborted {
+		t.Fatalf("Expected swapping a stale version to fail, got %v", err)
+	}
+	if version, err = CompareAndSwap(node, "key", []byte("c"), 2); err != nil {
+		t.Fatalf("Unexpected error swapping value: %v", err)
+	}
+
+	if val, got, err := GetVersion(node, "key"); err != nil {
+		t.Fatalf("Unexpected error getting value: %v", err)
+	} else if got != version || !reflect.DeepEqual(val, []byte("c")) {
+		t.Fatalf("Expected %q at version %v, got %q at %v", "c", version, val, got)
+	}
+}
+
 func TestTransferKeys(t *testing.T) {
 	t.Parallel()
 	key := "myKey"
-	hashedKey, err := hashKey(key)
+	hashedKey, err := config.hashKey(key)
 	if err != nil {
 		t.Fatalf("unexpected error hashing key: %v", err)
 	}
@@ -149,7 +328,7 @@ func TestTransferKeys(t *testing.T) {
 	}
 
 	// Make node that should get the key transferred to it.
-	hashedKey, err = hashKey(key)
+	hashedKey, err = config.hashKey(key)
 	if err != nil {
 		t.Fatalf("unexpected error hashing key: %v", err)
 	}
@@ -161,15 +340,69 @@ func TestTransferKeys(t *testing.T) {
 	// Make sure that "spacetravel!" is in node2.
 	if got, err := node2.getKey(key); err != nil {
 		t.Fatalf("Unexpected error getting value from node2: %v", err)
-	} else if !reflect.DeepEqual(got, want) {
+	} else if !reflect.DeepEqual(got.Val, want) {
 		t.Fatalf("Unexpected value")
 	}
 }
 
+func TestTTL(t *testing.T) {
+	t.Parallel()
+	key := "session"
+	hashedKey, err := config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+
+	hashedKey[0] += 2
+	node1 := createDefinedNode(t, nil, hashedKey)
+	defer node1.Shutdown()
+
+	start := time.Now()
+	if _, err := UpdateWithTTL(node1, key, []byte("a"), time.Minute); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	// The key keeps its expiry when it moves to node2.
+	hashedKey, err = config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+	hashedKey[0]++
+	node2 := createDefinedNode(t, node1.Node, hashedKey)
+	defer node2.Shutdown()
+
+	<-time.After(testTimeout)
+
+	if entry, ok, _ := node2.datastore.Get(key); !ok {
+		t.Fatal("Expected node2 to own the key")
+	} else if entry.Expires.Before(start.Add(time.Minute-testTimeout)) || entry.Expires.After(start.Add(time.Minute+testTimeout)) {
+		t.Fatalf("Expected key to expire about a minute after %v, got %v", start, entry.Expires)
+	}
+
+	if _, err := UpdateWithTTL(node1, key, []byte("b"), testTimeout/2); err != nil {
+		t.Fatalf("Unexpected error updating value: %v", err)
+	}
+	if got, err := Get(node1, key); err != nil || !reflect.DeepEqual(got, []byte("b")) {
+		t.Fatalf("Expected %q before the key expired, got %q, %v", "b", got, err)
+	}
+
+	<-time.After(testTimeout)
+
+	if _, err := Get(node1, key); err == nil {
+		t.Fatal("Expected key to have expired")
+	}
+	if _, ok, _ := node2.datastore.Get(key); ok {
+		t.Fatal("Expected key to be reaped from node2's datastore")
+	}
+	if _, ok, _ := node1.replicas.Get(key); ok {
+		t.Fatal("Expected key to be reaped from node1's replicas")
+	}
+}
+
 func TestTransferKeysAvailability(t *testing.T) {
 	// Tests that key stays available during transfer.
 	key := "myKey"
-	hashedKey, err := hashKey(key)
+	hashedKey, err := config.hashKey(key)
 	if err != nil {
 		t.Fatalf("unexpected error hashing key: %v", err)
 	}
@@ -204,7 +437,7 @@ func TestTransferKeysAvailability(t *testing.T) {
 	}()
 
 	// Make node that should get the key transferred to it.
-	hashedKey, err = hashKey(key)
+	hashedKey, err = config.hashKey(key)
 	if err != nil {
 		t.Fatalf("unexpected error hashing key: %v", err)
 	}
@@ -217,7 +450,7 @@ func TestTransferKeysAvailability(t *testing.T) {
 	// Make sure that "spacetravel!" is in node2.
 	if got, err := node2.getKey(key); err != nil {
 		t.Fatalf("Unexpected error getting value from node2:%v\n", err)
-	} else if !reflect.DeepEqual(got, want) {
+	} else if !reflect.DeepEqual(got.Val, want) {
 		t.Fatalf("Unexpected value")
 	}
 	<-time.After(testTimeout)
@@ -240,7 +473,7 @@ func TestKeyTransferAfterShutdownSimple(t *testing.T) {
 
 	// stores all the data in chord
 	data := make(map[string][]byte)
-	for k, v := range node1.datastore {
+	for k, v := range storeContents(t, node1.datastore) {
 		data[k] = v
 	}
 
@@ -279,20 +512,20 @@ func TestKeyTransferAfterShutdown(t *testing.T) {
 
 	// stores all the data in chord
 	data := make(map[string][]byte)
-	for k, v := range node1.datastore {
+	for k, v := range storeContents(t, node1.datastore) {
 		data[k] = v
 	}
-	for k, v := range node2.datastore {
+	for k, v := range storeContents(t, node2.datastore) {
 		data[k] = v
 	}
-	for k, v := range node3.datastore {
+	for k, v := range storeContents(t, node3.datastore) {
 		data[k] = v
 	}
 
 	node1.Shutdown()
 
 	// makes sure node1 transfered its keys
-	if l := len(node1.datastore); l > 0 {
+	if l := len(storeContents(t, node1.datastore)); l > 0 {
 		t.Fatalf("node1 should not have anything left in its data, but there are %v items\n", l)
 	}
 
@@ -311,7 +544,7 @@ func TestKeyTransferAfterShutdown(t *testing.T) {
 	node2.Shutdown()
 
 	// makes sure node3 transfered its keys
-	if l := len(node2.datastore); l > 0 {
+	if l := len(storeContents(t, node2.datastore)); l > 0 {
 		t.Fatalf("node2 should not have anything left in its data, but there are %v items\n", l)
 	}
 
@@ -335,7 +568,7 @@ func TestKeyTransferAfterShutdown(t *testing.T) {
 	node3.Shutdown()
 
 	// makes sure node3 transfered its keys
-	if l := len(node3.datastore); l > 0 {
+	if l := len(storeContents(t, node3.datastore)); l > 0 {
 		t.Fatalf(
 			"node3 should not have anything left in its data, but there are %v items\n", l,
 		)
@@ -0,0 +1,445 @@
+//
+//  a storage engine that keeps a node's keys in an append-only log on disk, so
+//  they survive restarts
+//
+
+package gmaj
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Operations recorded in the log of a diskStore.
+const (
+	diskPut byte = iota + 1
+	diskDelete
+)
+
+// minCompactRecords is the number of records the log of a diskStore must
+// have before it is compacted.
+const minCompactRecords = 1024
+
+// errBadRecord indicates that a record in the log is incomplete or corrupt.
+var errBadRecord = errors.New("gmaj: bad log record")
+
+// DiskStore returns an OpenStore for stores that keep their keys in dir, to be
+// passed to a node with WithStore. Each node gets a subdirectory named after
+// its ID, so a node that restarts with the same ID recovers its keys.
+//
+// Every change is appended to a log and synced before it is applied, and the
+// log is rewritten with just the live keys when most of it is garbage. The keys
+// are also kept in memory.
+func DiskStore(dir string) OpenStore {
+	return func(id []byte, name string) (Store, error) {
+		return openDiskStore(filepath.Join(dir, fmt.Sprintf("%x", id), name+".log"))
+	}
+}
+
+// diskStore is a memStore backed by a log of the changes made to it.
+type diskStore struct {
+	*memStore
+
+	path    string
+	file    logFile
+	size    int64 // size of the complete records in the log
+	records int   // number of records in the log
+
+	// err is set if a failed write could not be cut from the end of the log,
+	// after which nothing more can be appended to it.
+	err error
+
+	// compactAt is the number of records at which the log is compacted.
+	// Compacting once the log is twice the live keys bounds the work done
+	// per write.
+	compactAt int
+
+	mtx sync.Mutex // serializes writes to the log
+}
+
+var _ Store = (*diskStore)(nil)
+
+// logFile is the file the log of a diskStore is in. It is an *os.File, except
+// in tests that make writes to it fail.
+type logFile interface {
+	io.ReadWriteCloser
+	io.Seeker
+	Stat() (os.FileInfo, error)
+	Sync() error
+	Truncate(size int64) error
+}
+
+// openDiskStore opens the store logged at path, creating it if necessary. A
+// partially written record at the end of the log, left by a crash, is
+// discarded, but a bad record anywhere else is an error.
+func openDiskStore(path string) (*diskStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &diskStore{memStore: newMemStore(), path: path, file: file}
+	if s.size, err = s.replay(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	// Drop anything after the last complete record.
+	if err := s.truncate(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	s.setCompactAt()
+
+	return s, nil
+}
+
+// replay applies the records in the log to the in-memory keys, and returns the
+// size of the complete records.
+func (s *diskStore) replay() (int64, error) {
+	info, err := s.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(s.file)
+
+	var size int64
+	for {
+		op, id, key, entry, n, err := readRecord(r, info.Size()-size)
+		if err == io.EOF {
+			return size, nil
+		}
+		if err == errBadRecord {
+			// Records are appended one at a time, so only the last one can
+			// have been cut short by a crash. Anything else is corruption,
+			// and dropping the records after it would lose keys.
+			if size+n >= info.Size() {
+				return size, nil
+			}
+			return 0, fmt.Errorf("%v at offset %d of %v", err, size, s.path)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		switch op {
+		case diskPut:
+			s.entries[key] = memEntry{id: id, Entry: entry}
+		case diskDelete:
+			delete(s.entries, key)
+		}
+
+		size += n
+		s.records++
+	}
+}
+
+func (s *diskStore) Put(id ID, key string, entry Entry) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := s.append(diskPut, id, key, entry); err != nil {
+		return err
+	}
+
+	if err := s.memStore.Put(id, key, entry); err != nil {
+		return err
+	}
+
+	s.maybeCompact()
+
+	return nil
+}
+
+func (s *diskStore) Delete(key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok, _ := s.memStore.Get(key); !ok {
+		return nil
+	}
+
+	if err := s.append(diskDelete, ID{}, key, Entry{}); err != nil {
+		return err
+	}
+
+	if err := s.memStore.Delete(key); err != nil {
+		return err
+	}
+
+	s.maybeCompact()
+
+	return nil
+}
+
+func (s *diskStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.file.Close()
+}
+
+// append writes a record to the log and syncs it. It must be called with the
+// lock held.
+func (s *diskStore) append(op byte, id ID, key string, entry Entry) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	record := encodeRecord(op, id, key, entry)
+	_, err := s.file.Write(record)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		// The record may be in the log in part, or in full without being
+		// durable, and the change is not made. Only the last record of a
+		// log can be bad, so it is cut off before anything else is appended.
+		if truncErr := s.truncate(); truncErr != nil {
+			s.err = fmt.Errorf("gmaj: cannot write to %v after failing to undo a write: %v",
+				s.path, truncErr,
+			)
+		}
+		return err
+	}
+
+	s.size += int64(len(record))
+	s.records++
+
+	return nil
+}
+
+// truncate drops anything after the complete records in the log, and moves to
+// the end of them. It must be called with the lock held.
+func (s *diskStore) truncate() error {
+	if err := s.file.Truncate(s.size); err != nil {
+		return err
+	}
+
+	_, err := s.file.Seek(s.size, io.SeekStart)
+	return err
+}
+
+// maybeCompact compacts the log if it is due. The change that triggered it has
+// been made already, so failing to compact is not an error: the log in use
+// still has every change, and compacting is tried again when it is next due.
+// It must be called with the lock held.
+func (s *diskStore) maybeCompact() {
+	if s.records >= s.compactAt {
+		_ = s.compact()
+	}
+}
+
+// compact replaces the log with one that only has the live keys. It must be
+// called with the lock held.
+func (s *diskStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	// The keys only change with the lock held, so they can be read without
+	// locking the memStore.
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for key, entry := range s.entries {
+		record := encodeRecord(diskPut, entry.id, key, entry.Entry)
+		if _, err = w.Write(record); err != nil {
+			break
+		}
+		size += int64(len(record))
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	// tmp is now the log, and its offset is at the end. It has to be written
+	// to from now on, even if the rename cannot be synced, since the old log
+	// is unlinked.
+	_ = s.file.Close()
+	s.file = tmp
+	s.size = size
+	s.records = len(s.entries)
+	s.setCompactAt()
+
+	return syncDir(filepath.Dir(s.path))
+}
+
+// setCompactAt schedules the next compaction for when the log has twice as
+// many records as there are live keys.
+func (s *diskStore) setCompactAt() {
+	s.compactAt = 2 * len(s.entries)
+	if s.compactAt < minCompactRecords {
+		s.compactAt = minCompactRecords
+	}
+}
+
+// syncDir syncs a directory, so that renames in it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// A record in the log is
+//
+//	crc32 (4 bytes) | length (4 bytes) | op (1 byte) | id (idWords * 8 bytes) |
+//	key length (uvarint) | key | flags (1 byte) | [version (uvarint)] |
+//	[expiry (varint Unix nanoseconds)] | value
+//
+// where the length covers everything after it, and the checksum covers the
+// length and everything after it. The flags describe the entry, and fields
+// that are added to entries are flagged as present, so that older logs can
+// still be read.
+
+const recordHeaderLen = 8
+
+// Flags of the entry in a record.
+const (
+	recordDeleted byte = 1 << iota
+	recordVersion
+	recordExpires
+)
+
+func encodeRecord(op byte, id ID, key string, entry Entry) []byte {
+	payloadLen := 1 + idWords*8 + 3*binary.MaxVarintLen64 + len(key) + 1 + len(entry.Val)
+	buf := make([]byte, recordHeaderLen, recordHeaderLen+payloadLen)
+
+	buf = append(buf, op)
+	for _, word := range id {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], word)
+		buf = append(buf, b[:]...)
+	}
+
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(len(key)))]...)
+	buf = append(buf, key...)
+
+	var flags byte
+	if entry.Deleted {
+		flags |= recordDeleted
+	}
+	if entry.Version != 0 {
+		flags |= recordVersion
+	}
+	if !entry.Expires.IsZero() {
+		flags |= recordExpires
+	}
+	buf = append(buf, flags)
+	if entry.Version != 0 {
+		buf = append(buf, n[:binary.PutUvarint(n[:], entry.Version)]...)
+	}
+	if !entry.Expires.IsZero() {
+		buf = append(buf, n[:binary.PutVarint(n[:], entry.Expires.UnixNano())]...)
+	}
+	buf = append(buf, entry.Val...)
+
+	binary.BigEndian.PutUint32(buf[4:8], uint32(len(buf)-recordHeaderLen))
+	binary.BigEndian.PutUint32(buf[0:4], crc32.ChecksumIEEE(buf[4:]))
+
+	return buf
+}
+
+// readRecord reads a record of at most max bytes from r, and returns its
+// contents and size. It returns io.EOF at the end of the log, and errBadRecord
+// if the record is cut short or does not match its checksum. Once the header
+// of a bad record is read, n is the size the record claims to have.
+func readRecord(r io.Reader, max int64) (op byte, id ID, key string, entry Entry, n int64, err error) {
+	var header [recordHeaderLen]byte
+	if _, err = io.ReadFull(r, header[:]); err == io.ErrUnexpectedEOF {
+		err = errBadRecord
+	}
+	if err != nil {
+		return
+	}
+
+	payloadLen := int64(binary.BigEndian.Uint32(header[4:8]))
+	n = recordHeaderLen + payloadLen
+	if payloadLen > max-recordHeaderLen {
+		err = errBadRecord
+		return
+	}
+
+	payload := make([]byte, payloadLen)
+	if _, err = io.ReadFull(r, payload); err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = errBadRecord
+	}
+	if err != nil {
+		return
+	}
+
+	crc := crc32.Update(crc32.ChecksumIEEE(header[4:8]), crc32.IEEETable, payload)
+	if crc != binary.BigEndian.Uint32(header[0:4]) || len(payload) < 1+idWords*8 {
+		err = errBadRecord
+		return
+	}
+
+	op, payload = payload[0], payload[1:]
+	for i := range id {
+		id[i] = binary.BigEndian.Uint64(payload[i*8:])
+	}
+	payload = payload[idWords*8:]
+
+	keyLen, m := binary.Uvarint(payload)
+	if m <= 0 || keyLen >= uint64(len(payload)-m) {
+		err = errBadRecord
+		return
+	}
+	payload = payload[m:]
+
+	key, payload = string(payload[:keyLen]), payload[keyLen:]
+
+	flags := payload[0]
+	payload = payload[1:]
+	entry.Deleted = flags&recordDeleted != 0
+	if flags&recordVersion != 0 {
+		if entry.Version, m = binary.Uvarint(payload); m <= 0 {
+			err = errBadRecord
+			return
+		}
+		payload = payload[m:]
+	}
+	if flags&recordExpires != 0 {
+		expires, m := binary.Varint(payload)
+		if m <= 0 {
+			err = errBadRecord
+			return
+		}
+		entry.Expires = time.Unix(0, expires)
+		payload = payload[m:]
+	}
+	entry.Val = payload
+
+	return
+}
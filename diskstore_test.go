@@ -0,0 +1,218 @@
+package gmaj
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiskStoreRecovery(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %v", err)
+	}
+
+	want := map[string][]byte{"a": []byte("1"), "c": []byte("3")}
+	_ = store.Put(newID([]byte{1}), "a", Entry{Val: []byte("0")})
+	_ = store.Put(newID([]byte{1}), "a", Entry{Val: []byte("1"), Version: 2})
+	_ = store.Put(newID([]byte{2}), "b", Entry{Val: []byte("2")})
+	_ = store.Put(newID([]byte{3}), "c", Entry{Val: []byte("3"), Expires: time.Unix(0, 42)})
+	_ = store.Put(newID([]byte{5}), "e", Entry{Deleted: true})
+	if err := store.Delete("b"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Unexpected error closing store: %v", err)
+	}
+
+	// Simulate a crash in the middle of appending a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := encodeRecord(diskPut, newID([]byte{4}), "d", Entry{Val: []byte("4")})
+	if _, err := f.Write(record[:len(record)-1]); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	store, err = openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %v", err)
+	}
+	if got := storeContents(t, store); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v after recovery, got %v", want, got)
+	}
+	if entry, ok, _ := store.Get("a"); !ok || !reflect.DeepEqual(entry.Val, []byte("1")) || entry.Version != 2 {
+		t.Fatalf("Expected a to be 1 at version 2, got %q at %v", entry.Val, entry.Version)
+	}
+	if entry, ok, _ := store.Get("c"); !ok || !entry.Expires.Equal(time.Unix(0, 42)) {
+		t.Fatalf("Expected c to expire at %v, got %v", time.Unix(0, 42), entry.Expires)
+	}
+	if entry, ok, _ := store.Get("e"); !ok || !entry.Deleted {
+		t.Fatalf("Expected e to have a tombstone, got %v", entry)
+	}
+
+	// The partial record is gone, so new ones can be read back.
+	want["d"] = []byte("4")
+	_ = store.Put(newID([]byte{4}), "d", Entry{Val: []byte("4")})
+	_ = store.Close()
+
+	store, err = openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %v", err)
+	}
+	defer store.Close()
+	if got := storeContents(t, store); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v after recovery, got %v", want, got)
+	}
+}
+
+func TestDiskStoreCorruption(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %v", err)
+	}
+	_ = store.Put(newID([]byte{1}), "a", Entry{Val: []byte("1")})
+	_ = store.Put(newID([]byte{2}), "b", Entry{Val: []byte("2")})
+	_ = store.Close()
+
+	// Unlike a partial record at the end, a bad record in the middle of the
+	// log is not dropped along with the records after it.
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(encodeRecord(diskPut, newID([]byte{1}), "a", Entry{Val: []byte("1")}))-1] ^= 0xff
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openDiskStore(path); err == nil {
+		t.Fatal("Unexpected success opening a corrupt log")
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(len(b)) {
+		t.Fatalf("Expected the corrupt log to be left alone, got %v, %v", info, err)
+	}
+}
+
+// shortFile is a logFile whose writes stop part of the way and fail.
+type shortFile struct {
+	logFile
+}
+
+var errShortWrite = errors.New("short write")
+
+func (f shortFile) Write(b []byte) (int, error) {
+	n, err := f.logFile.Write(b[:len(b)/2])
+	if err != nil {
+		return n, err
+	}
+	return n, errShortWrite
+}
+
+func TestDiskStoreFailedWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %v", err)
+	}
+	_ = store.Put(newID([]byte{1}), "a", Entry{Val: []byte("1")})
+
+	// A write that fails part of the way does not leave half a record in
+	// the log for the next one to go after.
+	file := store.file
+	store.file = shortFile{file}
+	if err := store.Put(newID([]byte{2}), "b", Entry{Val: []byte("2")}); err != errShortWrite {
+		t.Fatalf("Expected %v, got %v", errShortWrite, err)
+	}
+	if _, ok, _ := store.Get("b"); ok {
+		t.Fatal("Expected the failed put not to be applied")
+	}
+	store.file = file
+	_ = store.Put(newID([]byte{3}), "c", Entry{Val: []byte("3")})
+	_ = store.Close()
+
+	store, err = openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %v", err)
+	}
+	defer store.Close()
+
+	want := map[string][]byte{"a": []byte("1"), "c": []byte("3")}
+	if got := storeContents(t, store); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v after reopening, got %v", want, got)
+	}
+}
+
+func TestDiskStoreCompaction(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store.log")
+	store, err := openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %v", err)
+	}
+	store.compactAt = 10
+
+	for i := 0; i < 9; i++ {
+		_ = store.Put(newID([]byte{byte(i % 3)}), string('a'+rune(i%3)), Entry{Val: []byte{byte(i)}})
+	}
+	before, _ := os.Stat(path)
+	_ = store.Put(newID([]byte{3}), "d", Entry{Val: []byte{9}})
+	after, _ := os.Stat(path)
+
+	if store.records != 4 {
+		t.Fatalf("Expected 4 records after compacting, got %v", store.records)
+	}
+	if after.Size() >= before.Size() {
+		t.Fatalf("Expected log to shrink from %v bytes, got %v", before.Size(), after.Size())
+	}
+
+	_ = store.Close()
+	store, err = openDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reopening store: %v", err)
+	}
+	defer store.Close()
+
+	want := map[string][]byte{"a": {6}, "b": {7}, "c": {8}, "d": {9}}
+	if got := storeContents(t, store); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v after compacting, got %v", want, got)
+	}
+}
+
+func TestDiskStoreRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	id := []byte{0x42}
+
+	node := createDefinedNode(t, nil, id, WithStore(DiskStore(dir)))
+	if err := Put(node, "key", []byte("val")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	crashNode(node)
+	node.closeStores()
+
+	node = createDefinedNode(t, nil, id, WithStore(DiskStore(dir)))
+	defer node.Shutdown()
+
+	if got, err := Get(node, "key"); err != nil {
+		t.Fatalf("Unexpected error getting value after restart: %v", err)
+	} else if !reflect.DeepEqual(got, []byte("val")) {
+		t.Fatalf("Unexpected value. Expected %q got %q", "val", got)
+	}
+}
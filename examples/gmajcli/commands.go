@@ -68,6 +68,28 @@ var cmds = map[string]command{
 		return
 	},
 
+	"update": func(nodes []*gmaj.Node, args ...string) (stop bool) {
+		if len(args) > 1 {
+			version, err := gmaj.Update(nodes[0], args[0], []byte(args[1]))
+			if err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Printf("version %d\n", version)
+			}
+		}
+		return
+	},
+
+	"delete": func(nodes []*gmaj.Node, args ...string) (stop bool) {
+		if len(args) > 0 {
+			err := gmaj.Delete(nodes[0], args[0])
+			if err != nil {
+				fmt.Println(err)
+			}
+		}
+		return
+	},
+
 	"help": func(nodes []*gmaj.Node, args ...string) (stop bool) {
 		fmt.Printf("available commands: %v\n", allCmds)
 		return
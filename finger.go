@@ -3,17 +3,18 @@ package gmaj
 import (
 	"bytes"
 	"fmt"
-	"math/big"
 
 	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
 )
 
 type fingerTable []*fingerEntry
 
-func newFingerTable(node *gmajpb.Node) fingerTable {
-	ft := make([]*fingerEntry, config.KeySize)
+func newFingerTable(cfg *nodeConfig, node *gmajpb.Node) fingerTable {
+	ft := make([]*fingerEntry, cfg.KeySize)
 	for i := range ft {
-		ft[i] = newFingerEntry(fingerMath(node.Id, i, config.KeySize), node)
+		ft[i] = newFingerEntry(cfg.fingerMath(newID(node.Id), i), node)
 	}
 
 	return ft
@@ -27,7 +28,7 @@ func (ft fingerTable) String() string {
 	for _, val := range ft {
 		buf.WriteString(fmt.Sprintf(
 			"\n\t{start:%v\tnodeID:%v %v}",
-			IDToString(val.StartID),
+			val.StartID,
 			IDToString(val.RemoteNode.Id),
 			val.RemoteNode.Addr,
 		))
@@ -38,12 +39,14 @@ func (ft fingerTable) String() string {
 
 // fingerEntry represents a single finger table entry
 type fingerEntry struct {
-	StartID    []byte       // ID hash of (n + 2^i) mod (2^m)
+	StartID    ID           // ID hash of (n + 2^i) mod (2^m)
 	RemoteNode *gmajpb.Node // RemoteNode that Start points to
+
+	suspect bool // RemoteNode failed to respond during a lookup
 }
 
 // newFingerEntry returns an allocated new finger entry with the attributes set
-func newFingerEntry(startID []byte, remoteNode *gmajpb.Node) *fingerEntry {
+func newFingerEntry(startID ID, remoteNode *gmajpb.Node) *fingerEntry {
 	return &fingerEntry{
 		StartID:    startID,
 		RemoteNode: remoteNode,
@@ -53,10 +56,10 @@ func newFingerEntry(startID []byte, remoteNode *gmajpb.Node) *fingerEntry {
 // fixNextFinger runs periodically (in a seperate go routine)
 // to fix entries in our finger table.
 func (node *Node) fixNextFinger(next int) int {
-	nextHash := fingerMath(node.Id, next, config.KeySize)
-	succ, err := node.findSuccessor(nextHash)
+	nextHash := node.config.fingerMath(node.id, next)
+	succ, err := node.findSuccessor(context.Background(), nextHash)
 	if err != nil {
-		// TODO: handle failed client here
+		// Lookups route around the finger until we manage to fix it.
 		return next
 	}
 
@@ -65,7 +68,20 @@ func (node *Node) fixNextFinger(next int) int {
 	node.fingerTable[next] = finger
 	node.ftMtx.Unlock()
 
-	return (next + 1) % config.KeySize
+	return (next + 1) % node.config.KeySize
+}
+
+// suspect marks all the fingers pointing to a node that failed to respond as
+// suspect, so lookups skip them until they are fixed by fixNextFinger.
+func (node *Node) suspect(remoteNode *gmajpb.Node) {
+	node.ftMtx.Lock()
+	defer node.ftMtx.Unlock()
+
+	for _, finger := range node.fingerTable {
+		if finger.RemoteNode != nil && idsEqual(finger.RemoteNode.Id, remoteNode.Id) {
+			finger.suspect = true
+		}
+	}
 }
 
 // FingerTableString takes a node and converts it's finger table into a string.
@@ -78,14 +94,9 @@ func (node *Node) FingerTableString() string {
 
 // fingerMath does the `(n + 2^i) mod (2^m)` operation
 // needed to update finger table entries.
-func fingerMath(n []byte, i int, m int) []byte {
-	iInt := big.NewInt(2)
-	iInt.Exp(iInt, big.NewInt(int64(i)), config.max)
-	mInt := big.NewInt(2)
-	mInt.Exp(mInt, big.NewInt(int64(m)), config.max)
-
-	res := &big.Int{} // res will pretty much be an accumulator
-	res.SetBytes(n).Add(res, iInt).Mod(res, mInt)
+func (cfg *nodeConfig) fingerMath(n ID, i int) ID {
+	var pow ID
+	pow[idWords-1-i/64] = 1 << uint(i%64)
 
-	return padID(res.Bytes())
+	return n.add(pow).and(cfg.mask)
 }
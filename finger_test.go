@@ -1,7 +1,6 @@
 package gmaj
 
 import (
-	"bytes"
 	"math/big"
 	"reflect"
 	"testing"
@@ -19,11 +18,11 @@ func TestInitFingerTable(t *testing.T) {
 		t.Fatalf("Expected finger table length %v, got %v.", want, got)
 	}
 	node.ftMtx.RLock()
-	if !bytes.Equal(node.fingerTable[0].StartID, addIDs(node.Id, []byte{1})) {
+	if node.fingerTable[0].StartID != newID(node.Id).add(newID([]byte{1})) {
 		node.ftMtx.RUnlock()
 		t.Fatalf("First finger entry start is wrong. got %v, expected %v",
 			node.fingerTable[0].StartID,
-			addIDs(node.Id, []byte{1}))
+			newID(node.Id).add(newID([]byte{1})))
 	}
 	node.ftMtx.RUnlock()
 
@@ -37,11 +36,12 @@ func TestInitFingerTable(t *testing.T) {
 func TestFixNextFinger(t *testing.T) {
 	t.Parallel()
 
-	node1 := &Node{Node: new(gmajpb.Node)}
+	node1 := &Node{Node: new(gmajpb.Node), config: &config.nodeConfig}
 	node1.Id = []byte{10}
+	node1.id = newID(node1.Id)
 	node1.Addr = "localhost"
 	node1.ftMtx.Lock()
-	node1.fingerTable = newFingerTable(node1.Node)
+	node1.fingerTable = newFingerTable(node1.config, node1.Node)
 	next := 1
 	next = node1.fixNextFinger(next) // shouldn't do anything because no rpc
 
@@ -49,7 +49,7 @@ func TestFixNextFinger(t *testing.T) {
 		t.Fatalf("next should not have changed.")
 	}
 
-	if !bytes.Equal(node1.fingerTable[0].StartID, addIDs(node1.Id, []byte{1})) {
+	if node1.fingerTable[0].StartID != newID(node1.Id).add(newID([]byte{1})) {
 		t.Fatalf("First finger entry start is wrong.")
 	}
 
@@ -57,7 +57,7 @@ func TestFixNextFinger(t *testing.T) {
 		t.Fatalf("Finger entry does not point to itself.")
 	}
 
-	node1, err := NewNode(nil)
+	node1, err := NewNode(nil, WithTransport(testNetwork.Transport()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -100,11 +100,44 @@ func TestFingerMath(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		result := fingerMath(big.NewInt(test.n).Bytes(), test.i, config.KeySize)
-		want, got := padID(big.NewInt(test.exp).Bytes()), result
-		if !bytes.Equal(got, want) {
+		result := config.fingerMath(newID(big.NewInt(test.n).Bytes()), test.i)
+		want, got := newID(big.NewInt(test.exp).Bytes()), result
+		if got != want {
 			t.Logf("running test [%02d]", i)
-			t.Fatalf("Expected %v, got %v.", test.exp, (&big.Int{}).SetBytes(result))
+			t.Fatalf("Expected %v, got %v.", test.exp, result)
+		}
+	}
+}
+
+func TestFingerMathKeySize(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.KeySize = 12
+	cfg.IDLength = 2
+	nodeCfg, err := newNodeConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n   int64
+		i   int
+		exp int64
+	}{
+		{n: 0, i: 0, exp: 1},
+		{n: 4095, i: 0, exp: 0},
+		{n: 4000, i: 11, exp: 1952},
+		{n: 2048, i: 11, exp: 0},
+		{n: 255, i: 8, exp: 511},
+	}
+
+	for i, test := range tests {
+		result := nodeCfg.fingerMath(newID(big.NewInt(test.n).Bytes()), test.i)
+		want, got := newID(big.NewInt(test.exp).Bytes()), result
+		if got != want {
+			t.Logf("running test [%02d]", i)
+			t.Fatalf("Expected %v, got %v.", test.exp, result)
 		}
 	}
 }
@@ -14,18 +14,27 @@ import (
 
 // GetID returns the ID of the node.
 func (node *Node) GetID(ctx context.Context, _ *gmajpb.GetIDRequest) (*gmajpb.GetIDResponse, error) {
-	Log.Println("calling GetID")
+	node.config.Log.Println("calling GetID")
 
 	return &gmajpb.GetIDResponse{Id: node.Id}, nil
 }
 
 // Locate finds where a key belongs.
 func (node *Node) Locate(ctx context.Context, req *gmajpb.LocateRequest) (*gmajpb.LocateResponse, error) {
-	Log.Println("calling Locate")
+	node.config.Log.Println("calling Locate")
 
-	location, err := node.locate(req.Key)
+	if req.Trace {
+		location, path, err := node.traceLocate(ctx, req.Key)
+		if err != nil {
+			return nil, grpc.Errorf(errCode(ctx, err), "could not locate key: %v", err)
+		}
+
+		return &gmajpb.LocateResponse{Node: location, Path: path}, nil
+	}
+
+	location, err := node.locate(ctx, req.Key)
 	if err != nil {
-		return nil, grpc.Errorf(codes.Internal, "could not locate key: %v", err)
+		return nil, grpc.Errorf(errCode(ctx, err), "could not locate key: %v", err)
 	}
 
 	return &gmajpb.LocateResponse{Node: location}, nil
@@ -33,24 +42,207 @@ func (node *Node) Locate(ctx context.Context, req *gmajpb.LocateRequest) (*gmajp
 
 // Get a value in the datastore, provided an abitrary node in the ring
 func (node *Node) Get(ctx context.Context, req *gmajpb.GetRequest) (*gmajpb.GetResponse, error) {
-	Log.Println("calling Get")
+	node.config.Log.Println("calling Get")
 
-	val, err := node.get(req.Key)
+	entry, err := node.get(ctx, req.Key)
 	if err != nil {
-		return nil, grpc.Errorf(codes.Internal, "could not get key: %v", err)
+		return nil, grpc.Errorf(errCode(ctx, err), "could not get key: %v", err)
 	}
 
-	return &gmajpb.GetResponse{Value: val}, nil
+	return &gmajpb.GetResponse{Value: entry.Val, Version: entry.Version}, nil
 }
 
 // Put a key/value in the datastore, provided an abitrary node in the ring.
-// This is useful for testing.
+// The mode says whether the key may exist already, and if an expected version
+// is given, the put only succeeds if the key has that version. If a TTL is
+// given, the key expires after it.
 func (node *Node) Put(ctx context.Context, req *gmajpb.PutRequest) (*gmajpb.PutResponse, error) {
-	Log.Println("calling Put")
+	node.config.Log.Println("calling Put")
+
+	version, err := node.put(ctx, &gmajpb.KeyVal{
+		Key:             req.Key,
+		Val:             req.Value,
+		Mode:            req.Mode,
+		ExpectedVersion: req.ExpectedVersion,
+		TtlNs:           req.TtlNs,
+	})
+	if err != nil {
+		return nil, grpc.Errorf(errCode(ctx, err), "could not put key value pair: %v", err)
+	}
+
+	return &gmajpb.PutResponse{Version: version}, nil
+}
+
+// PutStream is Put for values too large for one message, which the client
+// sends in chunks.
+func (node *Node) PutStream(stream gmajpb.GMaj_PutStreamServer) error {
+	node.config.Log.Println("calling PutStream")
+
+	req, err := recvPutRequest(stream.Recv, node.config.MaxValueSize)
+	if err != nil {
+		return err
+	}
+
+	resp, err := node.Put(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(resp)
+}
+
+// GetStream is Get for values too large for one message, which are sent to
+// the client in chunks.
+func (node *Node) GetStream(req *gmajpb.GetRequest, stream gmajpb.GMaj_GetStreamServer) error {
+	node.config.Log.Println("calling GetStream")
+
+	ctx := stream.Context()
+	if err := node.getStream(ctx, req.Key, stream.Send); err != nil {
+		return grpc.Errorf(errCode(ctx, err), "could not get key: %v", err)
+	}
+
+	return nil
+}
+
+// Delete a key from the datastore, provided an abitrary node in the ring.
+func (node *Node) Delete(ctx context.Context, req *gmajpb.DeleteRequest) (*gmajpb.DeleteResponse, error) {
+	node.config.Log.Println("calling Delete")
+
+	if err := node.delete(ctx, req.Key); err != nil {
+		return nil, grpc.Errorf(errCode(ctx, err), "could not delete key: %v", err)
+	}
+
+	return &gmajpb.DeleteResponse{}, nil
+}
+
+// List returns the keys in the datastore a page at a time, provided an
+// abitrary node in the ring.
+func (node *Node) List(ctx context.Context, req *gmajpb.ListRequest) (*gmajpb.ListResponse, error) {
+	node.config.Log.Println("calling List")
+
+	resp, err := node.list(ctx, req)
+	if err != nil {
+		return nil, grpc.Errorf(errCode(ctx, err), "could not list keys: %v", err)
+	}
+
+	return resp, nil
+}
+
+// MultiGet gets the values of many keys, provided an abitrary node in the
+// ring. Each key gets a result, which has an error instead of a value if the
+// key could not be gotten.
+func (node *Node) MultiGet(ctx context.Context, req *gmajpb.MultiGetRequest) (*gmajpb.MultiGetResponse, error) {
+	node.config.Log.Println("calling MultiGet")
+
+	results := node.multiGet(ctx, req.Keys)
+	for _, result := range results {
+		result.Error = clientKeyError(ctx, result.Error)
+	}
+
+	return &gmajpb.MultiGetResponse{Results: results}, nil
+}
+
+// MultiPut writes many key/values, provided an abitrary node in the ring. Each
+// put is like one done with Put, and gets a result with its version or error.
+func (node *Node) MultiPut(ctx context.Context, req *gmajpb.MultiPutRequest) (*gmajpb.MultiPutResponse, error) {
+	node.config.Log.Println("calling MultiPut")
+
+	keyVals := make([]*gmajpb.KeyVal, len(req.Puts))
+	for i, put := range req.Puts {
+		keyVals[i] = &gmajpb.KeyVal{
+			Key:             put.Key,
+			Val:             put.Value,
+			Mode:            put.Mode,
+			ExpectedVersion: put.ExpectedVersion,
+			TtlNs:           put.TtlNs,
+		}
+	}
+
+	results := node.multiPut(ctx, keyVals)
+	for _, result := range results {
+		result.Error = clientKeyError(ctx, result.Error)
+	}
+
+	return &gmajpb.MultiPutResponse{Results: results}, nil
+}
+
+// Watch sends the changes to a key, or to the keys with a prefix, to the
+// client as they happen, provided an abitrary node in the ring.
+func (node *Node) Watch(req *gmajpb.WatchRequest, stream gmajpb.GMaj_WatchServer) error {
+	node.config.Log.Println("calling Watch")
+
+	ctx := stream.Context()
+	if err := node.watch(ctx, req, stream.Send); err != nil {
+		return grpc.Errorf(errCode(ctx, err), "could not watch keys: %v", err)
+	}
+
+	return nil
+}
+
+// Txn writes and deletes several keys atomically, provided an abitrary node in
+// the ring, which coordinates the transaction.
+func (node *Node) Txn(ctx context.Context, req *gmajpb.TxnRequest) (*gmajpb.TxnResponse, error) {
+	node.config.Log.Println("calling Txn")
+
+	keyVals := make([]*gmajpb.KeyVal, len(req.Ops))
+	for i, op := range req.Ops {
+		if op.Delete {
+			keyVals[i] = &gmajpb.KeyVal{
+				Key:             op.Key,
+				Deleted:         true,
+				Mode:            gmajpb.PutMode_UPDATE,
+				ExpectedVersion: op.ExpectedVersion,
+			}
+			continue
+		}
+
+		keyVals[i] = &gmajpb.KeyVal{
+			Key:             op.Key,
+			Val:             op.Value,
+			Mode:            op.Mode,
+			ExpectedVersion: op.ExpectedVersion,
+			TtlNs:           op.TtlNs,
+		}
+	}
+
+	versions, err := node.txn(ctx, keyVals)
+	if err != nil {
+		return nil, grpc.Errorf(errCode(ctx, err), "could not run transaction: %v", err)
+	}
+
+	return &gmajpb.TxnResponse{Versions: versions}, nil
+}
+
+// clientKeyError returns the error for one key of a batch to send to a client,
+// with the code errCode gives it.
+func clientKeyError(ctx context.Context, keyErr *gmajpb.KeyError) *gmajpb.KeyError {
+	if keyErr == nil {
+		return nil
+	}
+
+	return &gmajpb.KeyError{
+		Code:    uint32(errCode(ctx, keyErrorErr(keyErr))),
+		Message: keyErr.Message,
+	}
+}
+
+// errCode returns the gRPC code for an error that happened while handling a
+// request, which tells clients whether their deadline passed or they gave up,
+// whether the key was missing or had changed, whether the request was bad, or
+// whether the client fell behind.
+func errCode(ctx context.Context, err error) codes.Code {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	case context.Canceled:
+		return codes.Canceled
+	}
 
-	if err := node.put(req.Key, req.Value); err != nil {
-		return nil, grpc.Errorf(codes.Internal, "could not put key value pair: %v", err)
+	switch code := grpc.Code(err); code {
+	case codes.NotFound, codes.AlreadyExists, codes.Aborted, codes.InvalidArgument,
+		codes.ResourceExhausted:
+		return code
 	}
 
-	return &gmajpb.PutResponse{}, nil
+	return codes.Internal
 }
@@ -1,7 +1,10 @@
 package gmajcfg
 
 import (
+	"crypto/sha1"
 	"errors"
+	"fmt"
+	"hash"
 	"log"
 	"os"
 	"time"
@@ -12,46 +15,171 @@ import (
 
 const dfltKeySize = 64
 
+// MaxIDLength is the largest supported ID length, in bytes. IDs are stored in
+// a fixed number of words, so key sizes of more than 256 bits are not
+// supported, even with a hasher whose digest is longer.
+const MaxIDLength = 32
+
 // configuration errors
 var (
-	ErrBadKeyLen = errors.New("gmaj: key length must be divisible by 8")
-	ErrBadIDLen  = errors.New("gmaj: ID length must be  key length/8")
+	ErrBadKeyLen            = errors.New("gmaj: key length must be positive")
+	ErrBadIDLen             = errors.New("gmaj: ID length must be key length/8, rounded up")
+	ErrIDTooLong            = errors.New("gmaj: ID length must not exceed MaxIDLength or the size of the hasher's digest")
+	ErrBadSuccessorListSize = errors.New("gmaj: successor list size must not be negative")
+	ErrBadReplicationFactor = errors.New("gmaj: replication factor must be between 0 and successor list size")
+	ErrBadLookupMode        = errors.New("gmaj: unknown lookup mode")
+	ErrBadLocationCacheSize = errors.New("gmaj: location cache size must not be negative")
+	ErrBadMaxValueSize      = errors.New("gmaj: maximum value size must not be negative")
+	ErrBadInterval          = errors.New("gmaj: intervals of background tasks must not be negative")
 )
 
+// LookupMode is the way nodes look up the successor of an ID.
+type LookupMode int
+
+// The lookup modes.
+const (
+	// IterativeLookup makes the node doing the lookup ask each node on the
+	// way for the next one.
+	IterativeLookup LookupMode = iota
+	// RecursiveLookup makes each node on the way pass the lookup on to the
+	// next one, which takes half as many round trips.
+	RecursiveLookup
+)
+
+func (mode LookupMode) String() string {
+	switch mode {
+	case IterativeLookup:
+		return "iterative"
+	case RecursiveLookup:
+		return "recursive"
+	}
+
+	return fmt.Sprintf("LookupMode(%d)", int(mode))
+}
+
 // Config contains all the configuration information for a gmaj node.
 type Config struct {
-	// KeySize is the number of bits (i.e. M value), divisible by 8
+	// KeySize is the number of bits (i.e. M value)
 	KeySize               int
-	IDLength              int // must be KeyLength/8
-	FixNextFingerInterval time.Duration
-	StabilizeInterval     time.Duration
-	ConnectionTimeout     time.Duration
+	IDLength              int           // must be KeyLength/8, rounded up, and at most MaxIDLength
+	FixNextFingerInterval time.Duration // 0 for the default
+	StabilizeInterval     time.Duration // 0 for the default
+	CheckPredInterval     time.Duration // 0 for the default
+	AntiEntropyInterval   time.Duration // 0 for the default
+	ReapInterval          time.Duration // how often expired keys are removed, 0 for never
+	TombstoneGracePeriod  time.Duration // how long deleted keys leave tombstones, 0 for forever
+	TxnTimeout            time.Duration // how long a prepared transaction waits to be told its outcome before asking, 0 for forever
+	ConnectionTimeout     time.Duration // timeout for each RPC, or each message of a stream, 0 for none
 	RetryInterval         time.Duration
+	SuccessorListSize     int // number of successors to track (i.e. r value), 0 for the default
+	ReplicationFactor     int // number of successors that keep a copy of each key
+	LookupMode            LookupMode
+	LocationCacheSize     int // number of ranges whose owners are cached, 0 for none
+	MaxValueSize          int // largest value in bytes that nodes accept, 0 for no limit
 	DialOptions           []grpc.DialOption
 
+	// Hasher creates the hash used to map keys to IDs (e.g. sha256.New). Its
+	// digest must have at least IDLength bytes. Defaults to sha1.New, which
+	// allows IDs of up to 160 bits. Longer digests (e.g. from sha512.New) can
+	// be used, but only their first MaxIDLength bytes, so IDs are at most 256
+	// bits.
+	Hasher func() hash.Hash
+
 	Log grpclog.Logger
 }
 
 // Validate checks some of the values of a Config to make sure they are valid.
 func (config *Config) Validate() error {
-	if config.KeySize%8 != 0 {
+	if config.FixNextFingerInterval < 0 || config.StabilizeInterval < 0 ||
+		config.CheckPredInterval < 0 || config.AntiEntropyInterval < 0 {
+		return ErrBadInterval
+	}
+
+	if config.KeySize < 1 {
 		return ErrBadKeyLen
 	}
 
-	if config.IDLength != config.KeySize/8 {
+	if config.IDLength != (config.KeySize+7)/8 {
 		return ErrBadIDLen
 	}
 
+	if config.IDLength > MaxIDLength || config.IDLength > config.NewHash().Size() {
+		return ErrIDTooLong
+	}
+
+	if config.SuccessorListSize < 0 {
+		return ErrBadSuccessorListSize
+	}
+
+	successorListSize := config.SuccessorListSize
+	if successorListSize == 0 {
+		successorListSize = DefaultConfig.SuccessorListSize
+	}
+	if config.ReplicationFactor < 0 || config.ReplicationFactor > successorListSize {
+		return ErrBadReplicationFactor
+	}
+
+	if config.LookupMode != IterativeLookup && config.LookupMode != RecursiveLookup {
+		return ErrBadLookupMode
+	}
+
+	if config.LocationCacheSize < 0 {
+		return ErrBadLocationCacheSize
+	}
+
+	if config.MaxValueSize < 0 {
+		return ErrBadMaxValueSize
+	}
+
 	return nil
 }
 
+// SetDefaults replaces the values of config that are zero, where zero is not
+// meaningful, with those of DefaultConfig.
+func (config *Config) SetDefaults() {
+	setDefault(&config.FixNextFingerInterval, DefaultConfig.FixNextFingerInterval)
+	setDefault(&config.StabilizeInterval, DefaultConfig.StabilizeInterval)
+	setDefault(&config.CheckPredInterval, DefaultConfig.CheckPredInterval)
+	setDefault(&config.AntiEntropyInterval, DefaultConfig.AntiEntropyInterval)
+	if config.SuccessorListSize == 0 {
+		config.SuccessorListSize = DefaultConfig.SuccessorListSize
+	}
+}
+
+func setDefault(d *time.Duration, dflt time.Duration) {
+	if *d == 0 {
+		*d = dflt
+	}
+}
+
+// NewHash returns a new hash from the configured Hasher, or a SHA-1 hash if
+// there is none.
+func (config *Config) NewHash() hash.Hash {
+	if config.Hasher == nil {
+		return sha1.New()
+	}
+
+	return config.Hasher()
+}
+
 // DefaultConfig is the default configuration.
 var DefaultConfig = &Config{
 	KeySize:               dfltKeySize,
 	IDLength:              dfltKeySize / 8, // key length bytes
 	FixNextFingerInterval: 50 * time.Millisecond,
 	StabilizeInterval:     100 * time.Millisecond,
+	CheckPredInterval:     100 * time.Millisecond,
+	AntiEntropyInterval:   time.Second,
+	ReapInterval:          time.Second,
+	TombstoneGracePeriod:  24 * time.Hour,
+	TxnTimeout:            10 * time.Second,
+	ConnectionTimeout:     5 * time.Second,
 	RetryInterval:         200 * time.Millisecond,
+	SuccessorListSize:     3,
+	ReplicationFactor:     2,
+	LocationCacheSize:     64,
+	MaxValueSize:          64 << 20,
+	Hasher:                sha1.New,
 	DialOptions: []grpc.DialOption{
 		grpc.WithInsecure(), // TODO(ricky): find a better way to use this for testing
 	},
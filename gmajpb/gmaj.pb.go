@@ -14,16 +14,49 @@ It has these top-level messages:
 	GetIDResponse
 	LocateRequest
 	LocateResponse
+	Hop
 	GetRequest
 	GetResponse
 	PutRequest
 	PutResponse
+	DeleteRequest
+	DeleteResponse
+	ListRequest
+	ListResponse
+	ListItem
+	MultiGetRequest
+	MultiGetResponse
+	GetResult
+	MultiPutRequest
+	MultiPutResponse
+	PutResult
+	KeyError
+	WatchRequest
+	WatchEvent
+	TxnRequest
+	TxnOp
+	TxnResponse
 	TransferKeysReq
 	MT
+	Nodes
 	KeyVal
+	KeyVals
+	KeyRange
+	MerkleTree
+	ListKeysReq
+	WatchKeysReq
+	PrepareReq
+	TxnID
+	TxnStatus
+	Versions
+	BucketsReq
 	ID
+	LookupRequest
+	LookupResponse
+	Finger
 	Key
 	Val
+	Version
 */
 package gmajpb
 
@@ -47,6 +80,89 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// PutMode says whether a put may replace an existing value.
+type PutMode int32
+
+const (
+	// CREATE fails if the key exists.
+	PutMode_CREATE PutMode = 0
+	// UPDATE creates the key or replaces its value.
+	PutMode_UPDATE PutMode = 1
+)
+
+var PutMode_name = map[int32]string{
+	0: "CREATE",
+	1: "UPDATE",
+}
+var PutMode_value = map[string]int32{
+	"CREATE": 0,
+	"UPDATE": 1,
+}
+
+func (x PutMode) String() string {
+	return proto.EnumName(PutMode_name, int32(x))
+}
+func (PutMode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+// EventType is the kind of change to a key.
+type EventType int32
+
+const (
+	// CREATED is a write to a key that did not have a value.
+	EventType_CREATED EventType = 0
+	// UPDATED is a write that replaced the value of a key.
+	EventType_UPDATED EventType = 1
+	// DELETED is the deletion of a key.
+	EventType_DELETED EventType = 2
+	// RESYNC tells a watch of a prefix that some of its keys moved to
+	// another node, and that changes to them may have been missed while they
+	// did. The client should read the keys again. The event only has the
+	// prefix as its key, and may come more than once for one move.
+	EventType_RESYNC EventType = 3
+)
+
+var EventType_name = map[int32]string{
+	0: "CREATED",
+	1: "UPDATED",
+	2: "DELETED",
+	3: "RESYNC",
+}
+var EventType_value = map[string]int32{
+	"CREATED": 0,
+	"UPDATED": 1,
+	"DELETED": 2,
+	"RESYNC":  3,
+}
+
+func (x EventType) String() string {
+	return proto.EnumName(EventType_name, int32(x))
+}
+func (EventType) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type TxnStatus_Outcome int32
+
+const (
+	TxnStatus_PENDING   TxnStatus_Outcome = 0
+	TxnStatus_COMMITTED TxnStatus_Outcome = 1
+	TxnStatus_ABORTED   TxnStatus_Outcome = 2
+)
+
+var TxnStatus_Outcome_name = map[int32]string{
+	0: "PENDING",
+	1: "COMMITTED",
+	2: "ABORTED",
+}
+var TxnStatus_Outcome_value = map[string]int32{
+	"PENDING":   0,
+	"COMMITTED": 1,
+	"ABORTED":   2,
+}
+
+func (x TxnStatus_Outcome) String() string {
+	return proto.EnumName(TxnStatus_Outcome_name, int32(x))
+}
+func (TxnStatus_Outcome) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{38, 0} }
+
 // Node contains a node ID and address.
 type Node struct {
 	Id   []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -98,6 +214,8 @@ func (m *GetIDResponse) GetId() []byte {
 
 type LocateRequest struct {
 	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// trace asks for the path the lookup took.
+	Trace bool `protobuf:"varint,2,opt,name=trace" json:"trace,omitempty"`
 }
 
 func (m *LocateRequest) Reset()                    { *m = LocateRequest{} }
@@ -112,8 +230,17 @@ func (m *LocateRequest) GetKey() string {
 	return ""
 }
 
+func (m *LocateRequest) GetTrace() bool {
+	if m != nil {
+		return m.Trace
+	}
+	return false
+}
+
 type LocateResponse struct {
 	Node *Node `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	// path is the nodes the lookup went through, if it was traced.
+	Path []*Hop `protobuf:"bytes,2,rep,name=path" json:"path,omitempty"`
 }
 
 func (m *LocateResponse) Reset()                    { *m = LocateResponse{} }
@@ -128,6 +255,50 @@ func (m *LocateResponse) GetNode() *Node {
 	return nil
 }
 
+func (m *LocateResponse) GetPath() []*Hop {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+// Hop is a step of a traced lookup.
+type Hop struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	// finger is the index of the finger in the previous node's finger table
+	// that led to node, or -1 if the lookup got to node by following a
+	// successor pointer.
+	Finger int32 `protobuf:"varint,2,opt,name=finger" json:"finger,omitempty"`
+	// latency_ns is how long the step took, in nanoseconds.
+	LatencyNs int64 `protobuf:"varint,3,opt,name=latency_ns,json=latencyNs" json:"latency_ns,omitempty"`
+}
+
+func (m *Hop) Reset()                    { *m = Hop{} }
+func (m *Hop) String() string            { return proto.CompactTextString(m) }
+func (*Hop) ProtoMessage()               {}
+func (*Hop) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Hop) GetNode() *Node {
+	if m != nil {
+		return m.Node
+	}
+	return nil
+}
+
+func (m *Hop) GetFinger() int32 {
+	if m != nil {
+		return m.Finger
+	}
+	return 0
+}
+
+func (m *Hop) GetLatencyNs() int64 {
+	if m != nil {
+		return m.LatencyNs
+	}
+	return 0
+}
+
 type GetRequest struct {
 	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
 }
@@ -135,7 +306,7 @@ type GetRequest struct {
 func (m *GetRequest) Reset()                    { *m = GetRequest{} }
 func (m *GetRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetRequest) ProtoMessage()               {}
-func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
 func (m *GetRequest) GetKey() string {
 	if m != nil {
@@ -146,12 +317,14 @@ func (m *GetRequest) GetKey() string {
 
 type GetResponse struct {
 	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// version is the version of the value, which is incremented by each write.
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
 }
 
 func (m *GetResponse) Reset()                    { *m = GetResponse{} }
 func (m *GetResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetResponse) ProtoMessage()               {}
-func (*GetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (*GetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
 
 func (m *GetResponse) GetValue() []byte {
 	if m != nil {
@@ -160,15 +333,28 @@ func (m *GetResponse) GetValue() []byte {
 	return nil
 }
 
+func (m *GetResponse) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type PutRequest struct {
-	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
-	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Key   string  `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value []byte  `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Mode  PutMode `protobuf:"varint,3,opt,name=mode,enum=gmajpb.PutMode" json:"mode,omitempty"`
+	// expected_version, if set, makes the put fail unless it is the version of
+	// the current value (i.e. compare-and-swap).
+	ExpectedVersion uint64 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
+	// ttl_ns, if positive, is how long the key lives, in nanoseconds.
+	TtlNs int64 `protobuf:"varint,5,opt,name=ttl_ns,json=ttlNs" json:"ttl_ns,omitempty"`
 }
 
 func (m *PutRequest) Reset()                    { *m = PutRequest{} }
 func (m *PutRequest) String() string            { return proto.CompactTextString(m) }
 func (*PutRequest) ProtoMessage()               {}
-func (*PutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*PutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *PutRequest) GetKey() string {
 	if m != nil {
@@ -184,94 +370,1055 @@ func (m *PutRequest) GetValue() []byte {
 	return nil
 }
 
+func (m *PutRequest) GetMode() PutMode {
+	if m != nil {
+		return m.Mode
+	}
+	return PutMode_CREATE
+}
+
+func (m *PutRequest) GetExpectedVersion() uint64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
+func (m *PutRequest) GetTtlNs() int64 {
+	if m != nil {
+		return m.TtlNs
+	}
+	return 0
+}
+
 type PutResponse struct {
+	// version is the version of the value that was written.
+	Version uint64 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
 }
 
 func (m *PutResponse) Reset()                    { *m = PutResponse{} }
 func (m *PutResponse) String() string            { return proto.CompactTextString(m) }
 func (*PutResponse) ProtoMessage()               {}
-func (*PutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (*PutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
-type TransferKeysReq struct {
-	FromId []byte `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
-	ToNode *Node  `protobuf:"bytes,2,opt,name=to_node,json=toNode" json:"to_node,omitempty"`
+func (m *PutResponse) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type DeleteRequest struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+}
+
+func (m *DeleteRequest) Reset()                    { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()               {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *DeleteRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+}
+
+func (m *DeleteResponse) Reset()                    { *m = DeleteResponse{} }
+func (m *DeleteResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteResponse) ProtoMessage()               {}
+func (*DeleteResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type ListRequest struct {
+	// limit is the most keys to return. The server picks one if it is 0.
+	Limit uint32 `protobuf:"varint,1,opt,name=limit" json:"limit,omitempty"`
+	// values asks for the values of the keys as well.
+	Values bool `protobuf:"varint,2,opt,name=values" json:"values,omitempty"`
+	// page_token is the next_page_token of a previous response, to continue
+	// the listing where it left off.
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken" json:"page_token,omitempty"`
+}
+
+func (m *ListRequest) Reset()                    { *m = ListRequest{} }
+func (m *ListRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListRequest) ProtoMessage()               {}
+func (*ListRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *ListRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ListRequest) GetValues() bool {
+	if m != nil {
+		return m.Values
+	}
+	return false
+}
+
+func (m *ListRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type ListResponse struct {
+	Items []*ListItem `protobuf:"bytes,1,rep,name=items" json:"items,omitempty"`
+	// next_page_token is set if there may be more keys.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken" json:"next_page_token,omitempty"`
+}
+
+func (m *ListResponse) Reset()                    { *m = ListResponse{} }
+func (m *ListResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListResponse) ProtoMessage()               {}
+func (*ListResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *ListResponse) GetItems() []*ListItem {
+	if m != nil {
+		return m.Items
+	}
+	return nil
+}
+
+func (m *ListResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+// ListItem is a key in a listing.
+type ListItem struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// value is only set if the values were asked for.
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,3,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *ListItem) Reset()                    { *m = ListItem{} }
+func (m *ListItem) String() string            { return proto.CompactTextString(m) }
+func (*ListItem) ProtoMessage()               {}
+func (*ListItem) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *ListItem) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ListItem) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *ListItem) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type MultiGetRequest struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys" json:"keys,omitempty"`
+}
+
+func (m *MultiGetRequest) Reset()                    { *m = MultiGetRequest{} }
+func (m *MultiGetRequest) String() string            { return proto.CompactTextString(m) }
+func (*MultiGetRequest) ProtoMessage()               {}
+func (*MultiGetRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *MultiGetRequest) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+type MultiGetResponse struct {
+	// results are in the order of the keys in the request.
+	Results []*GetResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *MultiGetResponse) Reset()                    { *m = MultiGetResponse{} }
+func (m *MultiGetResponse) String() string            { return proto.CompactTextString(m) }
+func (*MultiGetResponse) ProtoMessage()               {}
+func (*MultiGetResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *MultiGetResponse) GetResults() []*GetResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// GetResult is the result of getting one key of a MultiGet.
+type GetResult struct {
+	Key     string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,3,opt,name=version" json:"version,omitempty"`
+	// error is set if the key could not be gotten.
+	Error *KeyError `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *GetResult) Reset()                    { *m = GetResult{} }
+func (m *GetResult) String() string            { return proto.CompactTextString(m) }
+func (*GetResult) ProtoMessage()               {}
+func (*GetResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *GetResult) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *GetResult) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *GetResult) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *GetResult) GetError() *KeyError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+type MultiPutRequest struct {
+	Puts []*PutRequest `protobuf:"bytes,1,rep,name=puts" json:"puts,omitempty"`
+}
+
+func (m *MultiPutRequest) Reset()                    { *m = MultiPutRequest{} }
+func (m *MultiPutRequest) String() string            { return proto.CompactTextString(m) }
+func (*MultiPutRequest) ProtoMessage()               {}
+func (*MultiPutRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *MultiPutRequest) GetPuts() []*PutRequest {
+	if m != nil {
+		return m.Puts
+	}
+	return nil
+}
+
+type MultiPutResponse struct {
+	// results are in the order of the puts in the request.
+	Results []*PutResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *MultiPutResponse) Reset()                    { *m = MultiPutResponse{} }
+func (m *MultiPutResponse) String() string            { return proto.CompactTextString(m) }
+func (*MultiPutResponse) ProtoMessage()               {}
+func (*MultiPutResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *MultiPutResponse) GetResults() []*PutResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+// PutResult is the result of one put of a MultiPut.
+type PutResult struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// version is the version of the value that was written.
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
+	// error is set if the put failed.
+	Error *KeyError `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *PutResult) Reset()                    { *m = PutResult{} }
+func (m *PutResult) String() string            { return proto.CompactTextString(m) }
+func (*PutResult) ProtoMessage()               {}
+func (*PutResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *PutResult) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *PutResult) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *PutResult) GetError() *KeyError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+// KeyError is why an operation on one key of a batch failed.
+type KeyError struct {
+	// code is the gRPC status code of the error.
+	Code    uint32 `protobuf:"varint,1,opt,name=code" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *KeyError) Reset()                    { *m = KeyError{} }
+func (m *KeyError) String() string            { return proto.CompactTextString(m) }
+func (*KeyError) ProtoMessage()               {}
+func (*KeyError) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *KeyError) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *KeyError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type WatchRequest struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// prefix makes the watch cover all the keys that start with key.
+	Prefix bool `protobuf:"varint,2,opt,name=prefix" json:"prefix,omitempty"`
+}
+
+func (m *WatchRequest) Reset()                    { *m = WatchRequest{} }
+func (m *WatchRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()               {}
+func (*WatchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *WatchRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetPrefix() bool {
+	if m != nil {
+		return m.Prefix
+	}
+	return false
+}
+
+// WatchEvent is a change to a watched key.
+type WatchEvent struct {
+	Type EventType `protobuf:"varint,1,opt,name=type,enum=gmajpb.EventType" json:"type,omitempty"`
+	Key  string    `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	// value is the value written, if the key was not deleted.
+	Value   []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Version uint64 `protobuf:"varint,4,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *WatchEvent) Reset()                    { *m = WatchEvent{} }
+func (m *WatchEvent) String() string            { return proto.CompactTextString(m) }
+func (*WatchEvent) ProtoMessage()               {}
+func (*WatchEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *WatchEvent) GetType() EventType {
+	if m != nil {
+		return m.Type
+	}
+	return EventType_CREATED
+}
+
+func (m *WatchEvent) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchEvent) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *WatchEvent) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type TxnRequest struct {
+	Ops []*TxnOp `protobuf:"bytes,1,rep,name=ops" json:"ops,omitempty"`
+}
+
+func (m *TxnRequest) Reset()                    { *m = TxnRequest{} }
+func (m *TxnRequest) String() string            { return proto.CompactTextString(m) }
+func (*TxnRequest) ProtoMessage()               {}
+func (*TxnRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *TxnRequest) GetOps() []*TxnOp {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+// TxnOp is a write or delete of one key in a transaction. Each key may only
+// be in one operation.
+type TxnOp struct {
+	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// delete deletes the key instead of writing value. Deleting a key that
+	// does not exist is not an error.
+	Delete bool `protobuf:"varint,3,opt,name=delete" json:"delete,omitempty"`
+	// mode, expected_version and ttl_ns are like those of a PutRequest. Only
+	// expected_version applies to deletes.
+	Mode            PutMode `protobuf:"varint,4,opt,name=mode,enum=gmajpb.PutMode" json:"mode,omitempty"`
+	ExpectedVersion uint64  `protobuf:"varint,5,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
+	TtlNs           int64   `protobuf:"varint,6,opt,name=ttl_ns,json=ttlNs" json:"ttl_ns,omitempty"`
+}
+
+func (m *TxnOp) Reset()                    { *m = TxnOp{} }
+func (m *TxnOp) String() string            { return proto.CompactTextString(m) }
+func (*TxnOp) ProtoMessage()               {}
+func (*TxnOp) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *TxnOp) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *TxnOp) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *TxnOp) GetDelete() bool {
+	if m != nil {
+		return m.Delete
+	}
+	return false
+}
+
+func (m *TxnOp) GetMode() PutMode {
+	if m != nil {
+		return m.Mode
+	}
+	return PutMode_CREATE
+}
+
+func (m *TxnOp) GetExpectedVersion() uint64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
+func (m *TxnOp) GetTtlNs() int64 {
+	if m != nil {
+		return m.TtlNs
+	}
+	return 0
+}
+
+type TxnResponse struct {
+	// versions are the versions the keys have after the transaction, in the
+	// order of the operations.
+	Versions []uint64 `protobuf:"varint,1,rep,packed,name=versions" json:"versions,omitempty"`
+}
+
+func (m *TxnResponse) Reset()                    { *m = TxnResponse{} }
+func (m *TxnResponse) String() string            { return proto.CompactTextString(m) }
+func (*TxnResponse) ProtoMessage()               {}
+func (*TxnResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *TxnResponse) GetVersions() []uint64 {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type TransferKeysReq struct {
+	FromId []byte `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToNode *Node  `protobuf:"bytes,2,opt,name=to_node,json=toNode" json:"to_node,omitempty"`
+}
+
+func (m *TransferKeysReq) Reset()                    { *m = TransferKeysReq{} }
+func (m *TransferKeysReq) String() string            { return proto.CompactTextString(m) }
+func (*TransferKeysReq) ProtoMessage()               {}
+func (*TransferKeysReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *TransferKeysReq) GetFromId() []byte {
+	if m != nil {
+		return m.FromId
+	}
+	return nil
+}
+
+func (m *TransferKeysReq) GetToNode() *Node {
+	if m != nil {
+		return m.ToNode
+	}
+	return nil
+}
+
+type MT struct {
+}
+
+func (m *MT) Reset()                    { *m = MT{} }
+func (m *MT) String() string            { return proto.CompactTextString(m) }
+func (*MT) ProtoMessage()               {}
+func (*MT) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+// Nodes is a list of nodes.
+type Nodes struct {
+	Nodes []*Node `protobuf:"bytes,1,rep,name=nodes" json:"nodes,omitempty"`
+}
+
+func (m *Nodes) Reset()                    { *m = Nodes{} }
+func (m *Nodes) String() string            { return proto.CompactTextString(m) }
+func (*Nodes) ProtoMessage()               {}
+func (*Nodes) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *Nodes) GetNodes() []*Node {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+type KeyVal struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Val []byte `protobuf:"bytes,2,opt,name=val,proto3" json:"val,omitempty"`
+	// check_owner makes the node refuse the key if it does not own it.
+	CheckOwner bool `protobuf:"varint,3,opt,name=check_owner,json=checkOwner" json:"check_owner,omitempty"`
+	// deleted marks a tombstone, which records that the key was deleted.
+	Deleted bool `protobuf:"varint,4,opt,name=deleted" json:"deleted,omitempty"`
+	// transfer marks a key handed over by another node, rather than written by
+	// a client. It only replaces an older version the node has.
+	Transfer bool `protobuf:"varint,5,opt,name=transfer" json:"transfer,omitempty"`
+	// version is the version of a transferred key.
+	Version uint64 `protobuf:"varint,6,opt,name=version" json:"version,omitempty"`
+	// mode and expected_version are those of a client's PutRequest.
+	Mode            PutMode `protobuf:"varint,7,opt,name=mode,enum=gmajpb.PutMode" json:"mode,omitempty"`
+	ExpectedVersion uint64  `protobuf:"varint,8,opt,name=expected_version,json=expectedVersion" json:"expected_version,omitempty"`
+	// ttl_ns, if positive, is how long the key has left to live, in
+	// nanoseconds. It is relative so that it does not depend on the clocks of
+	// the nodes agreeing.
+	TtlNs int64 `protobuf:"varint,9,opt,name=ttl_ns,json=ttlNs" json:"ttl_ns,omitempty"`
+	// more marks a message of a stream whose val continues in the next
+	// message.
+	More bool `protobuf:"varint,10,opt,name=more" json:"more,omitempty"`
+}
+
+func (m *KeyVal) Reset()                    { *m = KeyVal{} }
+func (m *KeyVal) String() string            { return proto.CompactTextString(m) }
+func (*KeyVal) ProtoMessage()               {}
+func (*KeyVal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *KeyVal) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyVal) GetVal() []byte {
+	if m != nil {
+		return m.Val
+	}
+	return nil
+}
+
+func (m *KeyVal) GetCheckOwner() bool {
+	if m != nil {
+		return m.CheckOwner
+	}
+	return false
+}
+
+func (m *KeyVal) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+func (m *KeyVal) GetTransfer() bool {
+	if m != nil {
+		return m.Transfer
+	}
+	return false
+}
+
+func (m *KeyVal) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *KeyVal) GetMode() PutMode {
+	if m != nil {
+		return m.Mode
+	}
+	return PutMode_CREATE
+}
+
+func (m *KeyVal) GetExpectedVersion() uint64 {
+	if m != nil {
+		return m.ExpectedVersion
+	}
+	return 0
+}
+
+func (m *KeyVal) GetTtlNs() int64 {
+	if m != nil {
+		return m.TtlNs
+	}
+	return 0
+}
+
+func (m *KeyVal) GetMore() bool {
+	if m != nil {
+		return m.More
+	}
+	return false
+}
+
+type KeyVals struct {
+	KeyVals []*KeyVal `protobuf:"bytes,1,rep,name=key_vals,json=keyVals" json:"key_vals,omitempty"`
+}
+
+func (m *KeyVals) Reset()                    { *m = KeyVals{} }
+func (m *KeyVals) String() string            { return proto.CompactTextString(m) }
+func (*KeyVals) ProtoMessage()               {}
+func (*KeyVals) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *KeyVals) GetKeyVals() []*KeyVal {
+	if m != nil {
+		return m.KeyVals
+	}
+	return nil
+}
+
+// KeyRange is the range of IDs between (from_id : to_id].
+type KeyRange struct {
+	FromId []byte `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId   []byte `protobuf:"bytes,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+}
+
+func (m *KeyRange) Reset()                    { *m = KeyRange{} }
+func (m *KeyRange) String() string            { return proto.CompactTextString(m) }
+func (*KeyRange) ProtoMessage()               {}
+func (*KeyRange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *KeyRange) GetFromId() []byte {
+	if m != nil {
+		return m.FromId
+	}
+	return nil
+}
+
+func (m *KeyRange) GetToId() []byte {
+	if m != nil {
+		return m.ToId
+	}
+	return nil
+}
+
+// MerkleTree contains the hashes of a Merkle tree in heap order.
+type MerkleTree struct {
+	Hashes [][]byte `protobuf:"bytes,1,rep,name=hashes,proto3" json:"hashes,omitempty"`
+}
+
+func (m *MerkleTree) Reset()                    { *m = MerkleTree{} }
+func (m *MerkleTree) String() string            { return proto.CompactTextString(m) }
+func (*MerkleTree) ProtoMessage()               {}
+func (*MerkleTree) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *MerkleTree) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
+// ListKeysReq asks a node for the keys it owns in a part of a listing, in order
+// of their hashed IDs and then of the keys.
+type ListKeysReq struct {
+	// start_id and start_key are where the listing starts, inclusive.
+	StartId  []byte `protobuf:"bytes,1,opt,name=start_id,json=startId,proto3" json:"start_id,omitempty"`
+	StartKey string `protobuf:"bytes,2,opt,name=start_key,json=startKey" json:"start_key,omitempty"`
+	// end_id is the last ID to list. It is not less than start_id.
+	EndId []byte `protobuf:"bytes,3,opt,name=end_id,json=endId,proto3" json:"end_id,omitempty"`
+	Limit uint32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+	// values asks for the values of the keys as well.
+	Values bool `protobuf:"varint,5,opt,name=values" json:"values,omitempty"`
+}
+
+func (m *ListKeysReq) Reset()                    { *m = ListKeysReq{} }
+func (m *ListKeysReq) String() string            { return proto.CompactTextString(m) }
+func (*ListKeysReq) ProtoMessage()               {}
+func (*ListKeysReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *ListKeysReq) GetStartId() []byte {
+	if m != nil {
+		return m.StartId
+	}
+	return nil
+}
+
+func (m *ListKeysReq) GetStartKey() string {
+	if m != nil {
+		return m.StartKey
+	}
+	return ""
+}
+
+func (m *ListKeysReq) GetEndId() []byte {
+	if m != nil {
+		return m.EndId
+	}
+	return nil
+}
+
+func (m *ListKeysReq) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *ListKeysReq) GetValues() bool {
+	if m != nil {
+		return m.Values
+	}
+	return false
+}
+
+// WatchKeysReq asks a node for the changes to the keys it owns in a range.
+type WatchKeysReq struct {
+	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// prefix makes the watch cover all the keys that start with key.
+	Prefix bool `protobuf:"varint,2,opt,name=prefix" json:"prefix,omitempty"`
+	// from_id and to_id are the range (from_id : to_id] to watch, which the
+	// node must own.
+	FromId []byte `protobuf:"bytes,3,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId   []byte `protobuf:"bytes,4,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	// resume is set when a watch moves to the node. For a watch of one key,
+	// the node first sends the key's current state if its version is newer
+	// than version, so that changes made during the move are not missed. For
+	// a watch of a prefix, it first sends a RESYNC event.
+	Resume  bool   `protobuf:"varint,5,opt,name=resume" json:"resume,omitempty"`
+	Version uint64 `protobuf:"varint,6,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *WatchKeysReq) Reset()                    { *m = WatchKeysReq{} }
+func (m *WatchKeysReq) String() string            { return proto.CompactTextString(m) }
+func (*WatchKeysReq) ProtoMessage()               {}
+func (*WatchKeysReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *WatchKeysReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *WatchKeysReq) GetPrefix() bool {
+	if m != nil {
+		return m.Prefix
+	}
+	return false
+}
+
+func (m *WatchKeysReq) GetFromId() []byte {
+	if m != nil {
+		return m.FromId
+	}
+	return nil
+}
+
+func (m *WatchKeysReq) GetToId() []byte {
+	if m != nil {
+		return m.ToId
+	}
+	return nil
+}
+
+func (m *WatchKeysReq) GetResume() bool {
+	if m != nil {
+		return m.Resume
+	}
+	return false
+}
+
+func (m *WatchKeysReq) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+// PrepareReq asks a node to prepare its part of a transaction: to check that
+// the writes can be done, and to lock their keys until the transaction is
+// committed or aborted.
+type PrepareReq struct {
+	TxnId   string    `protobuf:"bytes,1,opt,name=txn_id,json=txnId" json:"txn_id,omitempty"`
+	KeyVals []*KeyVal `protobuf:"bytes,2,rep,name=key_vals,json=keyVals" json:"key_vals,omitempty"`
+	// coordinator is the node running the transaction. A node that has
+	// prepared its part asks the coordinator for the outcome if it is not
+	// told it in time.
+	Coordinator *Node `protobuf:"bytes,3,opt,name=coordinator" json:"coordinator,omitempty"`
+}
+
+func (m *PrepareReq) Reset()                    { *m = PrepareReq{} }
+func (m *PrepareReq) String() string            { return proto.CompactTextString(m) }
+func (*PrepareReq) ProtoMessage()               {}
+func (*PrepareReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *PrepareReq) GetTxnId() string {
+	if m != nil {
+		return m.TxnId
+	}
+	return ""
+}
+
+func (m *PrepareReq) GetKeyVals() []*KeyVal {
+	if m != nil {
+		return m.KeyVals
+	}
+	return nil
+}
+
+func (m *PrepareReq) GetCoordinator() *Node {
+	if m != nil {
+		return m.Coordinator
+	}
+	return nil
+}
+
+// TxnID identifies a transaction that was prepared.
+type TxnID struct {
+	TxnId string `protobuf:"bytes,1,opt,name=txn_id,json=txnId" json:"txn_id,omitempty"`
+}
+
+func (m *TxnID) Reset()                    { *m = TxnID{} }
+func (m *TxnID) String() string            { return proto.CompactTextString(m) }
+func (*TxnID) ProtoMessage()               {}
+func (*TxnID) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *TxnID) GetTxnId() string {
+	if m != nil {
+		return m.TxnId
+	}
+	return ""
+}
+
+// TxnStatus is the outcome of a transaction, as its coordinator knows it. A
+// transaction the coordinator has no record of was aborted.
+type TxnStatus struct {
+	Outcome TxnStatus_Outcome `protobuf:"varint,1,opt,name=outcome,enum=gmajpb.TxnStatus_Outcome" json:"outcome,omitempty"`
+}
+
+func (m *TxnStatus) Reset()                    { *m = TxnStatus{} }
+func (m *TxnStatus) String() string            { return proto.CompactTextString(m) }
+func (*TxnStatus) ProtoMessage()               {}
+func (*TxnStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *TxnStatus) GetOutcome() TxnStatus_Outcome {
+	if m != nil {
+		return m.Outcome
+	}
+	return TxnStatus_PENDING
+}
+
+// Versions are the versions of the keys of a transaction, in order.
+type Versions struct {
+	Versions []uint64 `protobuf:"varint,1,rep,packed,name=versions" json:"versions,omitempty"`
+}
+
+func (m *Versions) Reset()                    { *m = Versions{} }
+func (m *Versions) String() string            { return proto.CompactTextString(m) }
+func (*Versions) ProtoMessage()               {}
+func (*Versions) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *Versions) GetVersions() []uint64 {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type BucketsReq struct {
+	Range   *KeyRange `protobuf:"bytes,1,opt,name=range" json:"range,omitempty"`
+	Buckets []uint32  `protobuf:"varint,2,rep,packed,name=buckets" json:"buckets,omitempty"`
+}
+
+func (m *BucketsReq) Reset()                    { *m = BucketsReq{} }
+func (m *BucketsReq) String() string            { return proto.CompactTextString(m) }
+func (*BucketsReq) ProtoMessage()               {}
+func (*BucketsReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *BucketsReq) GetRange() *KeyRange {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+func (m *BucketsReq) GetBuckets() []uint32 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+type ID struct {
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (m *ID) Reset()                    { *m = ID{} }
+func (m *ID) String() string            { return proto.CompactTextString(m) }
+func (*ID) ProtoMessage()               {}
+func (*ID) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *ID) GetId() []byte {
+	if m != nil {
+		return m.Id
+	}
+	return nil
+}
+
+// LookupRequest asks for the successor of an ID in a recursive lookup.
+type LookupRequest struct {
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// trace asks for the path the lookup takes.
+	Trace bool `protobuf:"varint,2,opt,name=trace" json:"trace,omitempty"`
+	// finger is the index of the finger that the sender passed the lookup on
+	// through, or -1 if it used its successor pointer.
+	Finger int32 `protobuf:"varint,3,opt,name=finger" json:"finger,omitempty"`
 }
 
-func (m *TransferKeysReq) Reset()                    { *m = TransferKeysReq{} }
-func (m *TransferKeysReq) String() string            { return proto.CompactTextString(m) }
-func (*TransferKeysReq) ProtoMessage()               {}
-func (*TransferKeysReq) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+func (m *LookupRequest) Reset()                    { *m = LookupRequest{} }
+func (m *LookupRequest) String() string            { return proto.CompactTextString(m) }
+func (*LookupRequest) ProtoMessage()               {}
+func (*LookupRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
 
-func (m *TransferKeysReq) GetFromId() []byte {
+func (m *LookupRequest) GetId() []byte {
 	if m != nil {
-		return m.FromId
+		return m.Id
 	}
 	return nil
 }
 
-func (m *TransferKeysReq) GetToNode() *Node {
+func (m *LookupRequest) GetTrace() bool {
 	if m != nil {
-		return m.ToNode
+		return m.Trace
 	}
-	return nil
+	return false
 }
 
-type MT struct {
+func (m *LookupRequest) GetFinger() int32 {
+	if m != nil {
+		return m.Finger
+	}
+	return 0
 }
 
-func (m *MT) Reset()                    { *m = MT{} }
-func (m *MT) String() string            { return proto.CompactTextString(m) }
-func (*MT) ProtoMessage()               {}
-func (*MT) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
-
-type KeyVal struct {
-	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
-	Val []byte `protobuf:"bytes,2,opt,name=val,proto3" json:"val,omitempty"`
+type LookupResponse struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	// path is the nodes the lookup went through, if it was traced.
+	Path []*Hop `protobuf:"bytes,2,rep,name=path" json:"path,omitempty"`
+	// pred is the predecessor of node, which answered the lookup.
+	Pred *Node `protobuf:"bytes,3,opt,name=pred" json:"pred,omitempty"`
 }
 
-func (m *KeyVal) Reset()                    { *m = KeyVal{} }
-func (m *KeyVal) String() string            { return proto.CompactTextString(m) }
-func (*KeyVal) ProtoMessage()               {}
-func (*KeyVal) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (m *LookupResponse) Reset()                    { *m = LookupResponse{} }
+func (m *LookupResponse) String() string            { return proto.CompactTextString(m) }
+func (*LookupResponse) ProtoMessage()               {}
+func (*LookupResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
 
-func (m *KeyVal) GetKey() string {
+func (m *LookupResponse) GetNode() *Node {
 	if m != nil {
-		return m.Key
+		return m.Node
 	}
-	return ""
+	return nil
 }
 
-func (m *KeyVal) GetVal() []byte {
+func (m *LookupResponse) GetPath() []*Hop {
 	if m != nil {
-		return m.Val
+		return m.Path
 	}
 	return nil
 }
 
-type ID struct {
-	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+func (m *LookupResponse) GetPred() *Node {
+	if m != nil {
+		return m.Pred
+	}
+	return nil
 }
 
-func (m *ID) Reset()                    { *m = ID{} }
-func (m *ID) String() string            { return proto.CompactTextString(m) }
-func (*ID) ProtoMessage()               {}
-func (*ID) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+// Finger is an entry of a finger table.
+type Finger struct {
+	Node *Node `protobuf:"bytes,1,opt,name=node" json:"node,omitempty"`
+	// index is the index of the entry, or -1 if node is the node that owns the
+	// finger table.
+	Index int32 `protobuf:"varint,2,opt,name=index" json:"index,omitempty"`
+}
 
-func (m *ID) GetId() []byte {
+func (m *Finger) Reset()                    { *m = Finger{} }
+func (m *Finger) String() string            { return proto.CompactTextString(m) }
+func (*Finger) ProtoMessage()               {}
+func (*Finger) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *Finger) GetNode() *Node {
 	if m != nil {
-		return m.Id
+		return m.Node
 	}
 	return nil
 }
 
+func (m *Finger) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
 type Key struct {
 	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	// check_owner makes the node refuse the key if it does not own it.
+	CheckOwner bool `protobuf:"varint,2,opt,name=check_owner,json=checkOwner" json:"check_owner,omitempty"`
 }
 
 func (m *Key) Reset()                    { *m = Key{} }
 func (m *Key) String() string            { return proto.CompactTextString(m) }
 func (*Key) ProtoMessage()               {}
-func (*Key) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*Key) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
 
 func (m *Key) GetKey() string {
 	if m != nil {
@@ -280,14 +1427,22 @@ func (m *Key) GetKey() string {
 	return ""
 }
 
+func (m *Key) GetCheckOwner() bool {
+	if m != nil {
+		return m.CheckOwner
+	}
+	return false
+}
+
 type Val struct {
-	Val []byte `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	Val     []byte `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	Version uint64 `protobuf:"varint,2,opt,name=version" json:"version,omitempty"`
 }
 
 func (m *Val) Reset()                    { *m = Val{} }
 func (m *Val) String() string            { return proto.CompactTextString(m) }
 func (*Val) ProtoMessage()               {}
-func (*Val) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*Val) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
 
 func (m *Val) GetVal() []byte {
 	if m != nil {
@@ -296,22 +1451,81 @@ func (m *Val) GetVal() []byte {
 	return nil
 }
 
+func (m *Val) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type Version struct {
+	Version uint64 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *Version) Reset()                    { *m = Version{} }
+func (m *Version) String() string            { return proto.CompactTextString(m) }
+func (*Version) ProtoMessage()               {}
+func (*Version) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *Version) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Node)(nil), "gmajpb.Node")
 	proto.RegisterType((*GetIDRequest)(nil), "gmajpb.GetIDRequest")
 	proto.RegisterType((*GetIDResponse)(nil), "gmajpb.GetIDResponse")
 	proto.RegisterType((*LocateRequest)(nil), "gmajpb.LocateRequest")
 	proto.RegisterType((*LocateResponse)(nil), "gmajpb.LocateResponse")
+	proto.RegisterType((*Hop)(nil), "gmajpb.Hop")
 	proto.RegisterType((*GetRequest)(nil), "gmajpb.GetRequest")
 	proto.RegisterType((*GetResponse)(nil), "gmajpb.GetResponse")
 	proto.RegisterType((*PutRequest)(nil), "gmajpb.PutRequest")
 	proto.RegisterType((*PutResponse)(nil), "gmajpb.PutResponse")
+	proto.RegisterType((*DeleteRequest)(nil), "gmajpb.DeleteRequest")
+	proto.RegisterType((*DeleteResponse)(nil), "gmajpb.DeleteResponse")
+	proto.RegisterType((*ListRequest)(nil), "gmajpb.ListRequest")
+	proto.RegisterType((*ListResponse)(nil), "gmajpb.ListResponse")
+	proto.RegisterType((*ListItem)(nil), "gmajpb.ListItem")
+	proto.RegisterType((*MultiGetRequest)(nil), "gmajpb.MultiGetRequest")
+	proto.RegisterType((*MultiGetResponse)(nil), "gmajpb.MultiGetResponse")
+	proto.RegisterType((*GetResult)(nil), "gmajpb.GetResult")
+	proto.RegisterType((*MultiPutRequest)(nil), "gmajpb.MultiPutRequest")
+	proto.RegisterType((*MultiPutResponse)(nil), "gmajpb.MultiPutResponse")
+	proto.RegisterType((*PutResult)(nil), "gmajpb.PutResult")
+	proto.RegisterType((*KeyError)(nil), "gmajpb.KeyError")
+	proto.RegisterType((*WatchRequest)(nil), "gmajpb.WatchRequest")
+	proto.RegisterType((*WatchEvent)(nil), "gmajpb.WatchEvent")
+	proto.RegisterType((*TxnRequest)(nil), "gmajpb.TxnRequest")
+	proto.RegisterType((*TxnOp)(nil), "gmajpb.TxnOp")
+	proto.RegisterType((*TxnResponse)(nil), "gmajpb.TxnResponse")
 	proto.RegisterType((*TransferKeysReq)(nil), "gmajpb.TransferKeysReq")
 	proto.RegisterType((*MT)(nil), "gmajpb.MT")
+	proto.RegisterType((*Nodes)(nil), "gmajpb.Nodes")
 	proto.RegisterType((*KeyVal)(nil), "gmajpb.KeyVal")
+	proto.RegisterType((*KeyVals)(nil), "gmajpb.KeyVals")
+	proto.RegisterType((*KeyRange)(nil), "gmajpb.KeyRange")
+	proto.RegisterType((*MerkleTree)(nil), "gmajpb.MerkleTree")
+	proto.RegisterType((*ListKeysReq)(nil), "gmajpb.ListKeysReq")
+	proto.RegisterType((*WatchKeysReq)(nil), "gmajpb.WatchKeysReq")
+	proto.RegisterType((*PrepareReq)(nil), "gmajpb.PrepareReq")
+	proto.RegisterType((*TxnID)(nil), "gmajpb.TxnID")
+	proto.RegisterType((*TxnStatus)(nil), "gmajpb.TxnStatus")
+	proto.RegisterType((*Versions)(nil), "gmajpb.Versions")
+	proto.RegisterType((*BucketsReq)(nil), "gmajpb.BucketsReq")
 	proto.RegisterType((*ID)(nil), "gmajpb.ID")
+	proto.RegisterType((*LookupRequest)(nil), "gmajpb.LookupRequest")
+	proto.RegisterType((*LookupResponse)(nil), "gmajpb.LookupResponse")
+	proto.RegisterType((*Finger)(nil), "gmajpb.Finger")
 	proto.RegisterType((*Key)(nil), "gmajpb.Key")
 	proto.RegisterType((*Val)(nil), "gmajpb.Val")
+	proto.RegisterType((*Version)(nil), "gmajpb.Version")
+	proto.RegisterEnum("gmajpb.PutMode", PutMode_name, PutMode_value)
+	proto.RegisterEnum("gmajpb.EventType", EventType_name, EventType_value)
+	proto.RegisterEnum("gmajpb.TxnStatus_Outcome", TxnStatus_Outcome_name, TxnStatus_Outcome_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -333,6 +1547,33 @@ type GMajClient interface {
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	// Put writes a key value pair to the Chord ring.
 	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error)
+	// PutStream is Put for values too large for one message. The first
+	// request has the key and the other fields of the put, and the values of
+	// all the requests are joined together.
+	PutStream(ctx context.Context, opts ...grpc.CallOption) (GMaj_PutStreamClient, error)
+	// GetStream is Get for values too large for one message. The first
+	// response has the version, and the values of all the responses are
+	// joined together.
+	GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (GMaj_GetStreamClient, error)
+	// Delete removes a key from the Chord ring.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// List returns the keys in the Chord ring a page at a time, in order of
+	// their hashed IDs.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// MultiGet gets the values of many keys, with one request to each node
+	// that owns some of them.
+	MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error)
+	// MultiPut writes many key value pairs, with one request to each node
+	// that owns some of them. Each put succeeds or fails on its own.
+	MultiPut(ctx context.Context, in *MultiPutRequest, opts ...grpc.CallOption) (*MultiPutResponse, error)
+	// Watch sends the changes to a key, or to the keys with a prefix, as they
+	// happen, until the client cancels it. The watch follows the keys when
+	// they move to other nodes. A watch of one key gets the changes made
+	// while it moved; a watch of a prefix gets a RESYNC event instead.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (GMaj_WatchClient, error)
+	// Txn writes and deletes several keys atomically: either all of the
+	// operations happen, or none do.
+	Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error)
 }
 
 type gMajClient struct {
@@ -379,6 +1620,149 @@ func (c *gMajClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.CallO
 	return out, nil
 }
 
+func (c *gMajClient) PutStream(ctx context.Context, opts ...grpc.CallOption) (GMaj_PutStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GMaj_serviceDesc.Streams[0], c.cc, "/gmajpb.GMaj/PutStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gMajPutStreamClient{stream}
+	return x, nil
+}
+
+type GMaj_PutStreamClient interface {
+	Send(*PutRequest) error
+	CloseAndRecv() (*PutResponse, error)
+	grpc.ClientStream
+}
+
+type gMajPutStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *gMajPutStreamClient) Send(m *PutRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *gMajPutStreamClient) CloseAndRecv() (*PutResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(PutResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gMajClient) GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (GMaj_GetStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GMaj_serviceDesc.Streams[1], c.cc, "/gmajpb.GMaj/GetStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gMajGetStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GMaj_GetStreamClient interface {
+	Recv() (*GetResponse, error)
+	grpc.ClientStream
+}
+
+type gMajGetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *gMajGetStreamClient) Recv() (*GetResponse, error) {
+	m := new(GetResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gMajClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := grpc.Invoke(ctx, "/gmajpb.GMaj/Delete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gMajClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := grpc.Invoke(ctx, "/gmajpb.GMaj/List", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gMajClient) MultiGet(ctx context.Context, in *MultiGetRequest, opts ...grpc.CallOption) (*MultiGetResponse, error) {
+	out := new(MultiGetResponse)
+	err := grpc.Invoke(ctx, "/gmajpb.GMaj/MultiGet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gMajClient) MultiPut(ctx context.Context, in *MultiPutRequest, opts ...grpc.CallOption) (*MultiPutResponse, error) {
+	out := new(MultiPutResponse)
+	err := grpc.Invoke(ctx, "/gmajpb.GMaj/MultiPut", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gMajClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (GMaj_WatchClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_GMaj_serviceDesc.Streams[2], c.cc, "/gmajpb.GMaj/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &gMajWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GMaj_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type gMajWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *gMajWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *gMajClient) Txn(ctx context.Context, in *TxnRequest, opts ...grpc.CallOption) (*TxnResponse, error) {
+	out := new(TxnResponse)
+	err := grpc.Invoke(ctx, "/gmajpb.GMaj/Txn", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for GMaj service
 
 type GMajServer interface {
@@ -390,6 +1774,33 @@ type GMajServer interface {
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	// Put writes a key value pair to the Chord ring.
 	Put(context.Context, *PutRequest) (*PutResponse, error)
+	// PutStream is Put for values too large for one message. The first
+	// request has the key and the other fields of the put, and the values of
+	// all the requests are joined together.
+	PutStream(GMaj_PutStreamServer) error
+	// GetStream is Get for values too large for one message. The first
+	// response has the version, and the values of all the responses are
+	// joined together.
+	GetStream(*GetRequest, GMaj_GetStreamServer) error
+	// Delete removes a key from the Chord ring.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// List returns the keys in the Chord ring a page at a time, in order of
+	// their hashed IDs.
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// MultiGet gets the values of many keys, with one request to each node
+	// that owns some of them.
+	MultiGet(context.Context, *MultiGetRequest) (*MultiGetResponse, error)
+	// MultiPut writes many key value pairs, with one request to each node
+	// that owns some of them. Each put succeeds or fails on its own.
+	MultiPut(context.Context, *MultiPutRequest) (*MultiPutResponse, error)
+	// Watch sends the changes to a key, or to the keys with a prefix, as they
+	// happen, until the client cancels it. The watch follows the keys when
+	// they move to other nodes. A watch of one key gets the changes made
+	// while it moved; a watch of a prefix gets a RESYNC event instead.
+	Watch(*WatchRequest, GMaj_WatchServer) error
+	// Txn writes and deletes several keys atomically: either all of the
+	// operations happen, or none do.
+	Txn(context.Context, *TxnRequest) (*TxnResponse, error)
 }
 
 func RegisterGMajServer(s *grpc.Server, srv GMajServer) {
@@ -468,6 +1879,164 @@ func _GMaj_Put_Handler(srv interface{}, ctx context.Context, dec func(interface{
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GMaj_PutStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GMajServer).PutStream(&gMajPutStreamServer{stream})
+}
+
+type GMaj_PutStreamServer interface {
+	SendAndClose(*PutResponse) error
+	Recv() (*PutRequest, error)
+	grpc.ServerStream
+}
+
+type gMajPutStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *gMajPutStreamServer) SendAndClose(m *PutResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *gMajPutStreamServer) Recv() (*PutRequest, error) {
+	m := new(PutRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _GMaj_GetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GMajServer).GetStream(m, &gMajGetStreamServer{stream})
+}
+
+type GMaj_GetStreamServer interface {
+	Send(*GetResponse) error
+	grpc.ServerStream
+}
+
+type gMajGetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *gMajGetStreamServer) Send(m *GetResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GMaj_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GMajServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gmajpb.GMaj/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GMajServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GMaj_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GMajServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gmajpb.GMaj/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GMajServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GMaj_MultiGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GMajServer).MultiGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gmajpb.GMaj/MultiGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GMajServer).MultiGet(ctx, req.(*MultiGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GMaj_MultiPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultiPutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GMajServer).MultiPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gmajpb.GMaj/MultiPut",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GMajServer).MultiPut(ctx, req.(*MultiPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GMaj_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GMajServer).Watch(m, &gMajWatchServer{stream})
+}
+
+type GMaj_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type gMajWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *gMajWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GMaj_Txn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TxnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GMajServer).Txn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gmajpb.GMaj/Txn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GMajServer).Txn(ctx, req.(*TxnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _GMaj_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gmajpb.GMaj",
 	HandlerType: (*GMajServer)(nil),
@@ -488,38 +2057,149 @@ var _GMaj_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Put",
 			Handler:    _GMaj_Put_Handler,
 		},
+		{
+			MethodName: "Delete",
+			Handler:    _GMaj_Delete_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _GMaj_List_Handler,
+		},
+		{
+			MethodName: "MultiGet",
+			Handler:    _GMaj_MultiGet_Handler,
+		},
+		{
+			MethodName: "MultiPut",
+			Handler:    _GMaj_MultiPut_Handler,
+		},
+		{
+			MethodName: "Txn",
+			Handler:    _GMaj_Txn_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PutStream",
+			Handler:       _GMaj_PutStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetStream",
+			Handler:       _GMaj_GetStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _GMaj_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "github.com/r-medina/gmaj/gmajpb/gmaj.proto",
 }
 
 func init() { proto.RegisterFile("github.com/r-medina/gmaj/gmajpb/gmaj.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 398 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x74, 0x52, 0x6d, 0xcb, 0xda, 0x30,
-	0x14, 0xa5, 0x2f, 0x56, 0xbc, 0xbe, 0x4c, 0x32, 0x37, 0xc5, 0x0f, 0x9b, 0xcb, 0x18, 0x88, 0x6c,
-	0x15, 0x9c, 0xb0, 0x3f, 0x20, 0x14, 0x71, 0x0e, 0x57, 0x64, 0x5f, 0x25, 0x9a, 0xe8, 0xea, 0x63,
-	0x1b, 0x6d, 0x53, 0xc1, 0x1f, 0xfa, 0xfc, 0x9f, 0x87, 0xa6, 0xd1, 0xd6, 0xb7, 0x2f, 0xcd, 0xbd,
-	0x37, 0xe7, 0x1c, 0x4e, 0xee, 0x29, 0xf4, 0x36, 0x9e, 0xf8, 0x1f, 0x2f, 0xed, 0x15, 0xf7, 0xfb,
-	0xe1, 0x0f, 0x9f, 0x51, 0x2f, 0x20, 0xfd, 0x8d, 0x4f, 0xb6, 0xf2, 0xb3, 0x5f, 0xca, 0xc3, 0xde,
-	0x87, 0x5c, 0x70, 0x64, 0xa5, 0x23, 0xdc, 0x03, 0xf3, 0x0f, 0xa7, 0x0c, 0xd5, 0x40, 0xf7, 0x68,
-	0x4b, 0xeb, 0x68, 0xdd, 0x8a, 0xab, 0x7b, 0x14, 0x21, 0x30, 0x09, 0xa5, 0x61, 0x4b, 0xef, 0x68,
-	0xdd, 0x92, 0x2b, 0x6b, 0x5c, 0x83, 0x8a, 0xc3, 0xc4, 0x78, 0xe4, 0xb2, 0x43, 0xcc, 0x22, 0x81,
-	0x3f, 0x43, 0x55, 0xf5, 0xd1, 0x9e, 0x07, 0xd1, 0x9d, 0x08, 0xfe, 0x02, 0xd5, 0xdf, 0x7c, 0x45,
-	0x04, 0x53, 0x0c, 0x54, 0x07, 0xe3, 0x85, 0x9d, 0x24, 0xa2, 0xe4, 0x26, 0x25, 0x1e, 0x40, 0xed,
-	0x0c, 0x51, 0x22, 0x1d, 0x30, 0x03, 0x4e, 0x99, 0x04, 0x95, 0x07, 0x15, 0x3b, 0x35, 0x6a, 0x27,
-	0x2e, 0x5d, 0x79, 0x83, 0x3f, 0x01, 0x38, 0x4c, 0x3c, 0xd7, 0xfc, 0x0a, 0x65, 0x79, 0xaf, 0x04,
-	0x1b, 0x50, 0x38, 0x92, 0x5d, 0xcc, 0x94, 0xb1, 0xb4, 0xc1, 0x43, 0x80, 0x59, 0xfc, 0x5c, 0x24,
-	0x63, 0xe9, 0x79, 0x56, 0x15, 0xca, 0x92, 0x95, 0x4a, 0xe3, 0xbf, 0xf0, 0x6e, 0x1e, 0x92, 0x20,
-	0x5a, 0xb3, 0x70, 0xc2, 0x4e, 0x91, 0xcb, 0x0e, 0xa8, 0x09, 0xc5, 0x75, 0xc8, 0xfd, 0xc5, 0x65,
-	0x11, 0x56, 0xd2, 0x8e, 0x29, 0xfa, 0x06, 0x45, 0xc1, 0x17, 0xf2, 0x69, 0xfa, 0x83, 0xa7, 0x59,
-	0x82, 0x27, 0x27, 0x36, 0x41, 0x9f, 0xce, 0xf1, 0x77, 0xb0, 0x26, 0xec, 0xf4, 0x8f, 0xec, 0x1e,
-	0x38, 0xab, 0x83, 0x71, 0x24, 0x3b, 0xe5, 0x2b, 0x29, 0x71, 0x03, 0xf4, 0xf1, 0xe8, 0x6e, 0xfb,
-	0x4d, 0x30, 0x26, 0x29, 0xfc, 0x66, 0x3f, 0x4d, 0x30, 0x94, 0x72, 0xa2, 0xa3, 0x5d, 0x74, 0x06,
-	0xaf, 0x1a, 0x98, 0xce, 0x94, 0x6c, 0xd1, 0x10, 0x0a, 0x32, 0x59, 0xd4, 0x38, 0x7b, 0xcc, 0x07,
-	0xdf, 0xfe, 0x70, 0x33, 0x55, 0x8b, 0xfe, 0x05, 0x56, 0x9a, 0x25, 0xba, 0x00, 0xae, 0xe2, 0x6f,
-	0x7f, 0xbc, 0x1d, 0x2b, 0xa2, 0x0d, 0x86, 0xc3, 0x04, 0x42, 0x39, 0xd9, 0x33, 0xe5, 0xfd, 0xd5,
-	0x2c, 0xc3, 0xcf, 0xe2, 0x1c, 0x3e, 0x0b, 0x32, 0xc3, 0xe7, 0x62, 0x5a, 0x5a, 0xf2, 0x9f, 0xff,
-	0xf9, 0x16, 0x00, 0x00, 0xff, 0xff, 0x38, 0xf7, 0x6b, 0x18, 0x21, 0x03, 0x00, 0x00,
+	// 1593 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xdd, 0x52, 0x1b, 0xc7,
+	0x12, 0xf6, 0x6a, 0x77, 0xf5, 0xd3, 0x42, 0x42, 0x67, 0xc0, 0x58, 0xe6, 0xd4, 0x31, 0x78, 0x7c,
+	0xcc, 0xc1, 0xb8, 0x8c, 0x6d, 0xec, 0x3a, 0x76, 0x2e, 0x9c, 0xc4, 0xb6, 0x14, 0xac, 0x02, 0x81,
+	0x32, 0x28, 0x4e, 0x25, 0x17, 0x56, 0x2d, 0xda, 0x01, 0x64, 0x49, 0xbb, 0xeb, 0xdd, 0x59, 0x22,
+	0xdd, 0xe4, 0x01, 0x72, 0x9d, 0x0b, 0x57, 0xe5, 0x1d, 0xf2, 0x8c, 0xa9, 0xf9, 0x93, 0x56, 0x42,
+	0x02, 0x5c, 0xc9, 0x0d, 0xda, 0xfe, 0x9b, 0xe9, 0xfe, 0xba, 0xa7, 0x67, 0x1a, 0xd8, 0x3a, 0xed,
+	0xb0, 0xb3, 0xf8, 0x78, 0xbb, 0xed, 0xf7, 0x1f, 0x87, 0x8f, 0xfa, 0xd4, 0xed, 0x78, 0xce, 0xe3,
+	0xd3, 0xbe, 0xf3, 0x51, 0xfc, 0x09, 0x8e, 0xc5, 0xcf, 0x76, 0x10, 0xfa, 0xcc, 0x47, 0x69, 0xc9,
+	0xc2, 0x5b, 0x60, 0x1d, 0xf8, 0x2e, 0x45, 0x45, 0x48, 0x75, 0xdc, 0xb2, 0xb1, 0x6e, 0x6c, 0x2e,
+	0x90, 0x54, 0xc7, 0x45, 0x08, 0x2c, 0xc7, 0x75, 0xc3, 0x72, 0x6a, 0xdd, 0xd8, 0xcc, 0x11, 0xf1,
+	0x8d, 0x8b, 0xb0, 0xb0, 0x4b, 0x59, 0xad, 0x42, 0xe8, 0xa7, 0x98, 0x46, 0x0c, 0xaf, 0x41, 0x41,
+	0xd1, 0x51, 0xe0, 0x7b, 0xd1, 0x85, 0x45, 0xf0, 0x0b, 0x28, 0xec, 0xfb, 0x6d, 0x87, 0x51, 0x65,
+	0x81, 0x4a, 0x60, 0x76, 0xe9, 0x50, 0x68, 0xe4, 0x08, 0xff, 0x44, 0xcb, 0x60, 0xb3, 0xd0, 0x69,
+	0x53, 0xb1, 0x51, 0x96, 0x48, 0x02, 0x1f, 0x41, 0x51, 0x1b, 0xaa, 0xa5, 0xd7, 0xc1, 0xf2, 0x7c,
+	0x97, 0x0a, 0xd3, 0xfc, 0xce, 0xc2, 0xb6, 0x74, 0x7f, 0x9b, 0xfb, 0x4e, 0x84, 0x04, 0xad, 0x81,
+	0x15, 0x38, 0xec, 0xac, 0x9c, 0x5a, 0x37, 0x37, 0xf3, 0x3b, 0x79, 0xad, 0xf1, 0xce, 0x0f, 0x88,
+	0x10, 0xe0, 0x0f, 0x60, 0xbe, 0xf3, 0x83, 0x6b, 0xac, 0xb4, 0x02, 0xe9, 0x93, 0x8e, 0x77, 0x4a,
+	0x65, 0xf4, 0x36, 0x51, 0x14, 0xfa, 0x0f, 0x40, 0xcf, 0x61, 0xd4, 0x6b, 0x0f, 0x5b, 0x5e, 0x54,
+	0x36, 0xd7, 0x8d, 0x4d, 0x93, 0xe4, 0x14, 0xe7, 0x20, 0xc2, 0x77, 0x00, 0x76, 0x29, 0x9b, 0x1b,
+	0x2a, 0x7e, 0x05, 0x79, 0x21, 0x57, 0x11, 0x2d, 0x83, 0x7d, 0xee, 0xf4, 0x62, 0xaa, 0xf0, 0x92,
+	0x04, 0x2a, 0x43, 0xe6, 0x9c, 0x86, 0x51, 0xc7, 0xf7, 0xc4, 0xe6, 0x16, 0xd1, 0x24, 0xfe, 0xc3,
+	0x00, 0x68, 0xc4, 0xec, 0x52, 0x28, 0xe5, 0x82, 0xa9, 0xe4, 0x82, 0xf7, 0xc0, 0xea, 0xf3, 0x70,
+	0xb9, 0xbb, 0xc5, 0x9d, 0x45, 0x1d, 0x6e, 0x23, 0x66, 0x75, 0x11, 0x31, 0x17, 0xa2, 0x07, 0x50,
+	0xa2, 0x83, 0x80, 0xb6, 0x19, 0x75, 0x5b, 0x7a, 0x7b, 0x4b, 0x6c, 0xbf, 0xa8, 0xf9, 0xef, 0x25,
+	0x1b, 0xdd, 0x84, 0x34, 0x63, 0x3d, 0x0e, 0x80, 0x2d, 0x00, 0xb0, 0x19, 0xeb, 0x1d, 0x44, 0xf8,
+	0x7f, 0x90, 0x6f, 0xc4, 0xe3, 0xe0, 0x12, 0x61, 0x18, 0x93, 0x61, 0xdc, 0x85, 0x42, 0x85, 0xf6,
+	0xe8, 0x25, 0x35, 0x81, 0x4b, 0x50, 0xd4, 0x2a, 0x72, 0x39, 0xfc, 0x33, 0xe4, 0xf7, 0x3b, 0xd1,
+	0x28, 0xf6, 0x65, 0xb0, 0x7b, 0x9d, 0x7e, 0x87, 0x09, 0xa3, 0x02, 0x91, 0x04, 0x4f, 0x9b, 0x08,
+	0x39, 0x52, 0xb5, 0xa4, 0x28, 0x9e, 0xb6, 0xc0, 0x39, 0xa5, 0x2d, 0xe6, 0x77, 0xa9, 0x27, 0x70,
+	0xc8, 0x91, 0x1c, 0xe7, 0x34, 0x39, 0x03, 0x7f, 0x80, 0x05, 0xb9, 0xb6, 0x72, 0x7d, 0x03, 0xec,
+	0x0e, 0xa3, 0xfd, 0xa8, 0x6c, 0x88, 0x42, 0x2a, 0x69, 0xc4, 0xb8, 0x52, 0x8d, 0xd1, 0x3e, 0x91,
+	0x62, 0xb4, 0x01, 0x8b, 0x1e, 0x1d, 0xb0, 0x56, 0x62, 0x6d, 0x79, 0x58, 0x0a, 0x9c, 0xdd, 0x18,
+	0xad, 0xbf, 0x0f, 0x59, 0x6d, 0x7a, 0xed, 0xa4, 0x25, 0xe0, 0x33, 0x27, 0xe1, 0xbb, 0x0f, 0x8b,
+	0xf5, 0xb8, 0xc7, 0x3a, 0x89, 0x4a, 0x43, 0x60, 0x75, 0xe9, 0x50, 0xfa, 0x9b, 0x23, 0xe2, 0x1b,
+	0x7f, 0x03, 0xa5, 0xb1, 0x9a, 0x0a, 0xec, 0x21, 0x64, 0x42, 0x1a, 0xc5, 0x3d, 0xa6, 0x43, 0xfb,
+	0x97, 0x0e, 0x4d, 0x6a, 0xc5, 0x3d, 0x46, 0xb4, 0x06, 0x8e, 0x21, 0x37, 0xe2, 0xfe, 0x7d, 0xb7,
+	0x39, 0xa8, 0x34, 0x0c, 0xfd, 0x50, 0x54, 0x55, 0x02, 0xd4, 0x3d, 0x3a, 0xac, 0x72, 0x3e, 0x91,
+	0x62, 0xfc, 0x95, 0x0a, 0x2f, 0x51, 0xe8, 0x1b, 0x60, 0x05, 0xf1, 0xc8, 0x67, 0x94, 0x28, 0x60,
+	0xa5, 0x41, 0x84, 0x7c, 0x14, 0x72, 0x23, 0xbe, 0x4e, 0xc8, 0x8d, 0xf8, 0x42, 0xc8, 0x2d, 0xc8,
+	0x35, 0xe2, 0xf9, 0x21, 0xcf, 0x3d, 0x99, 0xe3, 0xe0, 0xcc, 0xcb, 0x83, 0x7b, 0x09, 0x59, 0xcd,
+	0xe2, 0x49, 0x6b, 0xeb, 0x2e, 0x54, 0x20, 0xe2, 0x9b, 0xef, 0xd0, 0xa7, 0x51, 0xe4, 0x9c, 0x52,
+	0x55, 0x49, 0x9a, 0xc4, 0x2f, 0x61, 0xe1, 0x47, 0x87, 0xb5, 0xcf, 0xe6, 0x1f, 0xfe, 0x15, 0x48,
+	0x07, 0x21, 0x3d, 0xe9, 0x0c, 0x74, 0xf1, 0x4b, 0x0a, 0xc7, 0x00, 0xc2, 0xb2, 0x7a, 0x4e, 0x3d,
+	0x86, 0xee, 0x83, 0xc5, 0x86, 0x81, 0xdc, 0xb5, 0x38, 0x06, 0x43, 0x08, 0x9b, 0xc3, 0x80, 0x12,
+	0x21, 0xd6, 0xcb, 0xa7, 0x66, 0xe4, 0xdb, 0x9c, 0x93, 0x6f, 0x6b, 0xb2, 0x4c, 0x1f, 0x01, 0x34,
+	0x07, 0x9e, 0x76, 0x77, 0x0d, 0x4c, 0x3f, 0xd0, 0x29, 0x28, 0xe8, 0x5d, 0x9b, 0x03, 0xef, 0x30,
+	0x20, 0x5c, 0x82, 0xff, 0x34, 0xc0, 0x16, 0xe4, 0xb5, 0x4b, 0x6d, 0x05, 0xd2, 0xae, 0xe8, 0x11,
+	0xc2, 0xa3, 0x2c, 0x51, 0xd4, 0xa8, 0xdd, 0x59, 0x5f, 0xda, 0xee, 0xec, 0xab, 0xda, 0x5d, 0x3a,
+	0xd9, 0xee, 0x1e, 0x40, 0x5e, 0xc4, 0xa7, 0xea, 0x6c, 0x15, 0xb2, 0x6a, 0x1d, 0x19, 0xa5, 0x45,
+	0x46, 0x34, 0xfe, 0x1e, 0x16, 0x9b, 0xa1, 0xe3, 0x45, 0x27, 0x34, 0xdc, 0xa3, 0xc3, 0x88, 0xd0,
+	0x4f, 0xe8, 0x16, 0x64, 0x4e, 0x42, 0xbf, 0xdf, 0x1a, 0x5d, 0x96, 0x69, 0x4e, 0xd6, 0x5c, 0x74,
+	0x1f, 0x32, 0xcc, 0x6f, 0x89, 0xeb, 0x29, 0x35, 0xe3, 0x7a, 0x4a, 0x33, 0x9f, 0xff, 0x62, 0x0b,
+	0x52, 0xf5, 0x26, 0x7e, 0x08, 0x36, 0xa7, 0x22, 0x84, 0xc1, 0xe6, 0x26, 0x1a, 0xe0, 0x49, 0x1b,
+	0x29, 0xc2, 0x9f, 0x53, 0x90, 0xde, 0xa3, 0xc3, 0xf7, 0x4e, 0x6f, 0x06, 0xc4, 0x25, 0x30, 0xcf,
+	0x9d, 0x9e, 0x02, 0x98, 0x7f, 0xa2, 0x35, 0xc8, 0xb7, 0xcf, 0x68, 0xbb, 0xdb, 0xf2, 0x7f, 0xf1,
+	0x68, 0xa8, 0x30, 0x06, 0xc1, 0x3a, 0xe4, 0x1c, 0x9e, 0x7a, 0x89, 0xb8, 0x2b, 0xa0, 0xce, 0x12,
+	0x4d, 0x72, 0x2c, 0x98, 0x8a, 0x57, 0x80, 0x9a, 0x25, 0x23, 0x3a, 0x59, 0x30, 0xe9, 0xc9, 0x33,
+	0xa4, 0xf3, 0x96, 0xf9, 0xd2, 0xbc, 0x65, 0xaf, 0xca, 0x5b, 0x2e, 0x91, 0x37, 0x7e, 0xec, 0xfa,
+	0x7e, 0x48, 0xcb, 0x20, 0x1c, 0x13, 0xdf, 0xf8, 0x39, 0x64, 0x24, 0x32, 0x11, 0x7a, 0x00, 0xd9,
+	0x2e, 0x1d, 0xb6, 0xce, 0x9d, 0x9e, 0x06, 0xb3, 0x98, 0x38, 0xcc, 0xef, 0x9d, 0x1e, 0xc9, 0x74,
+	0xa5, 0xaa, 0x3a, 0xcc, 0xc4, 0xf1, 0x4e, 0xe9, 0xfc, 0x7c, 0x2e, 0x81, 0xcd, 0x7c, 0xce, 0x96,
+	0xd0, 0x5a, 0xcc, 0xaf, 0xb9, 0xf8, 0xbf, 0x00, 0x75, 0x1a, 0x76, 0x7b, 0xb4, 0x19, 0x52, 0x51,
+	0xc8, 0x67, 0x4e, 0x74, 0xa6, 0xb2, 0xb7, 0x40, 0x14, 0x85, 0x7f, 0x33, 0xe4, 0x9d, 0xa7, 0x6b,
+	0xe6, 0x36, 0x64, 0x23, 0xe6, 0x84, 0x6c, 0xbc, 0x49, 0x46, 0xd0, 0x35, 0x17, 0xfd, 0x1b, 0x72,
+	0x52, 0x34, 0x3e, 0xb4, 0x52, 0x77, 0x8f, 0x0e, 0x39, 0x10, 0xd4, 0x73, 0xb9, 0x95, 0x3a, 0xba,
+	0xd4, 0x73, 0x6b, 0xee, 0xf8, 0x0a, 0xb5, 0x66, 0x5f, 0xa1, 0x76, 0xf2, 0x0a, 0xc5, 0x9f, 0x0d,
+	0xd5, 0x80, 0xb4, 0x37, 0xd7, 0x6e, 0x40, 0x49, 0x6c, 0xcc, 0xd9, 0xd8, 0x58, 0x63, 0x6c, 0xf8,
+	0x2a, 0xbc, 0x1d, 0xf7, 0xa9, 0x76, 0x40, 0x52, 0xf3, 0x0b, 0x07, 0xff, 0x0a, 0xd0, 0x08, 0x69,
+	0xe0, 0x84, 0xfc, 0x41, 0x21, 0xd2, 0x3e, 0xf0, 0x34, 0x46, 0x39, 0x62, 0xb3, 0x81, 0x57, 0x73,
+	0x27, 0xf2, 0x9a, 0xba, 0x34, 0xaf, 0x68, 0x1b, 0xf2, 0x6d, 0xdf, 0x0f, 0xf9, 0xdb, 0x99, 0x8d,
+	0x5a, 0xfa, 0xe4, 0x91, 0x4a, 0x2a, 0xe0, 0x3b, 0xa2, 0x73, 0xd5, 0x2a, 0x73, 0xb6, 0xc6, 0x0c,
+	0x72, 0xcd, 0x81, 0x77, 0xc4, 0x1c, 0x16, 0x47, 0xe8, 0x19, 0x64, 0xfc, 0x98, 0xb5, 0xfd, 0xbe,
+	0x6e, 0xc1, 0xb7, 0x13, 0xcd, 0x50, 0xea, 0x6c, 0x1f, 0x4a, 0x05, 0xa2, 0x35, 0xf1, 0x0e, 0x64,
+	0x14, 0x0f, 0xe5, 0x21, 0xd3, 0xa8, 0x1e, 0x54, 0x6a, 0x07, 0xbb, 0xa5, 0x1b, 0xa8, 0x00, 0xb9,
+	0xb7, 0x87, 0xf5, 0x7a, 0xad, 0xd9, 0xac, 0x56, 0x4a, 0x06, 0x97, 0xbd, 0x7e, 0x73, 0x48, 0x38,
+	0x91, 0xc2, 0x1b, 0x90, 0x55, 0x27, 0x21, 0xba, 0xb4, 0x39, 0x1d, 0x00, 0xbc, 0x89, 0xdb, 0x5d,
+	0xca, 0x44, 0x56, 0x37, 0xc0, 0x0e, 0x79, 0x41, 0x97, 0x8d, 0x0b, 0x17, 0x99, 0x28, 0x74, 0x22,
+	0xc5, 0x3c, 0x1b, 0xc7, 0xd2, 0x4a, 0xa0, 0x59, 0x20, 0x9a, 0xc4, 0xcb, 0x90, 0xaa, 0x55, 0x2e,
+	0xcc, 0x01, 0x75, 0x3e, 0x07, 0xf8, 0xdd, 0x38, 0xd0, 0x17, 0xc2, 0x94, 0xc2, 0xec, 0x29, 0x20,
+	0xf1, 0x0e, 0x37, 0x93, 0xef, 0x70, 0x1c, 0x43, 0x51, 0x2f, 0xf7, 0x8f, 0x4d, 0x07, 0x7c, 0x89,
+	0x20, 0xa4, 0xee, 0xcc, 0x84, 0x0b, 0x09, 0xfe, 0x16, 0xd2, 0xdf, 0xc9, 0x41, 0xe0, 0xea, 0xed,
+	0x96, 0xc1, 0xee, 0x78, 0x2e, 0x1d, 0xa8, 0x09, 0x42, 0x12, 0xf8, 0x25, 0x98, 0x7b, 0x74, 0x38,
+	0xe3, 0xf0, 0x4c, 0xb5, 0xdb, 0xd4, 0x74, 0xbb, 0xc5, 0x4f, 0xc1, 0x54, 0xad, 0x9b, 0x37, 0x6a,
+	0x63, 0xdc, 0xa8, 0xe7, 0xcf, 0x0b, 0xf7, 0x20, 0xa3, 0x9b, 0xe1, 0xdc, 0xd7, 0xf8, 0xd6, 0x5d,
+	0xc8, 0xa8, 0x16, 0x8b, 0x00, 0xd2, 0x6f, 0x49, 0xf5, 0x75, 0xb3, 0x5a, 0xba, 0xc1, 0xbf, 0x7f,
+	0x68, 0x54, 0xf8, 0xb7, 0xb1, 0xf5, 0x35, 0xe4, 0x46, 0xef, 0x03, 0x5e, 0x64, 0x52, 0xa9, 0x52,
+	0xba, 0xc1, 0x09, 0xa9, 0xa5, 0xca, 0xaf, 0x52, 0xdd, 0xaf, 0x8a, 0xf2, 0xe3, 0xf6, 0xa4, 0x7a,
+	0xf4, 0xd3, 0xc1, 0xdb, 0x92, 0xb9, 0xf3, 0xbb, 0x0d, 0xd6, 0x6e, 0xdd, 0xf9, 0x88, 0x9e, 0x83,
+	0x2d, 0xc6, 0x45, 0xb4, 0x9c, 0x78, 0x77, 0x8e, 0xa6, 0xc9, 0xd5, 0x9b, 0x53, 0x5c, 0x95, 0xda,
+	0x17, 0x90, 0x96, 0xa3, 0x20, 0x1a, 0x29, 0x4c, 0xcc, 0x94, 0xab, 0x2b, 0xd3, 0x6c, 0x65, 0xb8,
+	0x0d, 0xe6, 0x2e, 0x65, 0x08, 0x4d, 0x3c, 0x72, 0xa5, 0xc9, 0xd2, 0x04, 0x6f, 0xac, 0xdf, 0x88,
+	0x13, 0xfa, 0x8d, 0xf8, 0xa2, 0x7e, 0xf2, 0x6d, 0xf9, 0x7f, 0xf1, 0x5c, 0x3c, 0x62, 0x21, 0x75,
+	0xfa, 0xd7, 0xb6, 0xda, 0x34, 0xb8, 0xdd, 0x2e, 0xbd, 0x60, 0x77, 0x85, 0x77, 0x4f, 0x0c, 0x0e,
+	0x84, 0x9c, 0x8a, 0xc6, 0x40, 0x4c, 0x0c, 0x52, 0xab, 0x2b, 0xd3, 0x6c, 0xe5, 0xe8, 0x53, 0xb0,
+	0xf8, 0x45, 0x82, 0x96, 0x92, 0x93, 0x8c, 0x36, 0x5a, 0x9e, 0x64, 0x2a, 0x93, 0x57, 0x90, 0xd5,
+	0xe3, 0x03, 0xba, 0xa5, 0x35, 0xa6, 0xe6, 0x8e, 0xd5, 0xf2, 0x45, 0xc1, 0x94, 0x79, 0x23, 0x9e,
+	0x36, 0x6f, 0xc4, 0x73, 0xcc, 0x93, 0xc8, 0x3e, 0x03, 0x5b, 0x5c, 0x36, 0xe3, 0x42, 0x49, 0x3e,
+	0x7e, 0x57, 0xd1, 0x04, 0x57, 0xd4, 0xe6, 0x13, 0x83, 0xa7, 0xaf, 0x39, 0xf0, 0xc6, 0x80, 0x8e,
+	0x9f, 0x9f, 0xab, 0x4b, 0x13, 0x3c, 0xb9, 0xc9, 0x71, 0x5a, 0xfc, 0x1f, 0xe4, 0xd9, 0x5f, 0x03,
+	0x00, 0x6c, 0xc1, 0x63, 0xfe, 0x35, 0x11, 0x00, 0x00,
 }
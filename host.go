@@ -0,0 +1,519 @@
+package gmaj
+
+import (
+	"sync"
+
+	"github.com/r-medina/gmaj/gmajpb"
+	"github.com/r-medina/gmaj/internal/chord"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// targetIDKey is the metadata key for the ID of the node an RPC is meant for.
+const targetIDKey = "gmaj-target-id-bin"
+
+// host is the transport shared by the (virtual) nodes running behind a single
+// address. Incoming RPCs are routed to a node by
+// the target ID in their metadata, or to the first node when there is none
+// (e.g. for clients of the public API).
+type host struct {
+	addr      string
+	transport Transport
+	config    *nodeConfig
+
+	nodes    map[string]*Node
+	first    *Node
+	nodesMtx sync.RWMutex
+}
+
+// newHost starts listening on the address in opts, using the transport in
+// opts or gRPC if there is none.
+func newHost(opts nodeOptions, cfg *nodeConfig) (*host, error) {
+	h := &host{
+		transport: opts.transport,
+		config:    cfg,
+		nodes:     make(map[string]*Node),
+	}
+	if h.transport == nil {
+		h.transport = newGRPCTransport(cfg, opts)
+	}
+
+	addr, err := h.transport.Listen(opts.addr, router{h})
+	if err != nil {
+		return nil, err
+	}
+	h.addr = addr
+
+	return h, nil
+}
+
+// addNode starts routing RPCs for node's ID to node.
+func (h *host) addNode(node *Node) {
+	h.nodesMtx.Lock()
+	h.nodes[string(node.Id)] = node
+	if h.first == nil {
+		h.first = node
+	}
+	h.nodesMtx.Unlock()
+}
+
+// removeNode stops routing RPCs to node and returns how many nodes are left.
+func (h *host) removeNode(node *Node) int {
+	h.nodesMtx.Lock()
+	defer h.nodesMtx.Unlock()
+
+	delete(h.nodes, string(node.Id))
+	if h.first == node {
+		h.first = nil
+		for _, other := range h.nodes {
+			h.first = other
+			break
+		}
+	}
+
+	return len(h.nodes)
+}
+
+// node returns the node an incoming RPC is meant for.
+func (h *host) node(ctx context.Context) (*Node, error) {
+	h.nodesMtx.RLock()
+	defer h.nodesMtx.RUnlock()
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[targetIDKey]) == 0 {
+		if h.first == nil {
+			return nil, grpc.Errorf(codes.Unavailable, "gmaj: no nodes running")
+		}
+
+		return h.first, nil
+	}
+
+	id := md[targetIDKey][0]
+	node, ok := h.nodes[id]
+	if !ok {
+		// The node was shut down, which callers treat like any other node
+		// that is not running. NotFound would read as a deleted key.
+		return nil, grpc.Errorf(codes.Unavailable, "gmaj: no node with ID %v", IDToString([]byte(id)))
+	}
+
+	return node, nil
+}
+
+// stop stops serving RPCs and closes all the client connections.
+func (h *host) stop() {
+	_ = h.transport.Close()
+}
+
+// targetContext returns a context for an RPC to remoteNode.
+func targetContext(ctx context.Context, remoteNode *gmajpb.Node) context.Context {
+	return metadata.NewOutgoingContext(
+		ctx, metadata.Pairs(targetIDKey, string(remoteNode.Id)),
+	)
+}
+
+//
+// RPC routing
+//
+
+// router implements the gRPC services by passing RPCs on to the node they are
+// meant for.
+type router struct {
+	h *host
+}
+
+var _ chord.ChordServer = router{}
+var _ gmajpb.GMajServer = router{}
+
+func (r router) GetID(ctx context.Context, req *gmajpb.GetIDRequest) (*gmajpb.GetIDResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetID(ctx, req)
+}
+
+func (r router) Locate(ctx context.Context, req *gmajpb.LocateRequest) (*gmajpb.LocateResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Locate(ctx, req)
+}
+
+func (r router) Get(ctx context.Context, req *gmajpb.GetRequest) (*gmajpb.GetResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Get(ctx, req)
+}
+
+func (r router) Put(ctx context.Context, req *gmajpb.PutRequest) (*gmajpb.PutResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Put(ctx, req)
+}
+
+func (r router) PutStream(stream gmajpb.GMaj_PutStreamServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.PutStream(stream)
+}
+
+func (r router) GetStream(req *gmajpb.GetRequest, stream gmajpb.GMaj_GetStreamServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.GetStream(req, stream)
+}
+
+func (r router) Delete(ctx context.Context, req *gmajpb.DeleteRequest) (*gmajpb.DeleteResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Delete(ctx, req)
+}
+
+func (r router) List(ctx context.Context, req *gmajpb.ListRequest) (*gmajpb.ListResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.List(ctx, req)
+}
+
+func (r router) MultiGet(ctx context.Context, req *gmajpb.MultiGetRequest) (*gmajpb.MultiGetResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.MultiGet(ctx, req)
+}
+
+func (r router) MultiPut(ctx context.Context, req *gmajpb.MultiPutRequest) (*gmajpb.MultiPutResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.MultiPut(ctx, req)
+}
+
+func (r router) Watch(req *gmajpb.WatchRequest, stream gmajpb.GMaj_WatchServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.Watch(req, stream)
+}
+
+func (r router) Txn(ctx context.Context, req *gmajpb.TxnRequest) (*gmajpb.TxnResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Txn(ctx, req)
+}
+
+func (r router) GetPredecessor(ctx context.Context, req *gmajpb.MT) (*gmajpb.Node, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetPredecessor(ctx, req)
+}
+
+func (r router) GetSuccessor(ctx context.Context, req *gmajpb.MT) (*gmajpb.Node, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetSuccessor(ctx, req)
+}
+
+func (r router) GetSuccessorList(ctx context.Context, req *gmajpb.MT) (*gmajpb.Nodes, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetSuccessorList(ctx, req)
+}
+
+func (r router) Ping(ctx context.Context, req *gmajpb.MT) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Ping(ctx, req)
+}
+
+func (r router) SetPredecessor(ctx context.Context, req *gmajpb.Node) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.SetPredecessor(ctx, req)
+}
+
+func (r router) SetSuccessor(ctx context.Context, req *gmajpb.Node) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.SetSuccessor(ctx, req)
+}
+
+func (r router) Notify(ctx context.Context, req *gmajpb.Node) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Notify(ctx, req)
+}
+
+func (r router) ClosestPrecedingFinger(ctx context.Context, req *gmajpb.ID) (*gmajpb.Finger, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.ClosestPrecedingFinger(ctx, req)
+}
+
+func (r router) FindSuccessor(ctx context.Context, req *gmajpb.ID) (*gmajpb.Node, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.FindSuccessor(ctx, req)
+}
+
+func (r router) FindSuccessorRecursive(ctx context.Context, req *gmajpb.LookupRequest) (*gmajpb.LookupResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.FindSuccessorRecursive(ctx, req)
+}
+
+func (r router) GetKey(ctx context.Context, req *gmajpb.Key) (*gmajpb.Val, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetKey(ctx, req)
+}
+
+func (r router) PutKeyVal(ctx context.Context, req *gmajpb.KeyVal) (*gmajpb.Version, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.PutKeyVal(ctx, req)
+}
+
+func (r router) PutKeyValStream(stream chord.Chord_PutKeyValStreamServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.PutKeyValStream(stream)
+}
+
+func (r router) GetKeyStream(req *gmajpb.Key, stream chord.Chord_GetKeyStreamServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.GetKeyStream(req, stream)
+}
+
+func (r router) GetReplicaStream(
+	req *gmajpb.Key, stream chord.Chord_GetReplicaStreamServer,
+) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.GetReplicaStream(req, stream)
+}
+
+func (r router) GetBucketsStream(
+	req *gmajpb.BucketsReq, stream chord.Chord_GetBucketsStreamServer,
+) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.GetBucketsStream(req, stream)
+}
+
+func (r router) DeleteKey(ctx context.Context, req *gmajpb.Key) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.DeleteKey(ctx, req)
+}
+
+func (r router) GetReplica(ctx context.Context, req *gmajpb.Key) (*gmajpb.Val, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetReplica(ctx, req)
+}
+
+func (r router) PutReplica(ctx context.Context, req *gmajpb.KeyVal) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.PutReplica(ctx, req)
+}
+
+func (r router) PutReplicaStream(stream chord.Chord_PutReplicaStreamServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.PutReplicaStream(stream)
+}
+
+func (r router) GetMerkleTree(ctx context.Context, req *gmajpb.KeyRange) (*gmajpb.MerkleTree, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetMerkleTree(ctx, req)
+}
+
+func (r router) GetBuckets(ctx context.Context, req *gmajpb.BucketsReq) (*gmajpb.KeyVals, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetBuckets(ctx, req)
+}
+
+func (r router) TransferKeys(ctx context.Context, req *gmajpb.TransferKeysReq) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.TransferKeys(ctx, req)
+}
+
+func (r router) ListKeys(ctx context.Context, req *gmajpb.ListKeysReq) (*gmajpb.KeyVals, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.ListKeys(ctx, req)
+}
+
+func (r router) GetKeys(ctx context.Context, req *gmajpb.MultiGetRequest) (*gmajpb.MultiGetResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetKeys(ctx, req)
+}
+
+func (r router) PutKeyVals(ctx context.Context, req *gmajpb.KeyVals) (*gmajpb.MultiPutResponse, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.PutKeyVals(ctx, req)
+}
+
+func (r router) WatchKeys(req *gmajpb.WatchKeysReq, stream chord.Chord_WatchKeysServer) error {
+	node, err := r.h.node(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return node.WatchKeys(req, stream)
+}
+
+func (r router) Prepare(ctx context.Context, req *gmajpb.PrepareReq) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Prepare(ctx, req)
+}
+
+func (r router) Commit(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.Versions, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Commit(ctx, req)
+}
+
+func (r router) Abort(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.MT, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Abort(ctx, req)
+}
+
+func (r router) GetTxnStatus(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.TxnStatus, error) {
+	node, err := r.h.node(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return node.GetTxnStatus(ctx, req)
+}
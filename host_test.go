@@ -0,0 +1,133 @@
+package gmaj
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestVirtualNodes(t *testing.T) {
+	t.Parallel()
+
+	nodes, err := NewVirtualNodes(nil, 4, WithTransport(NewMemNetwork().Transport()))
+	if err != nil {
+		t.Fatalf("Unable to create virtual nodes, received error:%v", err)
+	}
+
+	<-time.After(testTimeout)
+
+	for _, node := range nodes[1:] {
+		if node.Addr != nodes[0].Addr {
+			t.Fatalf("Expected all nodes on %v, got %v", nodes[0].Addr, node.Addr)
+		}
+		if idsEqual(node.Id, nodes[0].Id) {
+			t.Fatalf("Expected distinct IDs, got %v twice", node.Id)
+		}
+	}
+
+	// Virtual nodes form a ring in the order of their IDs.
+	sorted := append([]*Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Id, sorted[j].Id) < 0
+	})
+	for i, node := range sorted {
+		succ := sorted[(i+1)%len(sorted)]
+		remoteNode, err := node.getSuccessorRPC(context.Background(), node.Node)
+		if err != nil {
+			t.Fatalf("Unexpected error:%v", err)
+		} else if !idsEqual(remoteNode.Id, succ.Id) {
+			t.Fatalf("Unexpected successor. Expected %v got %v", succ.Node, remoteNode)
+		}
+	}
+
+	key, want := "vnode", []byte("value")
+	if err := Put(nodes[0], key, want); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	nodes[0].Shutdown()
+
+	if got, err := Get(nodes[1], key); err != nil {
+		t.Fatalf("Unexpected error getting value: %v", err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value. Expected %q got %q", want, got)
+	}
+
+	if err := nodes[1].pingRPC(context.Background(), nodes[0].Node); err == nil {
+		t.Fatal("Unexpected success pinging node that was shut down")
+	}
+}
+
+func TestVirtualNodeShutdown(t *testing.T) {
+	t.Parallel()
+
+	// Without replicas, the keys of a virtual node are only kept if they are
+	// moved to its successor, which is on the same host.
+	cfg := config.Config
+	cfg.ReplicationFactor = 0
+	nodes, err := NewVirtualNodes(nil, 4, WithConfig(&cfg), WithTransport(NewMemNetwork().Transport()))
+	if err != nil {
+		t.Fatalf("Unable to create virtual nodes, received error:%v", err)
+	}
+	defer func() {
+		for _, node := range nodes[1:] {
+			node.Shutdown()
+		}
+	}()
+
+	<-time.After(testTimeout)
+
+	keys := make([]string, 40)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("vnode%d", i)
+		if err := Put(nodes[1], keys[i], []byte(keys[i])); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+	if len(storeContents(t, nodes[0].datastore)) == 0 {
+		t.Fatal("Expected the node that is shut down to own some keys")
+	}
+
+	nodes[0].Shutdown()
+
+	<-time.After(testTimeout)
+
+	for _, key := range keys {
+		if got, err := Get(nodes[1], key); err != nil {
+			t.Fatalf("Unexpected error getting %q: %v", key, err)
+		} else if string(got) != key {
+			t.Fatalf("Unexpected value. Expected %q got %q", key, got)
+		}
+	}
+}
+
+func TestVirtualNodeIDs(t *testing.T) {
+	t.Parallel()
+
+	// Virtual nodes created with the same ID on another address, e.g. after a
+	// restart, get the same IDs, so that they find the keys they stored.
+	network := NewMemNetwork()
+	var ids [][][]byte
+	for i := 0; i < 2; i++ {
+		nodes, err := NewVirtualNodes(nil, 4, WithID([]byte{7}), WithTransport(network.Transport()))
+		if err != nil {
+			t.Fatalf("Unable to create virtual nodes, received error:%v", err)
+		}
+
+		var nodeIDs [][]byte
+		for _, node := range nodes {
+			nodeIDs = append(nodeIDs, node.Id)
+			defer node.Shutdown()
+		}
+		ids = append(ids, nodeIDs)
+	}
+
+	if nodes1, nodes2 := ids[0], ids[1]; !reflect.DeepEqual(nodes1, nodes2) {
+		t.Fatalf("Expected the same IDs on both addresses, got %v and %v", nodes1, nodes2)
+	}
+}
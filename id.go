@@ -6,24 +6,51 @@ package gmaj
 
 import (
 	"bytes"
-	"crypto/sha1"
 	"errors"
 	"math/big"
+
+	"github.com/r-medina/gmaj/gmajcfg"
 )
 
 // hashKey hashes a string to its appropriate size.
-func hashKey(key string) ([]byte, error) {
-	h := sha1.New()
+func (cfg *nodeConfig) hashKey(key string) ([]byte, error) {
+	h := cfg.NewHash()
 	if _, err := h.Write([]byte(key)); err != nil {
 		return nil, err
 	}
 	v := h.Sum(nil)
 
-	return v[:config.IDLength], nil
+	return cfg.maskID(v[:cfg.IDLength]), nil
+}
+
+// keyID hashes key to its position on the ring.
+func (cfg *nodeConfig) keyID(key string) (ID, error) {
+	hashed, err := cfg.hashKey(key)
+	if err != nil {
+		return ID{}, err
+	}
+
+	return newID(hashed), nil
 }
 
-// NewID takes a string representing
+// NewID takes a string representing an ID as a number (e.g. "42" or "0x2a"),
+// and returns the ID, sized for the default configuration set with Init. IDs
+// for nodes created with WithConfig should come from NewIDWithConfig.
 func NewID(str string) ([]byte, error) {
+	return config.parseID(str)
+}
+
+// NewIDWithConfig is like NewID, but sizes the ID for cfg.
+func NewIDWithConfig(str string, cfg *gmajcfg.Config) ([]byte, error) {
+	nodeCfg, err := newNodeConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodeCfg.parseID(str)
+}
+
+func (cfg *nodeConfig) parseID(str string) ([]byte, error) {
 	i := big.NewInt(0)
 	i.SetString(str, 0)
 	id := i.Bytes()
@@ -31,11 +58,11 @@ func NewID(str string) ([]byte, error) {
 		return nil, errors.New("gmaj: invalid ID")
 	}
 
-	return padID(id), nil
+	return cfg.padID(id), nil
 }
 
-func padID(id []byte) []byte {
-	n := config.IDLength - len(id)
+func (cfg *nodeConfig) padID(id []byte) []byte {
+	n := cfg.IDLength - len(id)
 	if n < 0 {
 		n = 0
 	}
@@ -43,7 +70,17 @@ func padID(id []byte) []byte {
 	_id := make([]byte, n)
 	id = append(_id, id...)
 
-	return id[:config.IDLength]
+	return cfg.maskID(id[:cfg.IDLength])
+}
+
+// maskID clears the bits of id above KeySize, for key sizes that are not
+// multiples of 8. It modifies id in place.
+func (cfg *nodeConfig) maskID(id []byte) []byte {
+	if extra := uint(8*cfg.IDLength - cfg.KeySize); extra > 0 && len(id) > 0 {
+		id[0] &= 0xff >> extra
+	}
+
+	return id
 }
 
 // IDToString converts a []byte to a big.Int string, useful for debugging/logging.
@@ -59,16 +96,93 @@ func idsEqual(a, b []byte) bool {
 	return bytes.Equal(a, b)
 }
 
-// addIDs adds two IDs.
-func addIDs(a, b []byte) []byte {
-	aInt := big.Int{}
-	aInt.SetBytes(a)
+// idWords is the number of 64-bit words in an ID.
+const idWords = gmajcfg.MaxIDLength / 8
+
+// ID is a position on the Chord ring, stored as a fixed-width big-endian
+// number. Unlike the []byte IDs sent over RPCs, IDs are comparable, and the
+// ring arithmetic on them does not allocate.
+type ID [idWords]uint64
 
-	bInt := big.Int{}
-	bInt.SetBytes(b)
+// newID converts a big-endian []byte ID, like the ones in gmajpb messages, to
+// an ID. A nil or empty slice is the zero ID.
+func newID(b []byte) ID {
+	var id ID
+	for i := 0; i < len(b) && i < 8*idWords; i++ {
+		id[idWords-1-i/8] |= uint64(b[len(b)-1-i]) << (8 * uint(i%8))
+	}
+
+	return id
+}
+
+// idBytes converts id to a big-endian []byte of the configured ID length.
+func (cfg *nodeConfig) idBytes(id ID) []byte {
+	b := make([]byte, cfg.IDLength)
+	for i := 0; i < len(b); i++ {
+		b[len(b)-1-i] = byte(id[idWords-1-i/8] >> (8 * uint(i%8)))
+	}
 
-	aInt.Add(&aInt, &bInt)
-	return aInt.Bytes()
+	return b
+}
+
+// String returns id in base 10, useful for debugging/logging.
+func (id ID) String() string {
+	return id.bigInt().String()
+}
+
+// bigInt returns id as a big.Int.
+func (id ID) bigInt() *big.Int {
+	i := &big.Int{}
+	word := &big.Int{}
+	for _, w := range id {
+		i.Lsh(i, 64).Or(i, word.SetUint64(w))
+	}
+
+	return i
+}
+
+// cmp returns -1, 0 or 1 depending on whether id is less than, equal to or
+// greater than other.
+func (id ID) cmp(other ID) int {
+	for i := range id {
+		switch {
+		case id[i] < other[i]:
+			return -1
+		case id[i] > other[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// add adds two IDs, wrapping around at 2^MaxIDLength.
+func (id ID) add(other ID) ID {
+	var sum ID
+	var carry uint64
+	for i := idWords - 1; i >= 0; i-- {
+		s := id[i] + other[i]
+		c := uint64(0)
+		if s < id[i] {
+			c = 1
+		}
+		sum[i] = s + carry
+		if sum[i] < s {
+			c = 1
+		}
+		carry = c
+	}
+
+	return sum
+}
+
+// and returns the bitwise and of two IDs.
+func (id ID) and(other ID) ID {
+	for i := range id {
+		id[i] &= other[i]
+	}
+
+	return id
 }
 
 // between returns if x is between a and b.
@@ -78,23 +192,19 @@ func addIDs(a, b []byte) []byte {
 //      |     |
 //     b-\   /-x
 //        ---
-func between(x, a, b []byte) bool {
-	xInt := (&big.Int{}).SetBytes(x)
-	aInt := (&big.Int{}).SetBytes(a)
-	bInt := (&big.Int{}).SetBytes(b)
-
+func between(x, a, b ID) bool {
 	// Allow for wraparounds by checking that
 	//  1) x > a and x < b when a < b or
 	//  2) x < a or x < b when a > b or
 	//  3) x > a and x > b when a > b or
 	//  4) x < a or x > a when a == b
-	switch aInt.Cmp(bInt) {
+	switch a.cmp(b) {
 	case -1:
-		return (xInt.Cmp(aInt) > 0) && (xInt.Cmp(bInt) < 0)
+		return x.cmp(a) > 0 && x.cmp(b) < 0
 	case 1:
-		return (xInt.Cmp(aInt) > 0) || (xInt.Cmp(bInt) < 0)
+		return x.cmp(a) > 0 || x.cmp(b) < 0
 	case 0:
-		return xInt.Cmp(aInt) != 0
+		return x != a
 	}
 
 	return false
@@ -102,6 +212,12 @@ func between(x, a, b []byte) bool {
 
 // betweenRightIncl is like Between, but includes the right boundary.
 // That is, is x between (a : b]
-func betweenRightIncl(x, a, b []byte) bool {
-	return between(x, a, b) || bytes.Equal(x, b)
+func betweenRightIncl(x, a, b ID) bool {
+	return between(x, a, b) || x == b
+}
+
+// InRange returns if id is between (from : to] on the ring, wrapping around
+// past the largest ID. It is meant for Store implementations.
+func (id ID) InRange(from, to ID) bool {
+	return betweenRightIncl(id, from, to)
 }
@@ -1,8 +1,17 @@
 package gmaj
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
 	"math/big"
 	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajcfg"
+	"github.com/r-medina/gmaj/gmajpb"
 )
 
 func TestBetween(t *testing.T) {
@@ -31,9 +40,9 @@ func TestBetween(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		x := big.NewInt(test.x).Bytes()
-		a := big.NewInt(test.a).Bytes()
-		b := big.NewInt(test.b).Bytes()
+		x := newID(big.NewInt(test.x).Bytes())
+		a := newID(big.NewInt(test.a).Bytes())
+		b := newID(big.NewInt(test.b).Bytes())
 		if want, got := test.exp, between(x, a, b); got != want {
 			t.Logf("running test [%02d]", i)
 			t.Fatalf("expected %t for Between(%d, %d, %d), got %t",
@@ -69,9 +78,9 @@ func TestBetweenRightIncl(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		x := big.NewInt(test.x).Bytes()
-		a := big.NewInt(test.a).Bytes()
-		b := big.NewInt(test.b).Bytes()
+		x := newID(big.NewInt(test.x).Bytes())
+		a := newID(big.NewInt(test.a).Bytes())
+		b := newID(big.NewInt(test.b).Bytes())
 		if want, got := test.exp, betweenRightIncl(x, a, b); got != want {
 			t.Logf("running test [%02d]", i)
 			t.Fatalf("expected %t for BetweenRightIncl(%d, %d, %d), got %t",
@@ -80,3 +89,313 @@ func TestBetweenRightIncl(t *testing.T) {
 		}
 	}
 }
+
+func TestHashKeyWidths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		keySize int
+		hasher  func() hash.Hash
+	}{
+		{keySize: 1},
+		{keySize: 12},
+		{keySize: 160},
+		{keySize: 161, hasher: sha256.New},
+		{keySize: 256, hasher: sha256.New},
+		{keySize: 256, hasher: sha512.New},
+	}
+
+	for i, test := range tests {
+		cfg := config.Config
+		cfg.KeySize = test.keySize
+		cfg.IDLength = (test.keySize + 7) / 8
+		cfg.Hasher = test.hasher
+		nodeCfg, err := newNodeConfig(&cfg)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error validating configuration: %v", i, err)
+		}
+
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			id, err := nodeCfg.hashKey(key)
+			if err != nil {
+				t.Fatalf("[%02d] unexpected error hashing %q: %v", i, key, err)
+			}
+			if want, got := cfg.IDLength, len(id); got != want {
+				t.Fatalf("[%02d] expected ID of length %d, got %d", i, want, got)
+			}
+			if newID(id).and(nodeCfg.mask) != newID(id) {
+				t.Fatalf("[%02d] ID %v does not fit in %d bits", i, IDToString(id), test.keySize)
+			}
+		}
+	}
+}
+
+func TestValidateIDLength(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		keySize, idLength int
+		hasher            func() hash.Hash
+		err               error
+	}{
+		{keySize: 0, idLength: 0, err: gmajcfg.ErrBadKeyLen},
+		{keySize: 12, idLength: 1, err: gmajcfg.ErrBadIDLen},
+		{keySize: 12, idLength: 2},
+		{keySize: 168, idLength: 21, err: gmajcfg.ErrIDTooLong},
+		{keySize: 168, idLength: 21, hasher: sha256.New},
+		{keySize: 264, idLength: 33, hasher: sha256.New, err: gmajcfg.ErrIDTooLong},
+		// IDs are at most 256 bits, even if the digest is longer.
+		{keySize: 256, idLength: 32, hasher: sha512.New},
+		{keySize: 512, idLength: 64, hasher: sha512.New, err: gmajcfg.ErrIDTooLong},
+	}
+
+	for i, test := range tests {
+		cfg := config.Config
+		cfg.KeySize = test.keySize
+		cfg.IDLength = test.idLength
+		cfg.Hasher = test.hasher
+		if want, got := test.err, cfg.Validate(); got != want {
+			t.Fatalf("[%02d] expected error %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.FixNextFingerInterval = 0
+	cfg.StabilizeInterval = 0
+	cfg.CheckPredInterval = 0
+	cfg.AntiEntropyInterval = 0
+	cfg.SuccessorListSize = 0
+
+	nodeCfg, err := newNodeConfig(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := gmajcfg.DefaultConfig.SuccessorListSize, nodeCfg.SuccessorListSize; got != want {
+		t.Fatalf("expected successor list size %d, got %d", want, got)
+	}
+	for i, got := range []time.Duration{
+		nodeCfg.FixNextFingerInterval, nodeCfg.StabilizeInterval,
+		nodeCfg.CheckPredInterval, nodeCfg.AntiEntropyInterval,
+	} {
+		if got <= 0 {
+			t.Fatalf("[%02d] expected the default interval, got %v", i, got)
+		}
+	}
+
+	// The node's background tasks run at the default intervals, and it keeps
+	// the default number of successors.
+	node := createDefinedNode(t, nil, nil, WithConfig(&cfg))
+	node.Shutdown()
+
+	cfg.CheckPredInterval = -time.Second
+	if want, got := gmajcfg.ErrBadInterval, cfg.Validate(); got != want {
+		t.Fatalf("expected error %v, got %v", want, got)
+	}
+}
+
+func TestWithIDOutsideKeySize(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.KeySize = 12
+	cfg.IDLength = 2
+
+	_, err := NewNode(nil,
+		WithConfig(&cfg), WithID([]byte{0x10, 0}), WithTransport(testNetwork.Transport()),
+	)
+	if err != ErrBadID {
+		t.Fatalf("expected %v, got %v", ErrBadID, err)
+	}
+}
+
+func TestNewIDWithConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.KeySize = 12
+	cfg.IDLength = 2
+
+	tests := []struct {
+		str string
+		id  []byte
+	}{
+		{str: "16", id: []byte{0, 0x10}},
+		{str: "0x10", id: []byte{0, 0x10}},
+		{str: "0xffff", id: []byte{0x0f, 0xff}},
+	}
+
+	for i, test := range tests {
+		id, err := NewIDWithConfig(test.str, &cfg)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(id, test.id) {
+			t.Fatalf("[%02d] expected %v, got %v", i, test.id, id)
+		}
+	}
+
+	// NewID sizes IDs for the default configuration.
+	if id, err := NewID("0x10"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if want, got := config.IDLength, len(id); got != want {
+		t.Fatalf("expected ID of length %d, got %d", want, got)
+	}
+
+	if _, err := NewIDWithConfig("not a number", &cfg); err == nil {
+		t.Fatal("expected an error parsing an invalid ID")
+	}
+	cfg.IDLength = 1
+	if _, err := NewIDWithConfig("16", &cfg); err != gmajcfg.ErrBadIDLen {
+		t.Fatalf("expected error %v, got %v", gmajcfg.ErrBadIDLen, err)
+	}
+}
+
+func TestIDArithmetic(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.KeySize = 256
+	cfg.IDLength = 32
+	cfg.Hasher = sha256.New
+	nodeCfg, err := newNodeConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), 256)
+	tests := []struct{ a, b *big.Int }{
+		{big.NewInt(0), big.NewInt(0)},
+		{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 64)},
+		{new(big.Int).SetUint64(^uint64(0)), big.NewInt(1)},
+		{new(big.Int).Sub(max, big.NewInt(1)), big.NewInt(1)},
+		{new(big.Int).Sub(max, big.NewInt(3)), new(big.Int).Lsh(big.NewInt(1), 200)},
+	}
+
+	for i, test := range tests {
+		a, b := newID(test.a.Bytes()), newID(test.b.Bytes())
+		if want, got := test.a.String(), a.String(); got != want {
+			t.Fatalf("[%02d] expected %v, got %v", i, want, got)
+		}
+
+		sum := new(big.Int).Add(test.a, test.b)
+		sum.Mod(sum, max)
+		if want, got := sum.String(), a.add(b).String(); got != want {
+			t.Fatalf("[%02d] expected %v + %v = %v, got %v", i, test.a, test.b, want, got)
+		}
+
+		if want, got := test.a.Cmp(test.b), a.cmp(b); got != want {
+			t.Fatalf("[%02d] expected cmp(%v, %v) = %d, got %d", i, test.a, test.b, want, got)
+		}
+
+		if got := newID(nodeCfg.idBytes(a)); got != a {
+			t.Fatalf("[%02d] %v did not survive conversion to bytes, got %v", i, a, got)
+		}
+	}
+}
+
+// betweenBigInt is between on []byte IDs, the way it was done before IDs had a
+// type of their own. It is kept around for the benchmarks.
+func betweenBigInt(x, a, b []byte) bool {
+	xInt := (&big.Int{}).SetBytes(x)
+	aInt := (&big.Int{}).SetBytes(a)
+	bInt := (&big.Int{}).SetBytes(b)
+
+	switch aInt.Cmp(bInt) {
+	case -1:
+		return (xInt.Cmp(aInt) > 0) && (xInt.Cmp(bInt) < 0)
+	case 1:
+		return (xInt.Cmp(aInt) > 0) || (xInt.Cmp(bInt) < 0)
+	case 0:
+		return xInt.Cmp(aInt) != 0
+	}
+
+	return false
+}
+
+// fingerMathBigInt is fingerMath on []byte IDs, the way it was done before IDs
+// had a type of their own. It is kept around for the benchmarks.
+func fingerMathBigInt(cfg *nodeConfig, n []byte, i int) []byte {
+	iInt := new(big.Int).Lsh(big.NewInt(1), uint(i))
+	mInt := new(big.Int).Lsh(big.NewInt(1), uint(cfg.KeySize))
+
+	res := &big.Int{}
+	res.SetBytes(n).Add(res, iInt).Mod(res, mInt)
+
+	return cfg.padID(res.Bytes())
+}
+
+func benchmarkIDs(b *testing.B) (x, lo, hi []byte) {
+	var err error
+	if x, err = config.hashKey("x"); err != nil {
+		b.Fatal(err)
+	}
+	if lo, err = config.hashKey("lo"); err != nil {
+		b.Fatal(err)
+	}
+	if hi, err = config.hashKey("hi"); err != nil {
+		b.Fatal(err)
+	}
+
+	return x, lo, hi
+}
+
+func BenchmarkBetween(b *testing.B) {
+	x, lo, hi := benchmarkIDs(b)
+
+	b.Run("bigInt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			betweenBigInt(x, lo, hi)
+		}
+	})
+
+	b.Run("ID", func(b *testing.B) {
+		x, lo, hi := newID(x), newID(lo), newID(hi)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			between(x, lo, hi)
+		}
+	})
+}
+
+func BenchmarkFingerMath(b *testing.B) {
+	n, _, _ := benchmarkIDs(b)
+	cfg := &config.nodeConfig
+
+	b.Run("bigInt", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			fingerMathBigInt(cfg, n, i%cfg.KeySize)
+		}
+	})
+
+	b.Run("ID", func(b *testing.B) {
+		n := newID(n)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			cfg.fingerMath(n, i%cfg.KeySize)
+		}
+	})
+}
+
+func BenchmarkClosestPrecedingFinger(b *testing.B) {
+	node := &Node{Node: &gmajpb.Node{Addr: "bench"}, config: &config.nodeConfig}
+	node.Id, _, _ = benchmarkIDs(b)
+	node.id = newID(node.Id)
+	node.fingerTable = newFingerTable(node.config, node.Node)
+	for i, finger := range node.fingerTable {
+		// Point every finger at the node right at its start, so the whole
+		// table is scanned for IDs just past the node.
+		finger.RemoteNode = &gmajpb.Node{Id: node.config.idBytes(finger.StartID), Addr: fmt.Sprint(i)}
+	}
+	id := node.config.fingerMath(node.id, 0)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		node.closestPrecedingFinger(id)
+	}
+}
@@ -48,6 +48,11 @@ type ChordClient interface {
 	GetPredecessor(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.Node, error)
 	// GetSuccessor returns the node believed to be the current successor.
 	GetSuccessor(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.Node, error)
+	// GetSuccessorList returns the list of nodes believed to be the nearest
+	// successors, starting with the successor.
+	GetSuccessorList(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.Nodes, error)
+	// Ping checks that the node is alive.
+	Ping(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.MT, error)
 	// SetPredecessor sets Node as the predeccessor. This function does not do
 	// any validation.
 	SetPredecessor(ctx context.Context, in *gmajpb.Node, opts ...grpc.CallOption) (*gmajpb.MT, error)
@@ -59,17 +64,73 @@ type ChordClient interface {
 	Notify(ctx context.Context, in *gmajpb.Node, opts ...grpc.CallOption) (*gmajpb.MT, error)
 	// ClosestPrecedingFinger returns the entry of the finger table that
 	// precedes ID but is closest to it.
-	ClosestPrecedingFinger(ctx context.Context, in *gmajpb.ID, opts ...grpc.CallOption) (*gmajpb.Node, error)
+	ClosestPrecedingFinger(ctx context.Context, in *gmajpb.ID, opts ...grpc.CallOption) (*gmajpb.Finger, error)
 	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
 	// other nodes.
 	FindSuccessor(ctx context.Context, in *gmajpb.ID, opts ...grpc.CallOption) (*gmajpb.Node, error)
+	// FindSuccessorRecursive finds the node that succedes ID by passing the
+	// lookup on to the closest preceding finger, which does the same, until it
+	// gets to the predecessor of ID.
+	FindSuccessorRecursive(ctx context.Context, in *gmajpb.LookupRequest, opts ...grpc.CallOption) (*gmajpb.LookupResponse, error)
 	// GetKey returns the value in node for the given key;
 	GetKey(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.Val, error)
-	// PutKeyVal writes a key value pair to the node.
-	PutKeyVal(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// PutKeyVal writes a key value pair to the node, and returns the version
+	// it has.
+	PutKeyVal(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.Version, error)
+	// PutKeyValStream is PutKeyVal for large values, which are split over
+	// the vals of the messages. The other fields are taken from the first
+	// message.
+	PutKeyValStream(ctx context.Context, opts ...grpc.CallOption) (Chord_PutKeyValStreamClient, error)
+	// GetKeyStream is GetKey for large values, which are split over the vals
+	// of the messages. The version is in the first message.
+	GetKeyStream(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (Chord_GetKeyStreamClient, error)
+	// DeleteKey deletes a key from the node, leaving a tombstone.
+	DeleteKey(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// GetReplica returns the value in node for the given key, looking in the
+	// copies of keys it keeps for its predecessors as well.
+	GetReplica(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.Val, error)
+	// GetReplicaStream is GetReplica for large values, split up like in
+	// GetKeyStream.
+	GetReplicaStream(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (Chord_GetReplicaStreamClient, error)
+	// PutReplica writes a copy of a key value pair owned by a predecessor to
+	// the node.
+	PutReplica(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// PutReplicaStream is PutReplica for large values, split up like in
+	// PutKeyValStream.
+	PutReplicaStream(ctx context.Context, opts ...grpc.CallOption) (Chord_PutReplicaStreamClient, error)
+	// GetMerkleTree returns the Merkle tree of the keys the node has in a range,
+	// including the copies it keeps for its predecessors.
+	GetMerkleTree(ctx context.Context, in *gmajpb.KeyRange, opts ...grpc.CallOption) (*gmajpb.MerkleTree, error)
+	// GetBuckets returns the key value pairs the node has in the given buckets
+	// of the Merkle tree for a range.
+	GetBuckets(ctx context.Context, in *gmajpb.BucketsReq, opts ...grpc.CallOption) (*gmajpb.KeyVals, error)
+	// GetBucketsStream is GetBuckets for large values. Each key value pair is
+	// split up like in PutKeyValStream, and its last message has more unset.
+	GetBucketsStream(ctx context.Context, in *gmajpb.BucketsReq, opts ...grpc.CallOption) (Chord_GetBucketsStreamClient, error)
 	// TransferKeys tells a node to transfer keys in a specified range to
 	// another node.
 	TransferKeys(ctx context.Context, in *gmajpb.TransferKeysReq, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// ListKeys returns the keys the node owns in part of a listing.
+	ListKeys(ctx context.Context, in *gmajpb.ListKeysReq, opts ...grpc.CallOption) (*gmajpb.KeyVals, error)
+	// GetKeys returns the values of keys the node owns. Keys it does not own
+	// get errors, like the other keys it cannot get, as do keys whose values
+	// would make the response larger than a chunk.
+	GetKeys(ctx context.Context, in *gmajpb.MultiGetRequest, opts ...grpc.CallOption) (*gmajpb.MultiGetResponse, error)
+	// PutKeyVals writes key value pairs to the node, each like PutKeyVal.
+	PutKeyVals(ctx context.Context, in *gmajpb.KeyVals, opts ...grpc.CallOption) (*gmajpb.MultiPutResponse, error)
+	// WatchKeys sends the changes to keys the node owns as they happen. It
+	// fails once the node stops owning the keys.
+	WatchKeys(ctx context.Context, in *gmajpb.WatchKeysReq, opts ...grpc.CallOption) (Chord_WatchKeysClient, error)
+	// Prepare prepares the node's part of a transaction, locking its keys.
+	Prepare(ctx context.Context, in *gmajpb.PrepareReq, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// Commit does the writes of a prepared transaction and unlocks its keys.
+	// It returns the versions of the keys.
+	Commit(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.Versions, error)
+	// Abort forgets a prepared transaction and unlocks its keys.
+	Abort(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.MT, error)
+	// GetTxnStatus returns the outcome of a transaction the node
+	// coordinates.
+	GetTxnStatus(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.TxnStatus, error)
 }
 
 type chordClient struct {
@@ -98,6 +159,24 @@ func (c *chordClient) GetSuccessor(ctx context.Context, in *gmajpb.MT, opts ...g
 	return out, nil
 }
 
+func (c *chordClient) GetSuccessorList(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.Nodes, error) {
+	out := new(gmajpb.Nodes)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetSuccessorList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Ping(ctx context.Context, in *gmajpb.MT, opts ...grpc.CallOption) (*gmajpb.MT, error) {
+	out := new(gmajpb.MT)
+	err := grpc.Invoke(ctx, "/chord.Chord/Ping", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chordClient) SetPredecessor(ctx context.Context, in *gmajpb.Node, opts ...grpc.CallOption) (*gmajpb.MT, error) {
 	out := new(gmajpb.MT)
 	err := grpc.Invoke(ctx, "/chord.Chord/SetPredecessor", in, out, c.cc, opts...)
@@ -125,8 +204,8 @@ func (c *chordClient) Notify(ctx context.Context, in *gmajpb.Node, opts ...grpc.
 	return out, nil
 }
 
-func (c *chordClient) ClosestPrecedingFinger(ctx context.Context, in *gmajpb.ID, opts ...grpc.CallOption) (*gmajpb.Node, error) {
-	out := new(gmajpb.Node)
+func (c *chordClient) ClosestPrecedingFinger(ctx context.Context, in *gmajpb.ID, opts ...grpc.CallOption) (*gmajpb.Finger, error) {
+	out := new(gmajpb.Finger)
 	err := grpc.Invoke(ctx, "/chord.Chord/ClosestPrecedingFinger", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
@@ -143,6 +222,15 @@ func (c *chordClient) FindSuccessor(ctx context.Context, in *gmajpb.ID, opts ...
 	return out, nil
 }
 
+func (c *chordClient) FindSuccessorRecursive(ctx context.Context, in *gmajpb.LookupRequest, opts ...grpc.CallOption) (*gmajpb.LookupResponse, error) {
+	out := new(gmajpb.LookupResponse)
+	err := grpc.Invoke(ctx, "/chord.Chord/FindSuccessorRecursive", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *chordClient) GetKey(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.Val, error) {
 	out := new(gmajpb.Val)
 	err := grpc.Invoke(ctx, "/chord.Chord/GetKey", in, out, c.cc, opts...)
@@ -152,8 +240,8 @@ func (c *chordClient) GetKey(ctx context.Context, in *gmajpb.Key, opts ...grpc.C
 	return out, nil
 }
 
-func (c *chordClient) PutKeyVal(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.MT, error) {
-	out := new(gmajpb.MT)
+func (c *chordClient) PutKeyVal(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.Version, error) {
+	out := new(gmajpb.Version)
 	err := grpc.Invoke(ctx, "/chord.Chord/PutKeyVal", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
@@ -161,6 +249,215 @@ func (c *chordClient) PutKeyVal(ctx context.Context, in *gmajpb.KeyVal, opts ...
 	return out, nil
 }
 
+func (c *chordClient) PutKeyValStream(ctx context.Context, opts ...grpc.CallOption) (Chord_PutKeyValStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[0], c.cc, "/chord.Chord/PutKeyValStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordPutKeyValStreamClient{stream}
+	return x, nil
+}
+
+type Chord_PutKeyValStreamClient interface {
+	Send(*gmajpb.KeyVal) error
+	CloseAndRecv() (*gmajpb.Version, error)
+	grpc.ClientStream
+}
+
+type chordPutKeyValStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordPutKeyValStreamClient) Send(m *gmajpb.KeyVal) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chordPutKeyValStreamClient) CloseAndRecv() (*gmajpb.Version, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(gmajpb.Version)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) GetKeyStream(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (Chord_GetKeyStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[1], c.cc, "/chord.Chord/GetKeyStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordGetKeyStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_GetKeyStreamClient interface {
+	Recv() (*gmajpb.Val, error)
+	grpc.ClientStream
+}
+
+type chordGetKeyStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordGetKeyStreamClient) Recv() (*gmajpb.Val, error) {
+	m := new(gmajpb.Val)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) DeleteKey(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.MT, error) {
+	out := new(gmajpb.MT)
+	err := grpc.Invoke(ctx, "/chord.Chord/DeleteKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetReplica(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (*gmajpb.Val, error) {
+	out := new(gmajpb.Val)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetReplica", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetReplicaStream(ctx context.Context, in *gmajpb.Key, opts ...grpc.CallOption) (Chord_GetReplicaStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[2], c.cc, "/chord.Chord/GetReplicaStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordGetReplicaStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_GetReplicaStreamClient interface {
+	Recv() (*gmajpb.Val, error)
+	grpc.ClientStream
+}
+
+type chordGetReplicaStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordGetReplicaStreamClient) Recv() (*gmajpb.Val, error) {
+	m := new(gmajpb.Val)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) PutReplica(ctx context.Context, in *gmajpb.KeyVal, opts ...grpc.CallOption) (*gmajpb.MT, error) {
+	out := new(gmajpb.MT)
+	err := grpc.Invoke(ctx, "/chord.Chord/PutReplica", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) PutReplicaStream(ctx context.Context, opts ...grpc.CallOption) (Chord_PutReplicaStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[3], c.cc, "/chord.Chord/PutReplicaStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordPutReplicaStreamClient{stream}
+	return x, nil
+}
+
+type Chord_PutReplicaStreamClient interface {
+	Send(*gmajpb.KeyVal) error
+	CloseAndRecv() (*gmajpb.MT, error)
+	grpc.ClientStream
+}
+
+type chordPutReplicaStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordPutReplicaStreamClient) Send(m *gmajpb.KeyVal) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *chordPutReplicaStreamClient) CloseAndRecv() (*gmajpb.MT, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(gmajpb.MT)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) GetMerkleTree(ctx context.Context, in *gmajpb.KeyRange, opts ...grpc.CallOption) (*gmajpb.MerkleTree, error) {
+	out := new(gmajpb.MerkleTree)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetMerkleTree", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetBuckets(ctx context.Context, in *gmajpb.BucketsReq, opts ...grpc.CallOption) (*gmajpb.KeyVals, error) {
+	out := new(gmajpb.KeyVals)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetBuckets", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetBucketsStream(ctx context.Context, in *gmajpb.BucketsReq, opts ...grpc.CallOption) (Chord_GetBucketsStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[4], c.cc, "/chord.Chord/GetBucketsStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordGetBucketsStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_GetBucketsStreamClient interface {
+	Recv() (*gmajpb.KeyVal, error)
+	grpc.ClientStream
+}
+
+type chordGetBucketsStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordGetBucketsStreamClient) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *chordClient) TransferKeys(ctx context.Context, in *gmajpb.TransferKeysReq, opts ...grpc.CallOption) (*gmajpb.MT, error) {
 	out := new(gmajpb.MT)
 	err := grpc.Invoke(ctx, "/chord.Chord/TransferKeys", in, out, c.cc, opts...)
@@ -170,6 +467,101 @@ func (c *chordClient) TransferKeys(ctx context.Context, in *gmajpb.TransferKeysR
 	return out, nil
 }
 
+func (c *chordClient) ListKeys(ctx context.Context, in *gmajpb.ListKeysReq, opts ...grpc.CallOption) (*gmajpb.KeyVals, error) {
+	out := new(gmajpb.KeyVals)
+	err := grpc.Invoke(ctx, "/chord.Chord/ListKeys", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetKeys(ctx context.Context, in *gmajpb.MultiGetRequest, opts ...grpc.CallOption) (*gmajpb.MultiGetResponse, error) {
+	out := new(gmajpb.MultiGetResponse)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetKeys", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) PutKeyVals(ctx context.Context, in *gmajpb.KeyVals, opts ...grpc.CallOption) (*gmajpb.MultiPutResponse, error) {
+	out := new(gmajpb.MultiPutResponse)
+	err := grpc.Invoke(ctx, "/chord.Chord/PutKeyVals", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) WatchKeys(ctx context.Context, in *gmajpb.WatchKeysReq, opts ...grpc.CallOption) (Chord_WatchKeysClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Chord_serviceDesc.Streams[5], c.cc, "/chord.Chord/WatchKeys", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chordWatchKeysClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chord_WatchKeysClient interface {
+	Recv() (*gmajpb.WatchEvent, error)
+	grpc.ClientStream
+}
+
+type chordWatchKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *chordWatchKeysClient) Recv() (*gmajpb.WatchEvent, error) {
+	m := new(gmajpb.WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chordClient) Prepare(ctx context.Context, in *gmajpb.PrepareReq, opts ...grpc.CallOption) (*gmajpb.MT, error) {
+	out := new(gmajpb.MT)
+	err := grpc.Invoke(ctx, "/chord.Chord/Prepare", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Commit(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.Versions, error) {
+	out := new(gmajpb.Versions)
+	err := grpc.Invoke(ctx, "/chord.Chord/Commit", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) Abort(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.MT, error) {
+	out := new(gmajpb.MT)
+	err := grpc.Invoke(ctx, "/chord.Chord/Abort", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chordClient) GetTxnStatus(ctx context.Context, in *gmajpb.TxnID, opts ...grpc.CallOption) (*gmajpb.TxnStatus, error) {
+	out := new(gmajpb.TxnStatus)
+	err := grpc.Invoke(ctx, "/chord.Chord/GetTxnStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Chord service
 
 type ChordServer interface {
@@ -177,6 +569,11 @@ type ChordServer interface {
 	GetPredecessor(context.Context, *gmajpb.MT) (*gmajpb.Node, error)
 	// GetSuccessor returns the node believed to be the current successor.
 	GetSuccessor(context.Context, *gmajpb.MT) (*gmajpb.Node, error)
+	// GetSuccessorList returns the list of nodes believed to be the nearest
+	// successors, starting with the successor.
+	GetSuccessorList(context.Context, *gmajpb.MT) (*gmajpb.Nodes, error)
+	// Ping checks that the node is alive.
+	Ping(context.Context, *gmajpb.MT) (*gmajpb.MT, error)
 	// SetPredecessor sets Node as the predeccessor. This function does not do
 	// any validation.
 	SetPredecessor(context.Context, *gmajpb.Node) (*gmajpb.MT, error)
@@ -188,17 +585,73 @@ type ChordServer interface {
 	Notify(context.Context, *gmajpb.Node) (*gmajpb.MT, error)
 	// ClosestPrecedingFinger returns the entry of the finger table that
 	// precedes ID but is closest to it.
-	ClosestPrecedingFinger(context.Context, *gmajpb.ID) (*gmajpb.Node, error)
+	ClosestPrecedingFinger(context.Context, *gmajpb.ID) (*gmajpb.Finger, error)
 	// FindSuccessor finds the node the succedes ID. May initiate RPC calls to
 	// other nodes.
 	FindSuccessor(context.Context, *gmajpb.ID) (*gmajpb.Node, error)
+	// FindSuccessorRecursive finds the node that succedes ID by passing the
+	// lookup on to the closest preceding finger, which does the same, until it
+	// gets to the predecessor of ID.
+	FindSuccessorRecursive(context.Context, *gmajpb.LookupRequest) (*gmajpb.LookupResponse, error)
 	// GetKey returns the value in node for the given key;
 	GetKey(context.Context, *gmajpb.Key) (*gmajpb.Val, error)
-	// PutKeyVal writes a key value pair to the node.
-	PutKeyVal(context.Context, *gmajpb.KeyVal) (*gmajpb.MT, error)
+	// PutKeyVal writes a key value pair to the node, and returns the version
+	// it has.
+	PutKeyVal(context.Context, *gmajpb.KeyVal) (*gmajpb.Version, error)
+	// PutKeyValStream is PutKeyVal for large values, which are split over
+	// the vals of the messages. The other fields are taken from the first
+	// message.
+	PutKeyValStream(Chord_PutKeyValStreamServer) error
+	// GetKeyStream is GetKey for large values, which are split over the vals
+	// of the messages. The version is in the first message.
+	GetKeyStream(*gmajpb.Key, Chord_GetKeyStreamServer) error
+	// DeleteKey deletes a key from the node, leaving a tombstone.
+	DeleteKey(context.Context, *gmajpb.Key) (*gmajpb.MT, error)
+	// GetReplica returns the value in node for the given key, looking in the
+	// copies of keys it keeps for its predecessors as well.
+	GetReplica(context.Context, *gmajpb.Key) (*gmajpb.Val, error)
+	// GetReplicaStream is GetReplica for large values, split up like in
+	// GetKeyStream.
+	GetReplicaStream(*gmajpb.Key, Chord_GetReplicaStreamServer) error
+	// PutReplica writes a copy of a key value pair owned by a predecessor to
+	// the node.
+	PutReplica(context.Context, *gmajpb.KeyVal) (*gmajpb.MT, error)
+	// PutReplicaStream is PutReplica for large values, split up like in
+	// PutKeyValStream.
+	PutReplicaStream(Chord_PutReplicaStreamServer) error
+	// GetMerkleTree returns the Merkle tree of the keys the node has in a range,
+	// including the copies it keeps for its predecessors.
+	GetMerkleTree(context.Context, *gmajpb.KeyRange) (*gmajpb.MerkleTree, error)
+	// GetBuckets returns the key value pairs the node has in the given buckets
+	// of the Merkle tree for a range.
+	GetBuckets(context.Context, *gmajpb.BucketsReq) (*gmajpb.KeyVals, error)
+	// GetBucketsStream is GetBuckets for large values. Each key value pair is
+	// split up like in PutKeyValStream, and its last message has more unset.
+	GetBucketsStream(*gmajpb.BucketsReq, Chord_GetBucketsStreamServer) error
 	// TransferKeys tells a node to transfer keys in a specified range to
 	// another node.
 	TransferKeys(context.Context, *gmajpb.TransferKeysReq) (*gmajpb.MT, error)
+	// ListKeys returns the keys the node owns in part of a listing.
+	ListKeys(context.Context, *gmajpb.ListKeysReq) (*gmajpb.KeyVals, error)
+	// GetKeys returns the values of keys the node owns. Keys it does not own
+	// get errors, like the other keys it cannot get, as do keys whose values
+	// would make the response larger than a chunk.
+	GetKeys(context.Context, *gmajpb.MultiGetRequest) (*gmajpb.MultiGetResponse, error)
+	// PutKeyVals writes key value pairs to the node, each like PutKeyVal.
+	PutKeyVals(context.Context, *gmajpb.KeyVals) (*gmajpb.MultiPutResponse, error)
+	// WatchKeys sends the changes to keys the node owns as they happen. It
+	// fails once the node stops owning the keys.
+	WatchKeys(*gmajpb.WatchKeysReq, Chord_WatchKeysServer) error
+	// Prepare prepares the node's part of a transaction, locking its keys.
+	Prepare(context.Context, *gmajpb.PrepareReq) (*gmajpb.MT, error)
+	// Commit does the writes of a prepared transaction and unlocks its keys.
+	// It returns the versions of the keys.
+	Commit(context.Context, *gmajpb.TxnID) (*gmajpb.Versions, error)
+	// Abort forgets a prepared transaction and unlocks its keys.
+	Abort(context.Context, *gmajpb.TxnID) (*gmajpb.MT, error)
+	// GetTxnStatus returns the outcome of a transaction the node
+	// coordinates.
+	GetTxnStatus(context.Context, *gmajpb.TxnID) (*gmajpb.TxnStatus, error)
 }
 
 func RegisterChordServer(s *grpc.Server, srv ChordServer) {
@@ -241,6 +694,42 @@ func _Chord_GetSuccessor_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_GetSuccessorList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.MT)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetSuccessorList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetSuccessorList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetSuccessorList(ctx, req.(*gmajpb.MT))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.MT)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/Ping",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Ping(ctx, req.(*gmajpb.MT))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Chord_SetPredecessor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(gmajpb.Node)
 	if err := dec(in); err != nil {
@@ -331,6 +820,24 @@ func _Chord_FindSuccessor_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_FindSuccessorRecursive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).FindSuccessorRecursive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/FindSuccessorRecursive",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).FindSuccessorRecursive(ctx, req.(*gmajpb.LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Chord_GetKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(gmajpb.Key)
 	if err := dec(in); err != nil {
@@ -367,6 +874,211 @@ func _Chord_PutKeyVal_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_PutKeyValStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChordServer).PutKeyValStream(&chordPutKeyValStreamServer{stream})
+}
+
+type Chord_PutKeyValStreamServer interface {
+	SendAndClose(*gmajpb.Version) error
+	Recv() (*gmajpb.KeyVal, error)
+	grpc.ServerStream
+}
+
+type chordPutKeyValStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordPutKeyValStreamServer) SendAndClose(m *gmajpb.Version) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chordPutKeyValStreamServer) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chord_GetKeyStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(gmajpb.Key)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).GetKeyStream(m, &chordGetKeyStreamServer{stream})
+}
+
+type Chord_GetKeyStreamServer interface {
+	Send(*gmajpb.Val) error
+	grpc.ServerStream
+}
+
+type chordGetKeyStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordGetKeyStreamServer) Send(m *gmajpb.Val) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_DeleteKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).DeleteKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/DeleteKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).DeleteKey(ctx, req.(*gmajpb.Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetReplica",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetReplica(ctx, req.(*gmajpb.Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetReplicaStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(gmajpb.Key)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).GetReplicaStream(m, &chordGetReplicaStreamServer{stream})
+}
+
+type Chord_GetReplicaStreamServer interface {
+	Send(*gmajpb.Val) error
+	grpc.ServerStream
+}
+
+type chordGetReplicaStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordGetReplicaStreamServer) Send(m *gmajpb.Val) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_PutReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.KeyVal)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).PutReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/PutReplica",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).PutReplica(ctx, req.(*gmajpb.KeyVal))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_PutReplicaStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ChordServer).PutReplicaStream(&chordPutReplicaStreamServer{stream})
+}
+
+type Chord_PutReplicaStreamServer interface {
+	SendAndClose(*gmajpb.MT) error
+	Recv() (*gmajpb.KeyVal, error)
+	grpc.ServerStream
+}
+
+type chordPutReplicaStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordPutReplicaStreamServer) SendAndClose(m *gmajpb.MT) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *chordPutReplicaStreamServer) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Chord_GetMerkleTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.KeyRange)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetMerkleTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetMerkleTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetMerkleTree(ctx, req.(*gmajpb.KeyRange))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetBuckets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.BucketsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetBuckets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetBuckets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetBuckets(ctx, req.(*gmajpb.BucketsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetBucketsStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(gmajpb.BucketsReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).GetBucketsStream(m, &chordGetBucketsStreamServer{stream})
+}
+
+type Chord_GetBucketsStreamServer interface {
+	Send(*gmajpb.KeyVal) error
+	grpc.ServerStream
+}
+
+type chordGetBucketsStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordGetBucketsStreamServer) Send(m *gmajpb.KeyVal) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Chord_TransferKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(gmajpb.TransferKeysReq)
 	if err := dec(in); err != nil {
@@ -385,6 +1097,153 @@ func _Chord_TransferKeys_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Chord_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.ListKeysReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).ListKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/ListKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).ListKeys(ctx, req.(*gmajpb.ListKeysReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.MultiGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetKeys(ctx, req.(*gmajpb.MultiGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_PutKeyVals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.KeyVals)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).PutKeyVals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/PutKeyVals",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).PutKeyVals(ctx, req.(*gmajpb.KeyVals))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_WatchKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(gmajpb.WatchKeysReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChordServer).WatchKeys(m, &chordWatchKeysServer{stream})
+}
+
+type Chord_WatchKeysServer interface {
+	Send(*gmajpb.WatchEvent) error
+	grpc.ServerStream
+}
+
+type chordWatchKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *chordWatchKeysServer) Send(m *gmajpb.WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chord_Prepare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.PrepareReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Prepare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/Prepare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Prepare(ctx, req.(*gmajpb.PrepareReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.TxnID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/Commit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Commit(ctx, req.(*gmajpb.TxnID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_Abort_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.TxnID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).Abort(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/Abort",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).Abort(ctx, req.(*gmajpb.TxnID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chord_GetTxnStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(gmajpb.TxnID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChordServer).GetTxnStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chord.Chord/GetTxnStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChordServer).GetTxnStatus(ctx, req.(*gmajpb.TxnID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Chord_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "chord.Chord",
 	HandlerType: (*ChordServer)(nil),
@@ -397,6 +1256,14 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetSuccessor",
 			Handler:    _Chord_GetSuccessor_Handler,
 		},
+		{
+			MethodName: "GetSuccessorList",
+			Handler:    _Chord_GetSuccessorList_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Chord_Ping_Handler,
+		},
 		{
 			MethodName: "SetPredecessor",
 			Handler:    _Chord_SetPredecessor_Handler,
@@ -417,6 +1284,10 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FindSuccessor",
 			Handler:    _Chord_FindSuccessor_Handler,
 		},
+		{
+			MethodName: "FindSuccessorRecursive",
+			Handler:    _Chord_FindSuccessorRecursive_Handler,
+		},
 		{
 			MethodName: "GetKey",
 			Handler:    _Chord_GetKey_Handler,
@@ -425,12 +1296,91 @@ var _Chord_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PutKeyVal",
 			Handler:    _Chord_PutKeyVal_Handler,
 		},
+		{
+			MethodName: "DeleteKey",
+			Handler:    _Chord_DeleteKey_Handler,
+		},
+		{
+			MethodName: "GetReplica",
+			Handler:    _Chord_GetReplica_Handler,
+		},
+		{
+			MethodName: "PutReplica",
+			Handler:    _Chord_PutReplica_Handler,
+		},
+		{
+			MethodName: "GetMerkleTree",
+			Handler:    _Chord_GetMerkleTree_Handler,
+		},
+		{
+			MethodName: "GetBuckets",
+			Handler:    _Chord_GetBuckets_Handler,
+		},
 		{
 			MethodName: "TransferKeys",
 			Handler:    _Chord_TransferKeys_Handler,
 		},
+		{
+			MethodName: "ListKeys",
+			Handler:    _Chord_ListKeys_Handler,
+		},
+		{
+			MethodName: "GetKeys",
+			Handler:    _Chord_GetKeys_Handler,
+		},
+		{
+			MethodName: "PutKeyVals",
+			Handler:    _Chord_PutKeyVals_Handler,
+		},
+		{
+			MethodName: "Prepare",
+			Handler:    _Chord_Prepare_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _Chord_Commit_Handler,
+		},
+		{
+			MethodName: "Abort",
+			Handler:    _Chord_Abort_Handler,
+		},
+		{
+			MethodName: "GetTxnStatus",
+			Handler:    _Chord_GetTxnStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PutKeyValStream",
+			Handler:       _Chord_PutKeyValStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetKeyStream",
+			Handler:       _Chord_GetKeyStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetReplicaStream",
+			Handler:       _Chord_GetReplicaStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PutReplicaStream",
+			Handler:       _Chord_PutReplicaStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetBucketsStream",
+			Handler:       _Chord_GetBucketsStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchKeys",
+			Handler:       _Chord_WatchKeys_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "github.com/r-medina/gmaj/internal/chord/chord.proto",
 }
 
@@ -439,23 +1389,43 @@ func init() {
 }
 
 var fileDescriptor0 = []byte{
-	// 275 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x84, 0x91, 0x41, 0x4f, 0x83, 0x30,
-	0x14, 0xc7, 0x0f, 0x66, 0x4b, 0x7c, 0x22, 0x87, 0x1e, 0x34, 0xe1, 0xb8, 0x8b, 0x4a, 0x22, 0x24,
-	0xe3, 0x23, 0xcc, 0x8c, 0x18, 0xe2, 0xb2, 0xc8, 0xb2, 0x7b, 0xa1, 0x6f, 0xac, 0x06, 0xda, 0xd9,
-	0x96, 0x03, 0xdf, 0xcc, 0x8f, 0x67, 0x0a, 0xc1, 0x14, 0x75, 0x7a, 0x79, 0xed, 0x7b, 0xef, 0xf7,
-	0x7f, 0xfd, 0xb7, 0x85, 0xa4, 0xe2, 0xe6, 0xd8, 0x16, 0x51, 0x29, 0x9b, 0x58, 0x3d, 0x36, 0xc8,
-	0xb8, 0xa0, 0x71, 0xd5, 0xd0, 0xb7, 0x98, 0x0b, 0x83, 0x4a, 0xd0, 0x3a, 0x2e, 0x8f, 0x52, 0xb1,
-	0x21, 0x46, 0x27, 0x25, 0x8d, 0x24, 0xb3, 0x3e, 0x09, 0xc2, 0xb3, 0x5a, 0x1b, 0x4e, 0x45, 0xbf,
-	0x0c, 0x92, 0xe5, 0xc7, 0x05, 0xcc, 0x56, 0x56, 0x45, 0x42, 0xf0, 0x53, 0x34, 0x5b, 0x85, 0x0c,
-	0x4b, 0xd4, 0x5a, 0x2a, 0x02, 0xd1, 0xc0, 0x47, 0x2f, 0xbb, 0xc0, 0x1b, 0xf7, 0x1b, 0xc9, 0x90,
-	0xdc, 0x83, 0x97, 0xa2, 0xc9, 0xdb, 0xf2, 0x5f, 0x32, 0x04, 0x3f, 0x9f, 0x4e, 0x9d, 0xf4, 0x03,
-	0x47, 0x69, 0xa7, 0xe6, 0xee, 0xd4, 0xf3, 0xe4, 0x02, 0xe6, 0x1b, 0x69, 0xf8, 0xa1, 0xfb, 0x83,
-	0x59, 0xc2, 0xcd, 0xaa, 0x96, 0x1a, 0xb5, 0x3d, 0xbd, 0xb4, 0x8f, 0x50, 0xad, 0xb9, 0xa8, 0xd0,
-	0x71, 0xfb, 0xfc, 0xf4, 0xcd, 0xed, 0x03, 0x5c, 0xaf, 0xb9, 0x60, 0xbf, 0x5c, 0xec, 0x07, 0xba,
-	0x80, 0x79, 0x8a, 0x26, 0xc3, 0x8e, 0x5c, 0x8d, 0xf5, 0x0c, 0xbb, 0xe0, 0x2b, 0xd9, 0xd3, 0x9a,
-	0xdc, 0xc1, 0xe5, 0xb6, 0xb5, 0x8c, 0x4d, 0x7c, 0x07, 0xdb, 0xd3, 0x7a, 0xe2, 0x35, 0x01, 0x6f,
-	0xa7, 0xa8, 0xd0, 0x07, 0x54, 0x19, 0x76, 0x9a, 0xdc, 0x8e, 0x3d, 0xb7, 0xfa, 0x8a, 0xef, 0xae,
-	0xa8, 0x98, 0xf7, 0x3f, 0x98, 0x7c, 0x06, 0x00, 0x00, 0xff, 0xff, 0x5c, 0x44, 0x92, 0xd4, 0x2b,
-	0x02, 0x00, 0x00,
+	// 603 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0x5d, 0x4f, 0xdb, 0x30,
+	0x14, 0x86, 0x55, 0x69, 0xc0, 0x38, 0x14, 0xe8, 0xbc, 0x8d, 0x4d, 0xb9, 0xd8, 0x05, 0x9a, 0xa6,
+	0x82, 0x46, 0xdb, 0x0d, 0xa6, 0xed, 0x62, 0x37, 0x1b, 0x1d, 0x15, 0x2a, 0xa0, 0xa8, 0xad, 0xd8,
+	0xb5, 0x9b, 0x1e, 0x82, 0x47, 0x62, 0x07, 0x7f, 0x20, 0xfa, 0x03, 0xf7, 0xbf, 0x26, 0xe7, 0x0b,
+	0x27, 0x40, 0xd9, 0x4d, 0x62, 0x9f, 0x3c, 0xaf, 0xcf, 0x7b, 0x8e, 0xed, 0xc0, 0x7e, 0xc8, 0xf4,
+	0xa5, 0x99, 0x76, 0x02, 0x11, 0x77, 0xe5, 0x5e, 0x8c, 0x33, 0xc6, 0x69, 0x37, 0x8c, 0xe9, 0x9f,
+	0x2e, 0xe3, 0x1a, 0x25, 0xa7, 0x51, 0x37, 0xb8, 0x14, 0x72, 0x96, 0x3d, 0x3b, 0x89, 0x14, 0x5a,
+	0x90, 0xa5, 0x74, 0xe2, 0xed, 0x3e, 0xaa, 0xb5, 0x8f, 0x64, 0x9a, 0xbe, 0x32, 0xc9, 0xe7, 0xbf,
+	0x6b, 0xb0, 0x74, 0x68, 0x55, 0x64, 0x17, 0x36, 0x06, 0xa8, 0x7d, 0x89, 0x33, 0x0c, 0x50, 0x29,
+	0x21, 0x09, 0x74, 0x32, 0xbe, 0x73, 0x3a, 0xf1, 0x9a, 0xc5, 0xf8, 0x4c, 0xcc, 0x90, 0xb4, 0xa1,
+	0x39, 0x40, 0x3d, 0x36, 0xc1, 0x93, 0xe4, 0x1e, 0xb4, 0x5c, 0xf2, 0x84, 0x29, 0x5d, 0xa1, 0xd7,
+	0x5d, 0x5a, 0x91, 0x77, 0xf0, 0xcc, 0x67, 0x3c, 0xac, 0x20, 0xce, 0xd8, 0x9a, 0x1c, 0x57, 0x4d,
+	0x56, 0xd2, 0x55, 0xd8, 0x36, 0x34, 0xc7, 0xae, 0xc9, 0xc7, 0xc9, 0x6d, 0x58, 0x3e, 0x13, 0x9a,
+	0x5d, 0xcc, 0x17, 0x30, 0x07, 0xb0, 0x75, 0x18, 0x09, 0x85, 0xca, 0x66, 0x0f, 0x6c, 0x4f, 0xc3,
+	0x23, 0xc6, 0x43, 0x74, 0x8a, 0x3f, 0xee, 0x7b, 0x1b, 0xc5, 0x38, 0xff, 0xb6, 0x03, 0xeb, 0x47,
+	0x8c, 0xcf, 0x1e, 0xe8, 0xd4, 0x71, 0xbf, 0xd6, 0xa9, 0x01, 0x6c, 0x55, 0xd0, 0x11, 0x06, 0x46,
+	0x2a, 0x76, 0x83, 0xe4, 0x75, 0xc1, 0x9d, 0x08, 0x71, 0x65, 0x92, 0x11, 0x5e, 0x1b, 0x54, 0xda,
+	0xdb, 0xaa, 0x87, 0x55, 0x22, 0xb8, 0x42, 0x5b, 0xcd, 0x00, 0xf5, 0x10, 0xe7, 0x64, 0xad, 0x20,
+	0x86, 0x38, 0xf7, 0xca, 0xc9, 0x39, 0x8d, 0xc8, 0x47, 0x58, 0xf5, 0x8d, 0x65, 0xec, 0x64, 0xc3,
+	0xc1, 0xce, 0x69, 0xe4, 0x6d, 0x96, 0x24, 0x4a, 0xc5, 0x04, 0x27, 0x07, 0xb0, 0x59, 0xd2, 0x63,
+	0x2d, 0x91, 0xc6, 0x4f, 0x6a, 0xda, 0x0d, 0xb2, 0x9b, 0x1e, 0x92, 0x21, 0xce, 0x73, 0xc9, 0xa3,
+	0x6e, 0x7a, 0x0d, 0xf2, 0x1e, 0x56, 0xfb, 0x18, 0xa1, 0xc6, 0x7b, 0xb6, 0xdd, 0x3d, 0xf8, 0x00,
+	0x30, 0x40, 0x3d, 0xc2, 0x24, 0x62, 0x01, 0x5d, 0x50, 0x5d, 0x07, 0x5a, 0x77, 0xdc, 0x7f, 0x64,
+	0x6f, 0x03, 0xf8, 0xa6, 0x5c, 0xb7, 0x5e, 0x9a, 0xeb, 0xa0, 0x07, 0x2d, 0xdf, 0xd4, 0x56, 0x5e,
+	0xc0, 0xb7, 0x1b, 0xe4, 0x0b, 0xac, 0x0f, 0x50, 0x9f, 0xa2, 0xbc, 0x8a, 0x70, 0x22, 0x11, 0x49,
+	0xcb, 0xc1, 0x47, 0x94, 0x87, 0xe8, 0x91, 0x52, 0x70, 0x47, 0x7d, 0x4a, 0x4b, 0xfd, 0x69, 0x82,
+	0x2b, 0xd4, 0x8a, 0x94, 0x44, 0x1e, 0x18, 0xe1, 0xb5, 0xb7, 0x59, 0x4d, 0xab, 0xc8, 0xb7, 0xb4,
+	0xea, 0x9c, 0xc8, 0xbd, 0x3d, 0x24, 0xac, 0xf9, 0xed, 0x35, 0xc8, 0x3e, 0x34, 0x27, 0x92, 0x72,
+	0x75, 0x81, 0x72, 0x88, 0x73, 0x45, 0xde, 0x14, 0x84, 0x1b, 0xb5, 0xd2, 0x6a, 0x2b, 0x9e, 0xdb,
+	0xdb, 0x9c, 0x0a, 0x5e, 0x96, 0x47, 0x31, 0x8f, 0x3c, 0x68, 0xf0, 0x3b, 0xac, 0x64, 0x07, 0xc2,
+	0xc9, 0x70, 0x6a, 0x22, 0xcd, 0xd2, 0xcd, 0xca, 0x0e, 0xf5, 0xdb, 0xfb, 0x1f, 0xf2, 0x63, 0xfd,
+	0x35, 0xdd, 0xa4, 0x62, 0xad, 0xfa, 0xe2, 0x35, 0xa1, 0x6f, 0x5c, 0xe1, 0xea, 0x6f, 0xaa, 0x83,
+	0xcb, 0x34, 0xf1, 0xab, 0x02, 0x2b, 0x43, 0xd6, 0x2a, 0xa9, 0x44, 0x7f, 0xdd, 0x20, 0xd7, 0xbd,
+	0x06, 0xd9, 0x81, 0x15, 0x5f, 0x62, 0x42, 0x25, 0xde, 0xf5, 0x31, 0x0f, 0xd4, 0x9b, 0xb1, 0x03,
+	0xcb, 0x87, 0x22, 0x8e, 0x99, 0x26, 0xe5, 0x0f, 0x6d, 0x72, 0xcb, 0x8f, 0xfb, 0x5e, 0xab, 0x76,
+	0x2f, 0x14, 0xd9, 0x86, 0xa5, 0x1f, 0x53, 0x21, 0xef, 0x91, 0xd5, 0xde, 0xda, 0xab, 0x33, 0xb9,
+	0xe5, 0x63, 0x4d, 0xb5, 0x51, 0x75, 0xf4, 0x85, 0x33, 0xcd, 0x88, 0xe9, 0x72, 0xfa, 0x3b, 0xdf,
+	0xff, 0x37, 0x00, 0xed, 0x00, 0xf5, 0xaa, 0x38, 0x06, 0x00, 0x00,
 }
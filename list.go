@@ -0,0 +1,198 @@
+//
+//  lists the keys stored in the ring a page at a time, in order of their
+//  hashed IDs
+//
+
+package gmaj
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Limits on the number of keys in a page of a listing.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var errBadPageToken = grpc.Errorf(codes.InvalidArgument, "gmaj: bad page token")
+
+// listCursor is a position in a listing. Keys are listed in order of their
+// hashed IDs, and keys with the same ID in order of the keys themselves.
+type listCursor struct {
+	id  ID
+	key string
+}
+
+// less returns if c comes before other in a listing.
+func (c listCursor) less(other listCursor) bool {
+	if cmp := c.id.cmp(other.id); cmp != 0 {
+		return cmp < 0
+	}
+
+	return c.key < other.key
+}
+
+// list gets a page of the keys in the ring, starting where req.PageToken says.
+// It goes through the owners of the keys in order, asking each one for the
+// keys it has, until the page is full or it gets to the end of the ring.
+func (node *Node) list(ctx context.Context, req *gmajpb.ListRequest) (*gmajpb.ListResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultListLimit
+	} else if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	cursor, err := node.config.decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &gmajpb.ListResponse{}
+	for {
+		owner, err := node.findSuccessor(ctx, cursor.id)
+		if err != nil {
+			return nil, err
+		}
+
+		// The owner of the end of the ring also owns its start, so its part of
+		// the listing stops at the largest ID.
+		end := newID(owner.Id)
+		if end.cmp(cursor.id) < 0 {
+			end = node.config.mask
+		}
+
+		kvs, err := node.listKeysRPC(ctx, owner, &gmajpb.ListKeysReq{
+			StartId:  node.config.idBytes(cursor.id),
+			StartKey: cursor.key,
+			EndId:    node.config.idBytes(end),
+			Limit:    uint32(limit - len(resp.Items)),
+			Values:   req.Values,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, kv := range kvs {
+			resp.Items = append(resp.Items, &gmajpb.ListItem{
+				Key: kv.Key, Value: kv.Val, Version: kv.Version,
+			})
+		}
+
+		if len(resp.Items) >= limit && len(kvs) > 0 {
+			last := kvs[len(kvs)-1].Key
+			id, err := node.config.keyID(last)
+			if err != nil {
+				return nil, err
+			}
+
+			// The smallest key after last is last with a 0 byte appended.
+			resp.NextPageToken = node.config.pageToken(listCursor{id: id, key: last + "\x00"})
+			return resp, nil
+		}
+
+		if end == node.config.mask {
+			return resp, nil
+		}
+
+		cursor = listCursor{id: node.config.fingerMath(end, 0)}
+	}
+}
+
+// listKeys returns the keys in the datastore that are in a part of a listing,
+// in order, leaving out deleted and expired keys.
+func (node *Node) listKeys(req *gmajpb.ListKeysReq) (*gmajpb.KeyVals, error) {
+	if node.datastore == nil {
+		return nil, errNoDatastore
+	}
+
+	start := listCursor{id: newID(req.StartId), key: req.StartKey}
+	end := newID(req.EndId)
+	if end.cmp(start.id) < 0 {
+		return nil, errors.New("gmaj: listing ends before it starts")
+	}
+
+	type item struct {
+		listCursor
+		entry Entry
+	}
+
+	var (
+		items []item
+		err   error
+	)
+	now := node.clock.Now()
+	// Ranges do not include their start, so the range starts at the ID before
+	// start.id.
+	before := start.id.add(node.config.mask).and(node.config.mask)
+	rangeErr := node.datastore.Range(before, end, func(key string, entry Entry) bool {
+		if entry.Deleted || entry.expired(now) {
+			return true
+		}
+
+		var id ID
+		if id, err = node.config.keyID(key); err != nil {
+			return false
+		}
+
+		c := listCursor{id: id, key: key}
+		if !c.less(start) {
+			items = append(items, item{listCursor: c, entry: entry})
+		}
+		return true
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].less(items[j].listCursor) })
+	if req.Limit > 0 && len(items) > int(req.Limit) {
+		items = items[:req.Limit]
+	}
+
+	kvs := &gmajpb.KeyVals{KeyVals: make([]*gmajpb.KeyVal, 0, len(items))}
+	for _, item := range items {
+		kv := &gmajpb.KeyVal{Key: item.key, Version: item.entry.Version}
+		if req.Values {
+			kv.Val = item.entry.Val
+		}
+		kvs.KeyVals = append(kvs.KeyVals, kv)
+	}
+
+	return kvs, nil
+}
+
+// pageToken encodes a cursor as a page token.
+func (cfg *nodeConfig) pageToken(c listCursor) string {
+	return base64.RawURLEncoding.EncodeToString(append(cfg.idBytes(c.id), c.key...))
+}
+
+// decodePageToken returns the cursor a page token encodes. The empty token is
+// the start of the listing.
+func (cfg *nodeConfig) decodePageToken(token string) (listCursor, error) {
+	if token == "" {
+		return listCursor{}, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < cfg.IDLength {
+		return listCursor{}, errBadPageToken
+	}
+
+	return listCursor{
+		id:  newID(b[:cfg.IDLength]).and(cfg.mask),
+		key: string(b[cfg.IDLength:]),
+	}, nil
+}
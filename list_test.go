@@ -0,0 +1,87 @@
+package gmaj
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	<-time.After(testTimeout)
+
+	var want []listCursor
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("list%d", i)
+		if err := Put(node1, key, []byte(key)); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+
+		id, err := config.keyID(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, listCursor{id: id, key: key})
+	}
+	if err := Delete(node1, "list0"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	want = want[1:]
+	sort.Slice(want, func(i, j int) bool { return want[i].less(want[j]) })
+
+	var got []listCursor
+	req := &gmajpb.ListRequest{Limit: 7, Values: true}
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatal("Listing did not end")
+		}
+
+		resp, err := node2.list(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Unexpected error listing keys: %v", err)
+		}
+		if len(resp.Items) > int(req.Limit) {
+			t.Fatalf("Expected at most %v keys, got %v", req.Limit, len(resp.Items))
+		}
+
+		for _, item := range resp.Items {
+			if !reflect.DeepEqual(item.Value, []byte(item.Key)) {
+				t.Fatalf("Unexpected value for %q: %q", item.Key, item.Value)
+			}
+
+			id, err := config.keyID(item.Key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, listCursor{id: id, key: item.Key})
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected keys %v, got %v", want, got)
+	}
+
+	_, err := node2.list(context.Background(), &gmajpb.ListRequest{PageToken: "!"})
+	if grpc.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected bad page token to be rejected, got %v", err)
+	}
+}
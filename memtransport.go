@@ -0,0 +1,905 @@
+//
+//  an in-memory transport that lets many nodes in one process talk to each
+//  other without opening sockets
+//
+
+package gmaj
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/r-medina/gmaj/gmajpb"
+	"github.com/r-medina/gmaj/internal/chord"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MemNetwork is an in-memory network for nodes running in the same process.
+// It is useful for tests with many nodes.
+type MemNetwork struct {
+	servers  map[string]Server
+	streams  map[string]map[*memStream]context.CancelFunc // by server address
+	nextAddr int
+	mtx      sync.RWMutex
+}
+
+// NewMemNetwork creates an empty in-memory network.
+func NewMemNetwork() *MemNetwork {
+	return &MemNetwork{
+		servers: make(map[string]Server),
+		streams: make(map[string]map[*memStream]context.CancelFunc),
+	}
+}
+
+// Transport returns a new transport on the network, to be passed to a node with
+// WithTransport.
+func (network *MemNetwork) Transport() Transport {
+	return &memTransport{network: network}
+}
+
+// server returns the server listening on addr.
+func (network *MemNetwork) server(addr string) (Server, error) {
+	network.mtx.RLock()
+	srv, ok := network.servers[addr]
+	network.mtx.RUnlock()
+	if !ok {
+		return nil, grpc.Errorf(codes.Unavailable, "gmaj: nothing listening on %v", addr)
+	}
+
+	return srv, nil
+}
+
+// memTransport is a transport on a MemNetwork.
+type memTransport struct {
+	network *MemNetwork
+	addr    string
+	closed  bool
+	mtx     sync.Mutex
+}
+
+var _ Transport = (*memTransport)(nil)
+
+// Listen registers srv under addr on the network. If addr is empty, the
+// network picks an address.
+func (t *memTransport) Listen(addr string, srv Server) (string, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.closed {
+		return "", errTransportClosed
+	}
+	if t.addr != "" {
+		return "", fmt.Errorf("gmaj: transport is already listening on %v", t.addr)
+	}
+
+	network := t.network
+	network.mtx.Lock()
+	defer network.mtx.Unlock()
+
+	if addr == "" {
+		addr = fmt.Sprintf("mem-%d", network.nextAddr)
+		network.nextAddr++
+	}
+	if _, ok := network.servers[addr]; ok {
+		return "", fmt.Errorf("gmaj: address %v already in use", addr)
+	}
+	network.servers[addr] = srv
+	t.addr = addr
+
+	return addr, nil
+}
+
+// Dial returns a client for the server listening on addr.
+func (t *memTransport) Dial(ctx context.Context, addr string) (chord.ChordClient, error) {
+	t.mtx.Lock()
+	closed := t.closed
+	t.mtx.Unlock()
+	if closed {
+		return nil, errTransportClosed
+	}
+
+	if _, err := t.network.server(addr); err != nil {
+		return nil, err
+	}
+
+	return &memClient{network: t.network, addr: addr}, nil
+}
+
+// Close removes the server from the network.
+func (t *memTransport) Close() error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.closed = true
+	if t.addr == "" {
+		return nil
+	}
+
+	// Like a stopped gRPC server, the server stops handling the streaming
+	// calls that are in progress.
+	t.network.mtx.Lock()
+	delete(t.network.servers, t.addr)
+	for _, cancel := range t.network.streams[t.addr] {
+		cancel()
+	}
+	delete(t.network.streams, t.addr)
+	t.network.mtx.Unlock()
+
+	return nil
+}
+
+// memMaxMsgSize is the largest message gRPC receives by default. Larger
+// messages are refused by the MemNetwork too, so that it does not hide them.
+const memMaxMsgSize = 4 << 20
+
+// memCheckSize returns the error gRPC gives for a message that is too large.
+func memCheckSize(m proto.Message) error {
+	if size := proto.Size(m); size > memMaxMsgSize {
+		return grpc.Errorf(codes.ResourceExhausted,
+			"gmaj: message of %d bytes is larger than %d", size, memMaxMsgSize,
+		)
+	}
+
+	return nil
+}
+
+// memHandler passes a request on to the right method of a server.
+type memHandler func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error)
+
+type memResult struct {
+	out proto.Message
+	err error
+}
+
+// memClient is a chord.ChordClient for a server on a MemNetwork.
+type memClient struct {
+	network *MemNetwork
+	addr    string
+}
+
+var _ chord.ChordClient = (*memClient)(nil)
+
+// call makes a request to the server in its own goroutine, so that the caller
+// can give up on it like it would on a remote server.
+func (c *memClient) call(ctx context.Context, in proto.Message, handle memHandler) (proto.Message, error) {
+	srv, err := c.network.server(c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pass the metadata on the way gRPC does, and copy the messages so that
+	// nodes never share them.
+	if err := memCheckSize(in); err != nil {
+		return nil, err
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	srvCtx, cancel := context.WithCancel(metadata.NewIncomingContext(ctx, md))
+	defer cancel()
+	in = proto.Clone(in)
+
+	done := make(chan memResult, 1)
+	go func() {
+		out, err := handle(srvCtx, srv, in)
+		if err == nil {
+			err = memCheckSize(out)
+		}
+		if err != nil {
+			done <- memResult{err: status.Convert(err).Err()}
+			return
+		}
+
+		done <- memResult{out: proto.Clone(out)}
+	}()
+
+	select {
+	case res := <-done:
+		return res.out, res.err
+	case <-ctx.Done():
+		return nil, memContextErr(ctx)
+	}
+}
+
+// memContextErr returns the error gRPC gives when ctx is done.
+func memContextErr(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return grpc.Errorf(codes.DeadlineExceeded, "%v", ctx.Err())
+	}
+
+	return grpc.Errorf(codes.Canceled, "%v", ctx.Err())
+}
+
+// memStreamHandler passes a streaming call on to the right method of a server.
+type memStreamHandler func(srv Server, stream *memServerStream) error
+
+// stream starts a streaming call to the server, which handles it in its own
+// goroutine.
+func (c *memClient) stream(ctx context.Context, handle memStreamHandler) (*memClientStream, error) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	srvCtx, cancel := context.WithCancel(metadata.NewIncomingContext(ctx, md))
+	s := &memStream{
+		toServer: make(chan proto.Message),
+		toClient: make(chan proto.Message),
+		handled:  make(chan struct{}),
+	}
+
+	// Record the stream, so that it is cancelled if the server is closed.
+	network := c.network
+	network.mtx.Lock()
+	srv, ok := network.servers[c.addr]
+	if ok {
+		if network.streams[c.addr] == nil {
+			network.streams[c.addr] = make(map[*memStream]context.CancelFunc)
+		}
+		network.streams[c.addr][s] = cancel
+	}
+	network.mtx.Unlock()
+	if !ok {
+		cancel()
+		return nil, grpc.Errorf(codes.Unavailable, "gmaj: nothing listening on %v", c.addr)
+	}
+
+	go func() {
+		if err := handle(srv, &memServerStream{memStream: s, ctx: srvCtx}); err != nil {
+			s.err = status.Convert(err).Err()
+		}
+
+		network.mtx.Lock()
+		delete(network.streams[c.addr], s)
+		network.mtx.Unlock()
+
+		close(s.handled)
+		close(s.toClient)
+	}()
+
+	return &memClientStream{memStream: s, ctx: ctx, cancel: cancel}, nil
+}
+
+// memStream carries the messages of a streaming call on a MemNetwork. The
+// client and the server each get a view of it, and the messages are copied so
+// that nodes never share them.
+type memStream struct {
+	toServer chan proto.Message // closed when the client is done sending
+	toClient chan proto.Message // closed when the server's handler returns
+	handled  chan struct{}      // closed when the server's handler returns
+	err      error              // the error the handler returned
+	once     sync.Once
+}
+
+// memRecv receives a message from ch into m. It returns io.EOF once ch is
+// closed.
+func memRecv(ctx context.Context, ch <-chan proto.Message, m interface{}) error {
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			return io.EOF
+		}
+
+		m.(proto.Message).Reset()
+		proto.Merge(m.(proto.Message), msg)
+		return nil
+	case <-ctx.Done():
+		return memContextErr(ctx)
+	}
+}
+
+// memSend sends a copy of m on ch.
+func memSend(ctx context.Context, ch chan<- proto.Message, m interface{}) error {
+	if err := memCheckSize(m.(proto.Message)); err != nil {
+		return err
+	}
+
+	select {
+	case ch <- proto.Clone(m.(proto.Message)):
+		return nil
+	case <-ctx.Done():
+		return memContextErr(ctx)
+	}
+}
+
+// memClientStream is the client's view of a streaming call. It implements
+// grpc.ClientStream.
+type memClientStream struct {
+	*memStream
+	ctx    context.Context
+	cancel context.CancelFunc // cancels the server's context
+}
+
+var _ grpc.ClientStream = (*memClientStream)(nil)
+
+func (s *memClientStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *memClientStream) Trailer() metadata.MD         { return nil }
+func (s *memClientStream) Context() context.Context     { return s.ctx }
+
+func (s *memClientStream) CloseSend() error {
+	s.once.Do(func() { close(s.toServer) })
+	return nil
+}
+
+// SendMsg returns io.EOF if the server has returned, like gRPC does, and the
+// error it returned is given by RecvMsg.
+func (s *memClientStream) SendMsg(m interface{}) error {
+	if err := memCheckSize(m.(proto.Message)); err != nil {
+		return err
+	}
+
+	select {
+	case s.toServer <- proto.Clone(m.(proto.Message)):
+		return nil
+	case <-s.handled:
+		return io.EOF
+	case <-s.ctx.Done():
+		s.cancel()
+		return memContextErr(s.ctx)
+	}
+}
+
+func (s *memClientStream) RecvMsg(m interface{}) error {
+	err := memRecv(s.ctx, s.toClient, m)
+	if err == io.EOF && s.err != nil {
+		err = s.err
+	}
+	if err != nil {
+		s.cancel()
+	}
+
+	return err
+}
+
+// memServerStream is the server's view of a streaming call. It implements
+// grpc.ServerStream.
+type memServerStream struct {
+	*memStream
+	ctx context.Context
+}
+
+var _ grpc.ServerStream = (*memServerStream)(nil)
+
+func (s *memServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *memServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *memServerStream) SetTrailer(metadata.MD)       {}
+func (s *memServerStream) Context() context.Context     { return s.ctx }
+
+func (s *memServerStream) SendMsg(m interface{}) error {
+	return memSend(s.ctx, s.toClient, m)
+}
+
+func (s *memServerStream) RecvMsg(m interface{}) error {
+	return memRecv(s.ctx, s.toServer, m)
+}
+
+func (c *memClient) GetPredecessor(
+	ctx context.Context, in *gmajpb.MT, _ ...grpc.CallOption,
+) (*gmajpb.Node, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetPredecessor(ctx, in.(*gmajpb.MT))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Node), nil
+}
+
+func (c *memClient) GetSuccessor(
+	ctx context.Context, in *gmajpb.MT, _ ...grpc.CallOption,
+) (*gmajpb.Node, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetSuccessor(ctx, in.(*gmajpb.MT))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Node), nil
+}
+
+func (c *memClient) GetSuccessorList(
+	ctx context.Context, in *gmajpb.MT, _ ...grpc.CallOption,
+) (*gmajpb.Nodes, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetSuccessorList(ctx, in.(*gmajpb.MT))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Nodes), nil
+}
+
+func (c *memClient) Ping(
+	ctx context.Context, in *gmajpb.MT, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.Ping(ctx, in.(*gmajpb.MT))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) SetPredecessor(
+	ctx context.Context, in *gmajpb.Node, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.SetPredecessor(ctx, in.(*gmajpb.Node))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) SetSuccessor(
+	ctx context.Context, in *gmajpb.Node, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.SetSuccessor(ctx, in.(*gmajpb.Node))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) Notify(
+	ctx context.Context, in *gmajpb.Node, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.Notify(ctx, in.(*gmajpb.Node))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) ClosestPrecedingFinger(
+	ctx context.Context, in *gmajpb.ID, _ ...grpc.CallOption,
+) (*gmajpb.Finger, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.ClosestPrecedingFinger(ctx, in.(*gmajpb.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Finger), nil
+}
+
+func (c *memClient) FindSuccessor(
+	ctx context.Context, in *gmajpb.ID, _ ...grpc.CallOption,
+) (*gmajpb.Node, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.FindSuccessor(ctx, in.(*gmajpb.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Node), nil
+}
+
+func (c *memClient) FindSuccessorRecursive(
+	ctx context.Context, in *gmajpb.LookupRequest, _ ...grpc.CallOption,
+) (*gmajpb.LookupResponse, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.FindSuccessorRecursive(ctx, in.(*gmajpb.LookupRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.LookupResponse), nil
+}
+
+func (c *memClient) GetKey(
+	ctx context.Context, in *gmajpb.Key, _ ...grpc.CallOption,
+) (*gmajpb.Val, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetKey(ctx, in.(*gmajpb.Key))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Val), nil
+}
+
+func (c *memClient) PutKeyVal(
+	ctx context.Context, in *gmajpb.KeyVal, _ ...grpc.CallOption,
+) (*gmajpb.Version, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.PutKeyVal(ctx, in.(*gmajpb.KeyVal))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Version), nil
+}
+
+func (c *memClient) DeleteKey(
+	ctx context.Context, in *gmajpb.Key, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.DeleteKey(ctx, in.(*gmajpb.Key))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) GetReplica(
+	ctx context.Context, in *gmajpb.Key, _ ...grpc.CallOption,
+) (*gmajpb.Val, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetReplica(ctx, in.(*gmajpb.Key))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Val), nil
+}
+
+func (c *memClient) PutReplica(
+	ctx context.Context, in *gmajpb.KeyVal, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.PutReplica(ctx, in.(*gmajpb.KeyVal))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) GetMerkleTree(
+	ctx context.Context, in *gmajpb.KeyRange, _ ...grpc.CallOption,
+) (*gmajpb.MerkleTree, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetMerkleTree(ctx, in.(*gmajpb.KeyRange))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MerkleTree), nil
+}
+
+func (c *memClient) GetBuckets(
+	ctx context.Context, in *gmajpb.BucketsReq, _ ...grpc.CallOption,
+) (*gmajpb.KeyVals, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetBuckets(ctx, in.(*gmajpb.BucketsReq))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.KeyVals), nil
+}
+
+func (c *memClient) TransferKeys(
+	ctx context.Context, in *gmajpb.TransferKeysReq, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.TransferKeys(ctx, in.(*gmajpb.TransferKeysReq))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) ListKeys(
+	ctx context.Context, in *gmajpb.ListKeysReq, _ ...grpc.CallOption,
+) (*gmajpb.KeyVals, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.ListKeys(ctx, in.(*gmajpb.ListKeysReq))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.KeyVals), nil
+}
+
+func (c *memClient) GetKeys(
+	ctx context.Context, in *gmajpb.MultiGetRequest, _ ...grpc.CallOption,
+) (*gmajpb.MultiGetResponse, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetKeys(ctx, in.(*gmajpb.MultiGetRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MultiGetResponse), nil
+}
+
+func (c *memClient) PutKeyVals(
+	ctx context.Context, in *gmajpb.KeyVals, _ ...grpc.CallOption,
+) (*gmajpb.MultiPutResponse, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.PutKeyVals(ctx, in.(*gmajpb.KeyVals))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MultiPutResponse), nil
+}
+
+func (c *memClient) Prepare(
+	ctx context.Context, in *gmajpb.PrepareReq, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.Prepare(ctx, in.(*gmajpb.PrepareReq))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) Commit(
+	ctx context.Context, in *gmajpb.TxnID, _ ...grpc.CallOption,
+) (*gmajpb.Versions, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.Commit(ctx, in.(*gmajpb.TxnID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.Versions), nil
+}
+
+func (c *memClient) Abort(
+	ctx context.Context, in *gmajpb.TxnID, _ ...grpc.CallOption,
+) (*gmajpb.MT, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.Abort(ctx, in.(*gmajpb.TxnID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.MT), nil
+}
+
+func (c *memClient) GetTxnStatus(
+	ctx context.Context, in *gmajpb.TxnID, _ ...grpc.CallOption,
+) (*gmajpb.TxnStatus, error) {
+	out, err := c.call(ctx, in, func(ctx context.Context, srv Server, in proto.Message) (proto.Message, error) {
+		return srv.GetTxnStatus(ctx, in.(*gmajpb.TxnID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out.(*gmajpb.TxnStatus), nil
+}
+
+func (c *memClient) PutKeyValStream(
+	ctx context.Context, _ ...grpc.CallOption,
+) (chord.Chord_PutKeyValStreamClient, error) {
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.PutKeyValStream(memPutKeyValStreamServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memPutKeyValStreamClient{stream}, nil
+}
+
+func (c *memClient) GetKeyStream(
+	ctx context.Context, in *gmajpb.Key, _ ...grpc.CallOption,
+) (chord.Chord_GetKeyStreamClient, error) {
+	in = proto.Clone(in).(*gmajpb.Key)
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.GetKeyStream(in, memGetKeyStreamServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memGetKeyStreamClient{stream}, nil
+}
+
+func (c *memClient) GetReplicaStream(
+	ctx context.Context, in *gmajpb.Key, _ ...grpc.CallOption,
+) (chord.Chord_GetReplicaStreamClient, error) {
+	in = proto.Clone(in).(*gmajpb.Key)
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.GetReplicaStream(in, memGetReplicaStreamServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memGetReplicaStreamClient{stream}, nil
+}
+
+func (c *memClient) GetBucketsStream(
+	ctx context.Context, in *gmajpb.BucketsReq, _ ...grpc.CallOption,
+) (chord.Chord_GetBucketsStreamClient, error) {
+	in = proto.Clone(in).(*gmajpb.BucketsReq)
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.GetBucketsStream(in, memGetBucketsStreamServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memGetBucketsStreamClient{stream}, nil
+}
+
+func (c *memClient) PutReplicaStream(
+	ctx context.Context, _ ...grpc.CallOption,
+) (chord.Chord_PutReplicaStreamClient, error) {
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.PutReplicaStream(memPutReplicaStreamServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memPutReplicaStreamClient{stream}, nil
+}
+
+func (c *memClient) WatchKeys(
+	ctx context.Context, in *gmajpb.WatchKeysReq, _ ...grpc.CallOption,
+) (chord.Chord_WatchKeysClient, error) {
+	in = proto.Clone(in).(*gmajpb.WatchKeysReq)
+	stream, err := c.stream(ctx, func(srv Server, stream *memServerStream) error {
+		return srv.WatchKeys(in, memWatchKeysServer{stream})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return memWatchKeysClient{stream}, nil
+}
+
+// The typed views of the streams, like the ones generated for gRPC.
+
+type memPutKeyValStreamClient struct{ *memClientStream }
+
+func (x memPutKeyValStreamClient) Send(m *gmajpb.KeyVal) error { return x.SendMsg(m) }
+
+func (x memPutKeyValStreamClient) CloseAndRecv() (*gmajpb.Version, error) {
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	m := new(gmajpb.Version)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memPutKeyValStreamServer struct{ *memServerStream }
+
+func (x memPutKeyValStreamServer) SendAndClose(m *gmajpb.Version) error { return x.SendMsg(m) }
+
+func (x memPutKeyValStreamServer) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memGetKeyStreamClient struct{ *memClientStream }
+
+func (x memGetKeyStreamClient) Recv() (*gmajpb.Val, error) {
+	m := new(gmajpb.Val)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memGetKeyStreamServer struct{ *memServerStream }
+
+func (x memGetKeyStreamServer) Send(m *gmajpb.Val) error { return x.SendMsg(m) }
+
+type memGetReplicaStreamClient struct{ *memClientStream }
+
+func (x memGetReplicaStreamClient) Recv() (*gmajpb.Val, error) {
+	m := new(gmajpb.Val)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memGetReplicaStreamServer struct{ *memServerStream }
+
+func (x memGetReplicaStreamServer) Send(m *gmajpb.Val) error { return x.SendMsg(m) }
+
+type memGetBucketsStreamClient struct{ *memClientStream }
+
+func (x memGetBucketsStreamClient) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memGetBucketsStreamServer struct{ *memServerStream }
+
+func (x memGetBucketsStreamServer) Send(m *gmajpb.KeyVal) error { return x.SendMsg(m) }
+
+type memPutReplicaStreamClient struct{ *memClientStream }
+
+func (x memPutReplicaStreamClient) Send(m *gmajpb.KeyVal) error { return x.SendMsg(m) }
+
+func (x memPutReplicaStreamClient) CloseAndRecv() (*gmajpb.MT, error) {
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	m := new(gmajpb.MT)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memPutReplicaStreamServer struct{ *memServerStream }
+
+func (x memPutReplicaStreamServer) SendAndClose(m *gmajpb.MT) error { return x.SendMsg(m) }
+
+func (x memPutReplicaStreamServer) Recv() (*gmajpb.KeyVal, error) {
+	m := new(gmajpb.KeyVal)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memWatchKeysClient struct{ *memClientStream }
+
+func (x memWatchKeysClient) Recv() (*gmajpb.WatchEvent, error) {
+	m := new(gmajpb.WatchEvent)
+	if err := x.RecvMsg(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+type memWatchKeysServer struct{ *memServerStream }
+
+func (x memWatchKeysServer) Send(m *gmajpb.WatchEvent) error { return x.SendMsg(m) }
@@ -0,0 +1,125 @@
+package gmaj
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// TestMemNetworkManyNodes does not run in parallel with the other tests, since
+// its nodes keep the CPU busy.
+func TestMemNetworkManyNodes(t *testing.T) {
+	const nNodes = 100
+
+	// Keep the maintenance traffic of this many nodes down.
+	cfg := config.Config
+	cfg.FixNextFingerInterval = 250 * time.Millisecond
+	cfg.CheckPredInterval = 500 * time.Millisecond
+	cfg.AntiEntropyInterval = time.Second
+
+	network := NewMemNetwork()
+	nodes := make([]*Node, nNodes)
+	defer func() {
+		for _, node := range nodes {
+			if node != nil {
+				crashNode(node)
+			}
+		}
+	}()
+
+	var parent *gmajpb.Node
+	for i := range nodes {
+		var err error
+		nodes[i], err = NewNode(
+			parent, WithID([]byte{byte(i)}), WithConfig(&cfg),
+			WithTransport(network.Transport()),
+		)
+		if err != nil {
+			t.Fatalf("Unable to create node %d, received error:%v", i, err)
+		}
+
+		parent = nodes[0].Node
+	}
+
+	// Wait for every node to find its successor.
+	deadline := time.Now().Add(20 * time.Second)
+	for i := 0; i < nNodes; i++ {
+		want := nodes[(i+1)%nNodes]
+		for {
+			succ, err := nodes[i].getSuccessorRPC(context.Background(), nodes[i].Node)
+			if err == nil && idsEqual(succ.Id, want.Id) {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Node %d has successor %v, expected %v", i, succ, want.Node)
+			}
+
+			<-time.After(10 * time.Millisecond)
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		key, val := fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("val-%d", i))
+		if err := Put(nodes[i], key, val); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+
+		got, err := Get(nodes[nNodes-1-i], key)
+		if err != nil {
+			t.Fatalf("Unexpected error getting value: %v", err)
+		} else if string(got) != string(val) {
+			t.Fatalf("Unexpected value. Expected %q got %q", val, got)
+		}
+	}
+}
+
+func TestMemTransportClose(t *testing.T) {
+	t.Parallel()
+
+	network := NewMemNetwork()
+	node1 := createNodeOn(t, network)
+	node2 := createNodeOn(t, network)
+
+	if err := node1.pingRPC(context.Background(), node2.Node); err != nil {
+		t.Fatalf("Unexpected error pinging node: %v", err)
+	}
+
+	// Streaming calls in progress end when the server is closed.
+	watchErr := make(chan error, 1)
+	go func() {
+		watchErr <- node1.watchKeysRPC(context.Background(), node2.Node, &gmajpb.WatchKeysReq{
+			Key: "key", FromId: node2.Id, ToId: node2.Id,
+		}, func(*gmajpb.WatchEvent) error { return nil })
+	}()
+
+	crashNode(node2)
+
+	err := node1.pingRPC(context.Background(), node2.Node)
+	if code := grpc.Code(err); code != codes.Unavailable {
+		t.Fatalf("Expected %v, got %v", codes.Unavailable, err)
+	}
+
+	select {
+	case err := <-watchErr:
+		if err == nil {
+			t.Fatal("Expected watch to fail")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("Expected watch to end")
+	}
+}
+
+func createNodeOn(t *testing.T, network *MemNetwork) *Node {
+	node, err := NewNode(nil, WithTransport(network.Transport()))
+	if err != nil {
+		t.Fatalf("Unable to create node, received error:%v", err)
+	}
+
+	return node
+}
@@ -0,0 +1,290 @@
+//
+//  anti-entropy between a node and its replicas using Merkle trees
+//
+
+package gmaj
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+// merkleDepth is the depth of the Merkle trees, which have 2^merkleDepth
+// leaves (buckets).
+const merkleDepth = 6
+
+// merkleTree is a hash tree over the key/value pairs in a range of IDs. Keys
+// are put into buckets by where their IDs are in the range, so that trees built
+// by different nodes over the same range line up. The hashes are stored in heap
+// order, with the root at index 0 and the leaves at the end.
+type merkleTree [][]byte
+
+// newMerkleTree builds the Merkle tree for a set of key/value pairs with IDs in
+// (from : to].
+func newMerkleTree(cfg *nodeConfig, from, to ID, entries map[string]Entry) (merkleTree, error) {
+	const nLeaves = 1 << merkleDepth
+
+	buckets := make([][]string, nLeaves)
+	for key := range entries {
+		bucket, err := merkleBucket(cfg, from, to, key)
+		if err != nil {
+			return nil, err
+		}
+
+		buckets[bucket] = append(buckets[bucket], key)
+	}
+
+	tree := make(merkleTree, 2*nLeaves-1)
+	for i, keys := range buckets {
+		sort.Strings(keys)
+
+		h := sha1.New()
+		for _, key := range keys {
+			entry := entries[key]
+			keyHash := sha1.Sum([]byte(key))
+			valHash := sha1.Sum(entry.Val)
+			var version, expiry [8]byte
+			binary.BigEndian.PutUint64(version[:], entry.Version)
+			binary.BigEndian.PutUint64(expiry[:], uint64(entry.expiry()))
+			h.Write(keyHash[:])
+			h.Write(valHash[:])
+			h.Write(version[:])
+			h.Write(expiry[:])
+			if entry.Deleted {
+				h.Write([]byte{1})
+			} else {
+				h.Write([]byte{0})
+			}
+		}
+		tree[nLeaves-1+i] = h.Sum(nil)
+	}
+
+	for i := nLeaves - 2; i >= 0; i-- {
+		h := sha1.New()
+		h.Write(tree[2*i+1])
+		h.Write(tree[2*i+2])
+		tree[i] = h.Sum(nil)
+	}
+
+	return tree, nil
+}
+
+// diff returns the buckets whose contents differ between two trees.
+func (tree merkleTree) diff(other merkleTree) []uint32 {
+	firstLeaf := len(tree) / 2
+
+	var buckets []uint32
+	if len(other) != len(tree) {
+		for i := firstLeaf; i < len(tree); i++ {
+			buckets = append(buckets, uint32(i-firstLeaf))
+		}
+
+		return buckets
+	}
+
+	var walk func(i int)
+	walk = func(i int) {
+		if bytes.Equal(tree[i], other[i]) {
+			return
+		}
+
+		if i >= firstLeaf {
+			buckets = append(buckets, uint32(i-firstLeaf))
+			return
+		}
+
+		walk(2*i + 1)
+		walk(2*i + 2)
+	}
+	walk(0)
+
+	return buckets
+}
+
+// merkleBucket returns the bucket a key with an ID in (from : to] belongs in.
+// The range is split into equal parts, one per bucket, so that the keys of a
+// range that is a small part of the ring are still spread over the buckets.
+func merkleBucket(cfg *nodeConfig, from, to ID, key string) (uint32, error) {
+	id, err := cfg.keyID(key)
+	if err != nil {
+		return 0, err
+	}
+
+	ring := new(big.Int).Lsh(big.NewInt(1), uint(cfg.KeySize))
+	start := from.bigInt()
+
+	// The offset of the key in the range, from 0 to size-1.
+	offset := new(big.Int).Sub(id.bigInt(), start)
+	offset.Sub(offset, big.NewInt(1)).Mod(offset, ring)
+
+	size := new(big.Int).Sub(to.bigInt(), start)
+	size.Mod(size, ring)
+	if size.Sign() == 0 {
+		// The range is the whole ring.
+		size = ring
+	}
+
+	bucket := offset.Lsh(offset, merkleDepth).Div(offset, size)
+	if !bucket.IsUint64() || bucket.Uint64() >= 1<<merkleDepth {
+		// The key is not in the range.
+		return 1<<merkleDepth - 1, nil
+	}
+
+	return uint32(bucket.Uint64()), nil
+}
+
+// inBuckets returns the key/value pairs with IDs in (from : to] that belong in
+// the given buckets.
+func inBuckets(
+	cfg *nodeConfig, from, to ID, entries map[string]Entry, buckets []uint32,
+) (map[string]Entry, error) {
+	want := make(map[uint32]bool, len(buckets))
+	for _, bucket := range buckets {
+		want[bucket] = true
+	}
+
+	out := make(map[string]Entry)
+	for key, entry := range entries {
+		bucket, err := merkleBucket(cfg, from, to, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if want[bucket] {
+			out[key] = entry
+		}
+	}
+
+	return out, nil
+}
+
+// keysInRange returns the key/value pairs in the datastore with IDs between
+// (fromID : toID], including tombstones. If withReplicas is set, copies of
+// predecessors' keys are included as well.
+func (node *Node) keysInRange(
+	fromID, toID []byte, withReplicas bool,
+) (map[string]Entry, error) {
+	node.dsMtx.RLock()
+	defer node.dsMtx.RUnlock()
+
+	from, to := newID(fromID), newID(toID)
+	stores := []Store{node.datastore}
+	if withReplicas {
+		stores = append(stores, node.replicas)
+	}
+
+	entries := make(map[string]Entry)
+	for _, store := range stores {
+		err := store.Range(from, to, func(key string, entry Entry) bool {
+			if _, ok := entries[key]; !ok {
+				entries[key] = entry
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// antiEntropy compares the keys this node owns with the copies its replicas
+// have, and repairs any differences.
+func (node *Node) antiEntropy() {
+	ctx := context.Background()
+
+	node.predMtx.RLock()
+	pred := node.predecessor
+	node.predMtx.RUnlock()
+
+	if pred == nil {
+		return
+	}
+
+	replicas := node.replicaSet()
+	if len(replicas) == 0 {
+		return
+	}
+
+	entries, err := node.keysInRange(pred.Id, node.Id, false)
+	if err != nil {
+		node.config.Log.Printf("anti-entropy failed: %v", err)
+		return
+	}
+
+	tree, err := newMerkleTree(node.config, newID(pred.Id), node.id, entries)
+	if err != nil {
+		node.config.Log.Printf("anti-entropy failed: %v", err)
+		return
+	}
+
+	for _, replica := range replicas {
+		if err := node.repairReplica(ctx, replica, pred.Id, entries, tree); err != nil {
+			node.config.Log.Printf("anti-entropy with %v failed: %v", IDToString(replica.Id), err)
+		}
+	}
+}
+
+// repairReplica exchanges Merkle trees with a replica to find the keys that
+// differ between (fromID : node.Id]. Keys the replica is missing or has older
+// versions of are copied to it, and keys the replica has newer versions of, or
+// that only the replica has, are copied back to this node. Deleted keys have
+// tombstones, so they are not copied back.
+func (node *Node) repairReplica(
+	ctx context.Context,
+	replica *gmajpb.Node, fromID []byte, entries map[string]Entry, tree merkleTree,
+) error {
+	remoteTree, err := node.getMerkleTreeRPC(ctx, replica, fromID, node.Id)
+	if err != nil {
+		return err
+	}
+
+	buckets := tree.diff(remoteTree)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	remoteEntries, err := node.getBucketsRPC(ctx, replica, fromID, node.Id, buckets)
+	if err != nil {
+		return err
+	}
+
+	localEntries, err := inBuckets(node.config, newID(fromID), node.id, entries, buckets)
+	if err != nil {
+		return err
+	}
+
+	for key, entry := range localEntries {
+		if remote, ok := remoteEntries[key]; ok && !entry.newer(remote) {
+			continue
+		}
+
+		// Copies of keys locked by transactions are refused, and repaired
+		// in a later round.
+		if err := node.putReplicaRPC(ctx, replica, key, entry); err != nil && !isConflict(err) {
+			return err
+		}
+	}
+
+	for key, entry := range remoteEntries {
+		if local, ok := localEntries[key]; ok && !entry.newer(local) {
+			continue
+		}
+
+		keyVal := entryKeyVal(key, entry, node.clock.Now())
+		keyVal.Transfer = true
+		if _, err := node.putKeyVal(ctx, keyVal); err != nil && !isConflict(err) {
+			return err
+		}
+	}
+
+	return nil
+}
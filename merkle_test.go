@@ -0,0 +1,219 @@
+package gmaj
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajcfg"
+)
+
+func TestMerkleTreeDiff(t *testing.T) {
+	t.Parallel()
+
+	expires := time.Unix(1000, 0)
+	entries := map[string]Entry{"a": {Val: []byte("1")}, "b": {Val: []byte("2"), Expires: expires}, "c": {Val: []byte("3")}}
+	tree, err := newMerkleTree(&config.nodeConfig, ID{}, ID{}, entries)
+	if err != nil {
+		t.Fatalf("Unexpected error building tree: %v", err)
+	}
+
+	// Copies of a key expire at slightly different times, depending on how
+	// long they took to send.
+	same, err := newMerkleTree(&config.nodeConfig, ID{}, ID{}, map[string]Entry{"c": {Val: []byte("3")}, "b": {Val: []byte("2"), Expires: expires.Add(time.Millisecond)}, "a": {Val: []byte("1")}})
+	if err != nil {
+		t.Fatalf("Unexpected error building tree: %v", err)
+	}
+	if buckets := tree.diff(same); len(buckets) != 0 {
+		t.Fatalf("Expected no differences, got %v", buckets)
+	}
+
+	bucket, err := merkleBucket(&config.nodeConfig, ID{}, ID{}, "b")
+	if err != nil {
+		t.Fatalf("Unexpected error getting bucket: %v", err)
+	}
+
+	for _, other := range []map[string]Entry{
+		{"a": {Val: []byte("1")}, "c": {Val: []byte("3")}},
+		{"a": {Val: []byte("1")}, "b": {Val: []byte("two"), Expires: expires}, "c": {Val: []byte("3")}},
+		{"a": {Val: []byte("1")}, "b": {Val: []byte("2"), Deleted: true, Expires: expires}, "c": {Val: []byte("3")}},
+		{"a": {Val: []byte("1")}, "b": {Val: []byte("2")}, "c": {Val: []byte("3")}},
+		{"a": {Val: []byte("1")}, "b": {Val: []byte("2"), Expires: expires.Add(time.Hour)}, "c": {Val: []byte("3")}},
+	} {
+		otherTree, err := newMerkleTree(&config.nodeConfig, ID{}, ID{}, other)
+		if err != nil {
+			t.Fatalf("Unexpected error building tree: %v", err)
+		}
+
+		if want, got := []uint32{bucket}, tree.diff(otherTree); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Expected buckets %v to differ, got %v", want, got)
+		}
+	}
+}
+
+func TestMerkleBucket(t *testing.T) {
+	t.Parallel()
+
+	// The test ring is too small for the keys to have distinct IDs.
+	cfg, err := newNodeConfig(gmajcfg.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[ID]string)
+	var sorted []ID
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("bucket%d", i)
+		id, err := cfg.keyID(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[id] = key
+		sorted = append(sorted, id)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].cmp(sorted[j]) < 0 })
+
+	// A range with 100 of the keys is a small part of the ring, but its keys
+	// must still be spread over the buckets.
+	from, to := sorted[5000], sorted[5100]
+	used := make(map[uint32]bool)
+	for _, id := range sorted[5001:5101] {
+		bucket, err := merkleBucket(cfg, from, to, ids[id])
+		if err != nil {
+			t.Fatalf("Unexpected error getting bucket: %v", err)
+		}
+		used[bucket] = true
+	}
+	if len(used) < 1<<merkleDepth/2 {
+		t.Fatalf("Expected the keys to be spread over the buckets, got %v buckets", len(used))
+	}
+
+	// The first and last keys of the range are in the first and last buckets.
+	for want, id := range map[uint32]ID{0: sorted[5001], 1<<merkleDepth - 1: to} {
+		if got, err := merkleBucket(cfg, from, to, ids[id]); err != nil || got != want {
+			t.Fatalf("Expected bucket %v, got %v, %v", want, got, err)
+		}
+	}
+}
+
+func TestAntiEntropy(t *testing.T) {
+	t.Parallel()
+
+	node1 := createDefinedNode(t, nil, []byte{0})
+	node2 := createDefinedNode(t, node1.Node, []byte{0x80})
+
+	<-time.After(testTimeout)
+
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for _, key := range keys {
+		if err := Put(node1, key, []byte(key)); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+
+	// Make the replicas diverge from the owners.
+	for _, node := range []*Node{node1, node2} {
+		node.dsMtx.Lock()
+		node.replicas = newMemStore()
+		node.dsMtx.Unlock()
+	}
+
+	<-time.After(testTimeout)
+
+	for _, key := range keys {
+		for _, node := range []*Node{node1, node2} {
+			if got, err := node.getReplica(key); err != nil {
+				t.Fatalf("Unexpected error getting %q from %v: %v", key, node, err)
+			} else if !reflect.DeepEqual(got.Val, []byte(key)) {
+				t.Fatalf("Unexpected value. Expected %q got %q", key, got.Val)
+			}
+		}
+	}
+}
+
+func TestAntiEntropyDelete(t *testing.T) {
+	t.Parallel()
+
+	node1 := createDefinedNode(t, nil, []byte{0})
+	node2 := createDefinedNode(t, node1.Node, []byte{0x80})
+
+	<-time.After(testTimeout)
+
+	key, val := "a", []byte("1")
+	if err := Put(node1, key, val); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if err := Delete(node1, key); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+
+	owner, replica := node1, node2
+	if _, err := node2.getKey(key); isDeleted(err) {
+		owner, replica = node2, node1
+	}
+
+	// Make the replica miss the delete.
+	id, err := replica.config.keyID(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica.dsMtx.Lock()
+	_ = replica.replicas.Put(id, key, Entry{Val: val})
+	replica.dsMtx.Unlock()
+
+	<-time.After(testTimeout)
+
+	for _, node := range []*Node{owner, replica} {
+		if _, err := node.getReplica(key); !isDeleted(err) {
+			t.Fatalf("Expected %q to be deleted on %v, got %v", key, node, err)
+		}
+	}
+}
+
+func TestAntiEntropyExpires(t *testing.T) {
+	t.Parallel()
+
+	node1 := createDefinedNode(t, nil, []byte{0})
+	node2 := createDefinedNode(t, node1.Node, []byte{0x80})
+
+	<-time.After(testTimeout)
+
+	key, val := "a", []byte("1")
+	if err := Put(node1, key, val); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	owner, replica := node1, node2
+	entry, err := node1.getKey(key)
+	if err != nil {
+		owner, replica = node2, node1
+		if entry, err = node2.getKey(key); err != nil {
+			t.Fatalf("Unexpected error getting %q: %v", key, err)
+		}
+	}
+
+	// Give the replica's copy an expiry that the owner's does not have.
+	id, err := replica.config.keyID(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiring := entry
+	expiring.Expires = time.Now().Add(time.Hour)
+	replica.dsMtx.Lock()
+	_ = replica.replicas.Put(id, key, expiring)
+	replica.dsMtx.Unlock()
+
+	<-time.After(testTimeout)
+
+	for _, node := range []*Node{owner, replica} {
+		got, err := node.getReplica(key)
+		if err != nil {
+			t.Fatalf("Unexpected error getting %q from %v: %v", key, node, err)
+		}
+		if !got.Expires.IsZero() {
+			t.Fatalf("Expected %q not to expire on %v, got %v", key, node, got.Expires)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+//
+//  gets and puts many keys at once, with one RPC to each node that owns some
+//  of them
+//
+
+package gmaj
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	errBatchResults = errors.New("gmaj: wrong number of results for a batch")
+	// errBatchFull is the error for a key whose value did not fit in the
+	// response to a batch, and has to be gotten on its own.
+	errBatchFull = grpc.Errorf(codes.ResourceExhausted, "gmaj: value does not fit in the batch")
+)
+
+// batch is the keys of a multi-key request that one node owns, given by their
+// indexes in the request.
+type batch struct {
+	owner   *gmajpb.Node
+	indexes []int
+}
+
+// batchKeys groups keys by the nodes that own them. The keys are located in
+// the order of their IDs, so that the range of IDs a lookup finds the owner of
+// covers the keys after it too, and each owner is looked up about once even
+// without a location cache. Keys that cannot be located are passed to failed
+// instead.
+func (node *Node) batchKeys(
+	ctx context.Context, keys []string, failed func(i int, err error),
+) []*batch {
+	ids := make([]ID, len(keys))
+	order := make([]int, 0, len(keys))
+	for i, key := range keys {
+		id, err := node.config.keyID(key)
+		if err != nil {
+			failed(i, err)
+			continue
+		}
+
+		ids[i] = id
+		order = append(order, i)
+	}
+	sort.Slice(order, func(a, b int) bool { return ids[order[a]].cmp(ids[order[b]]) < 0 })
+
+	var (
+		batches     []*batch
+		byOwner     = make(map[string]*batch)
+		pred, owner *gmajpb.Node // from the last lookup
+	)
+	for _, i := range order {
+		if pred == nil || idsEqual(pred.Id, owner.Id) ||
+			!betweenRightIncl(ids[i], newID(pred.Id), newID(owner.Id)) {
+			var err error
+			if pred, owner, err = node.locateID(ctx, ids[i]); err != nil {
+				pred = nil
+				failed(i, err)
+				continue
+			}
+		}
+
+		b, ok := byOwner[string(owner.Id)]
+		if !ok {
+			b = &batch{owner: owner}
+			byOwner[string(owner.Id)] = b
+			batches = append(batches, b)
+		}
+		b.indexes = append(b.indexes, i)
+	}
+
+	return batches
+}
+
+// eachBatch calls f on each batch in parallel, and returns when all the calls
+// have.
+func eachBatch(batches []*batch, f func(b *batch)) {
+	var wg sync.WaitGroup
+	wg.Add(len(batches))
+	for _, b := range batches {
+		go func(b *batch) {
+			defer wg.Done()
+			f(b)
+		}(b)
+	}
+	wg.Wait()
+}
+
+// multiGet gets the values of keys from the nodes that own them, and returns
+// a result for each key. A batch that fails as a whole, keys that a node says
+// it does not own, and keys whose values did not fit in the response are
+// gotten again one by one with get, which streams large values.
+func (node *Node) multiGet(ctx context.Context, keys []string) []*gmajpb.GetResult {
+	results := make([]*gmajpb.GetResult, len(keys))
+	batches := node.batchKeys(ctx, keys, func(i int, err error) {
+		results[i] = getResult(keys[i], Entry{}, err)
+	})
+
+	eachBatch(batches, func(b *batch) {
+		req := &gmajpb.MultiGetRequest{Keys: make([]string, len(b.indexes))}
+		for j, i := range b.indexes {
+			req.Keys[j] = keys[i]
+		}
+
+		got, err := node.getKeysRPC(ctx, b.owner, req)
+		if err != nil {
+			node.locations.invalidate(b.owner)
+		}
+
+		for j, i := range b.indexes {
+			if err == nil && !notOwner(got[j].Error) && !batchFull(got[j].Error) {
+				results[i] = got[j]
+				continue
+			}
+
+			entry, err := node.get(ctx, keys[i])
+			results[i] = getResult(keys[i], entry, err)
+		}
+	})
+
+	return results
+}
+
+// multiPut writes key/values from a client to the nodes that own them, and
+// returns a result for each. Like in multiGet, the puts of keys a node does not
+// own are done again one by one, and so are those of a batch that fails as a
+// whole, but only if it was not written.
+func (node *Node) multiPut(ctx context.Context, keyVals []*gmajpb.KeyVal) []*gmajpb.PutResult {
+	keys := make([]string, len(keyVals))
+	for i, keyVal := range keyVals {
+		keyVal.CheckOwner = true
+		keys[i] = keyVal.Key
+	}
+
+	results := make([]*gmajpb.PutResult, len(keyVals))
+	batches := node.batchKeys(ctx, keys, func(i int, err error) {
+		results[i] = putResult(keys[i], 0, err)
+	})
+
+	eachBatch(batches, func(b *batch) {
+		for _, indexes := range splitBatch(b.indexes, keyVals) {
+			node.putBatch(ctx, b.owner, indexes, keyVals, results)
+		}
+	})
+
+	return results
+}
+
+// splitBatch splits the indexes of a batch of key/values so that the values
+// of each part add up to at most ChunkSize bytes, or are a single value.
+func splitBatch(indexes []int, keyVals []*gmajpb.KeyVal) [][]int {
+	var parts [][]int
+	for len(indexes) > 0 {
+		n, size := 1, len(keyVals[indexes[0]].Val)
+		for n < len(indexes) && size+len(keyVals[indexes[n]].Val) <= ChunkSize {
+			size += len(keyVals[indexes[n]].Val)
+			n++
+		}
+
+		parts = append(parts, indexes[:n])
+		indexes = indexes[n:]
+	}
+
+	return parts
+}
+
+// putBatch puts the key/values at indexes, which owner owns, and records the
+// results. A single value larger than ChunkSize is put with put, which sends
+// it in chunks.
+func (node *Node) putBatch(
+	ctx context.Context, owner *gmajpb.Node, indexes []int,
+	keyVals []*gmajpb.KeyVal, results []*gmajpb.PutResult,
+) {
+	if len(indexes) == 1 && len(keyVals[indexes[0]].Val) > ChunkSize {
+		i := indexes[0]
+		version, err := node.put(ctx, keyVals[i])
+		results[i] = putResult(keyVals[i].Key, version, err)
+		return
+	}
+
+	req := &gmajpb.KeyVals{KeyVals: make([]*gmajpb.KeyVal, len(indexes))}
+	for j, i := range indexes {
+		req.KeyVals[j] = keyVals[i]
+	}
+
+	got, err := node.putKeyValsRPC(ctx, owner, req)
+	if err != nil {
+		node.locations.invalidate(owner)
+	}
+
+	for j, i := range indexes {
+		switch {
+		case err == nil && !notOwner(got[j].Error):
+			results[i] = got[j]
+		case err == nil || notApplied(err):
+			version, err := node.put(ctx, keyVals[i])
+			results[i] = putResult(keyVals[i].Key, version, err)
+		default:
+			// The batch may have been written, so writing it again could
+			// apply an update twice, or fail a compare-and-swap on the
+			// version it set.
+			results[i] = putResult(keyVals[i].Key, 0, err)
+		}
+	}
+}
+
+// getKeys gets the values of keys the node owns, and returns a result for
+// each. Like in splitBatch, the values add up to at most ChunkSize bytes, so
+// that the results fit in one message. The keys whose values do not fit get
+// errBatchFull instead.
+func (node *Node) getKeys(keys []string) []*gmajpb.GetResult {
+	results := make([]*gmajpb.GetResult, len(keys))
+	size := 0
+	for i, key := range keys {
+		var entry Entry
+		err := node.checkOwner(key)
+		if err == nil {
+			entry, err = node.getKey(key)
+		}
+		if err == nil && size+len(entry.Val) > ChunkSize {
+			entry, err = Entry{}, errBatchFull
+		}
+		size += len(entry.Val)
+
+		results[i] = getResult(key, entry, err)
+	}
+
+	return results
+}
+
+// putKeyVals writes key/values to the datastore, and returns a result for
+// each.
+func (node *Node) putKeyVals(ctx context.Context, keyVals []*gmajpb.KeyVal) []*gmajpb.PutResult {
+	results := make([]*gmajpb.PutResult, len(keyVals))
+	for i, keyVal := range keyVals {
+		var version uint64
+		var err error
+		if keyVal.CheckOwner {
+			err = node.checkOwner(keyVal.Key)
+		}
+		if err == nil {
+			version, err = node.putKeyVal(ctx, keyVal)
+		}
+
+		results[i] = putResult(keyVal.Key, version, err)
+	}
+
+	return results
+}
+
+func getResult(key string, entry Entry, err error) *gmajpb.GetResult {
+	if err != nil {
+		return &gmajpb.GetResult{Key: key, Error: keyError(err)}
+	}
+
+	return &gmajpb.GetResult{Key: key, Value: entry.Val, Version: entry.Version}
+}
+
+func putResult(key string, version uint64, err error) *gmajpb.PutResult {
+	if err != nil {
+		return &gmajpb.PutResult{Key: key, Error: keyError(err)}
+	}
+
+	return &gmajpb.PutResult{Key: key, Version: version}
+}
+
+// keyError returns the error for one key of a batch, which keeps the gRPC code
+// of err.
+func keyError(err error) *gmajpb.KeyError {
+	return &gmajpb.KeyError{Code: uint32(grpc.Code(err)), Message: grpc.ErrorDesc(err)}
+}
+
+// keyErrorErr returns the error that a KeyError stands for.
+func keyErrorErr(keyErr *gmajpb.KeyError) error {
+	return grpc.Errorf(codes.Code(keyErr.Code), "%s", keyErr.Message)
+}
+
+// notOwner returns if keyErr is from a node that does not own the key.
+func notOwner(keyErr *gmajpb.KeyError) bool {
+	return keyErr != nil && codes.Code(keyErr.Code) == codes.FailedPrecondition
+}
+
+// notApplied returns if err, from a batch RPC, means that the batch was not
+// applied: its node either does not own the keys or could not be reached.
+func notApplied(err error) bool {
+	code := grpc.Code(err)
+	return code == codes.FailedPrecondition || code == codes.Unavailable
+}
+
+// batchFull returns if keyErr is errBatchFull.
+func batchFull(keyErr *gmajpb.KeyError) bool {
+	return keyErr != nil && codes.Code(keyErr.Code) == codes.ResourceExhausted
+}
@@ -0,0 +1,328 @@
+package gmaj
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMulti(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	<-time.After(testTimeout)
+
+	if err := Put(node1, "multi0", []byte("taken")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	ctx := context.Background()
+	putReq := &gmajpb.MultiPutRequest{}
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("multi%d", i)
+		putReq.Puts = append(putReq.Puts, &gmajpb.PutRequest{Key: key, Value: []byte(key)})
+	}
+	putResp, err := node1.MultiPut(ctx, putReq)
+	if err != nil {
+		t.Fatalf("Unexpected error putting values: %v", err)
+	}
+	if len(putResp.Results) != len(putReq.Puts) {
+		t.Fatalf("Expected %v results, got %v", len(putReq.Puts), len(putResp.Results))
+	}
+
+	for i, result := range putResp.Results {
+		key := putReq.Puts[i].Key
+		if result.Key != key {
+			t.Fatalf("Expected result for %q, got %q", key, result.Key)
+		}
+
+		if i == 0 {
+			if result.Error == nil || codes.Code(result.Error.Code) != codes.AlreadyExists {
+				t.Fatalf("Expected existing key to fail, got %v", result)
+			}
+			continue
+		}
+		if result.Error != nil || result.Version != 1 {
+			t.Fatalf("Unexpected result putting %q: %v", key, result)
+		}
+
+		// The key must be on the node that owns it.
+		owner, err := node1.locate(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range []*Node{node1, node2, node3} {
+			if !idsEqual(node.Id, owner.Id) {
+				continue
+			}
+			if entry, err := node.getKey(key); err != nil || string(entry.Val) != key {
+				t.Fatalf("Expected %q on its owner, got %q, %v", key, entry.Val, err)
+			}
+		}
+	}
+
+	if err := Delete(node1, "multi1"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+
+	keys := []string{"multi0", "multi1", "multi2", "multi29"}
+	getResp, err := node2.MultiGet(ctx, &gmajpb.MultiGetRequest{Keys: keys})
+	if err != nil {
+		t.Fatalf("Unexpected error getting values: %v", err)
+	}
+
+	want := []*gmajpb.GetResult{
+		{Key: "multi0", Value: []byte("taken"), Version: 1},
+		{Key: "multi1", Error: &gmajpb.KeyError{Code: uint32(codes.NotFound)}},
+		{Key: "multi2", Value: []byte("multi2"), Version: 1},
+		{Key: "multi29", Value: []byte("multi29"), Version: 1},
+	}
+	if len(getResp.Results) != len(want) {
+		t.Fatalf("Expected %v results, got %v", len(want), len(getResp.Results))
+	}
+	for i, result := range getResp.Results {
+		if result.Error != nil {
+			if want[i].Error == nil || result.Error.Code != want[i].Error.Code {
+				t.Fatalf("Expected %v, got %v", want[i], result)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(result, want[i]) {
+			t.Fatalf("Expected %v, got %v", want[i], result)
+		}
+	}
+}
+
+// lookupCountingServer is a Server that counts the iterative lookups started
+// at it, each of which begins by asking the node for its successor.
+type lookupCountingServer struct {
+	Server
+	lookups int32
+}
+
+func (srv *lookupCountingServer) GetSuccessor(ctx context.Context, mt *gmajpb.MT) (*gmajpb.Node, error) {
+	atomic.AddInt32(&srv.lookups, 1)
+	return srv.Server.GetSuccessor(ctx, mt)
+}
+
+func TestBatchKeys(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.LocationCacheSize = 0
+	node1, node2, node3 := create3SuccessiveNodes(t, WithConfig(&cfg))
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	waitForRing(t, node1, node2, node3)
+
+	testNetwork.mtx.Lock()
+	srv := &lookupCountingServer{Server: testNetwork.servers[node1.Addr]}
+	testNetwork.servers[node1.Addr] = srv
+	testNetwork.mtx.Unlock()
+
+	// Even without a location cache, the keys are located with about one
+	// lookup per owner: one more covers the keys past the largest ID, and
+	// the other nodes' lookups can go through node1 too.
+	ctx := context.Background()
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("batch%d", i)
+	}
+	batches := node1.batchKeys(ctx, keys, func(i int, err error) {
+		t.Fatalf("Unexpected error locating %q: %v", keys[i], err)
+	})
+	if lookups := atomic.LoadInt32(&srv.lookups); lookups > 10 {
+		t.Fatalf("Expected about one lookup per owner, got %v for %v keys", lookups, len(keys))
+	}
+
+	if len(batches) != 3 {
+		t.Fatalf("Expected a batch for each node, got %v", len(batches))
+	}
+	seen := 0
+	for _, b := range batches {
+		for _, i := range b.indexes {
+			owner, err := node1.locate(ctx, keys[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !idsEqual(owner.Id, b.owner.Id) {
+				t.Fatalf("Expected %q in the batch for %v, got %v",
+					keys[i], IDToString(owner.Id), IDToString(b.owner.Id),
+				)
+			}
+			seen++
+		}
+	}
+	if seen != len(keys) {
+		t.Fatalf("Expected %v keys in the batches, got %v", len(keys), seen)
+	}
+}
+
+// failingPutServer is a Server whose batch puts fail with err. If apply is
+// set, the batch is written before the error is returned, as if the response
+// was lost.
+type failingPutServer struct {
+	Server
+	apply bool
+	err   error
+}
+
+func (srv *failingPutServer) PutKeyVals(ctx context.Context, keyVals *gmajpb.KeyVals) (*gmajpb.MultiPutResponse, error) {
+	if srv.apply {
+		if _, err := srv.Server.PutKeyVals(ctx, keyVals); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, srv.err
+}
+
+func TestMultiPutFailedBatch(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	waitForRing(t, node1, node2, node3)
+
+	// Find keys that node2 owns.
+	ctx := context.Background()
+	var keys []string
+	for i := 0; len(keys) < 4; i++ {
+		key := fmt.Sprintf("failed%d", i)
+		owner, err := node1.locate(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idsEqual(owner.Id, node2.Id) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if err := Put(node1, key, []byte("old")); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+
+	testNetwork.mtx.Lock()
+	srv := &failingPutServer{Server: testNetwork.servers[node2.Addr]}
+	testNetwork.servers[node2.Addr] = srv
+	testNetwork.mtx.Unlock()
+
+	multiUpdate := func(keys []string, val string) []*gmajpb.PutResult {
+		keyVals := make([]*gmajpb.KeyVal, len(keys))
+		for i, key := range keys {
+			keyVals[i] = &gmajpb.KeyVal{Key: key, Val: []byte(val), Mode: gmajpb.PutMode_UPDATE}
+		}
+		return node1.multiPut(ctx, keyVals)
+	}
+
+	// A batch that is not written is put again key by key.
+	srv.err = grpc.Errorf(codes.Unavailable, "down")
+	for _, result := range multiUpdate(keys[:2], "new") {
+		if result.Error != nil || result.Version != 2 {
+			t.Fatalf("Unexpected result putting %q: %v", result.Key, result)
+		}
+	}
+
+	// A batch that may have been written is not, so its updates are only
+	// applied once.
+	srv.apply, srv.err = true, grpc.Errorf(codes.DeadlineExceeded, "lost")
+	for _, result := range multiUpdate(keys[2:], "new") {
+		if result.Error == nil || codes.Code(result.Error.Code) != codes.DeadlineExceeded {
+			t.Fatalf("Expected the batch's error putting %q, got %v", result.Key, result)
+		}
+	}
+	for _, key := range keys[2:] {
+		val, version, err := GetVersion(node1, key)
+		if err != nil {
+			t.Fatalf("Unexpected error getting %q: %v", key, err)
+		}
+		if string(val) != "new" || version != 2 {
+			t.Fatalf("Expected %q at version 2, got %q at version %v", "new", val, version)
+		}
+	}
+}
+
+func TestSplitBatch(t *testing.T) {
+	t.Parallel()
+
+	keyVals := []*gmajpb.KeyVal{
+		{Val: make([]byte, ChunkSize/2)},
+		{Val: make([]byte, ChunkSize/2)},
+		{Val: make([]byte, 1)},
+		{Val: make([]byte, ChunkSize+1)},
+		{},
+		{},
+	}
+
+	got := splitBatch([]int{0, 1, 2, 3, 4, 5}, keyVals)
+	want := [][]int{{0, 1}, {2}, {3}, {4, 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestMultiGetLarge(t *testing.T) {
+	t.Parallel()
+
+	node := createDefinedNode(t, nil, nil)
+	defer node.Shutdown()
+
+	// Together the values are larger than the largest message, and the last
+	// is on its own.
+	keys := make([]string, 6)
+	vals := make([][]byte, len(keys))
+	for i := range keys {
+		keys[i] = fmt.Sprintf("large%d", i)
+		vals[i] = make([]byte, ChunkSize)
+		if i == len(keys)-1 {
+			vals[i] = make([]byte, memMaxMsgSize+5)
+		}
+		rand.Read(vals[i])
+
+		if err := Put(node, keys[i], vals[i]); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+
+	// The node sends as many values as fit in a chunk, and the rest are
+	// gotten on their own.
+	for i, result := range node.getKeys(keys) {
+		if i == 0 {
+			if result.Error != nil || !bytes.Equal(result.Value, vals[i]) {
+				t.Fatalf("Expected %q in the batch, got error %v", keys[i], result.Error)
+			}
+		} else if !batchFull(result.Error) {
+			t.Fatalf("Expected %q to not fit in the batch, got %v", keys[i], result.Error)
+		}
+	}
+
+	resp, err := node.MultiGet(context.Background(), &gmajpb.MultiGetRequest{Keys: keys})
+	if err != nil {
+		t.Fatalf("Unexpected error getting values: %v", err)
+	}
+	for i, result := range resp.Results {
+		if result.Error != nil || !bytes.Equal(result.Value, vals[i]) || result.Version != 1 {
+			t.Fatalf("Unexpected result for %q: error %v", keys[i], result.Error)
+		}
+	}
+}
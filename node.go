@@ -1,45 +1,58 @@
 package gmaj
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"net"
 	"sync"
 	"time"
 
+	"github.com/r-medina/gmaj/gmajcfg"
 	"github.com/r-medina/gmaj/gmajpb"
 	"github.com/r-medina/gmaj/internal/chord"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 )
 
 // ErrBadIDLen indicates that the passed in ID is of the wrong length.
 var ErrBadIDLen = errors.New("gmaj: ID length does not match length in configuration")
 
+// ErrBadID indicates that the passed in ID has bits set above the key size.
+var ErrBadID = errors.New("gmaj: ID does not fit in key size")
+
 // Node represents a node in the Chord mesh.
 type Node struct {
 	*gmajpb.Node
+	id ID // Id, for the ring arithmetic
 
-	opts nodeOptions
+	opts   nodeOptions
+	config *nodeConfig // shared with the other virtual nodes on the host
 
-	grpcs *grpc.Server
+	host *host // gRPC server and client connections, shared by virtual nodes
 
 	predecessor *gmajpb.Node // This Node's predecessor
 	predMtx     sync.RWMutex
 
-	successor *gmajpb.Node // This Node's successor
-	succMtx   sync.RWMutex
+	successor  *gmajpb.Node   // This Node's successor
+	successors []*gmajpb.Node // Successor list, starting with successor
+	succMtx    sync.RWMutex
+
+	clock Clock    // Runs the background tasks
+	tasks []func() // Stops the background tasks
 
-	shutdownCh chan struct{}
+	locations *locationCache // Owners of recently looked up ranges
 
 	fingerTable fingerTable  // Finger table entries
 	ftMtx       sync.RWMutex // RWLock for finger table
 
-	datastore map[string][]byte // Local datastore for this node
-	dsMtx     sync.RWMutex      // RWLock for datastore
+	datastore Store        // Local datastore for this node
+	replicas  Store        // Copies of keys owned by predecessors
+	txnStore  Store        // Records of transactions, for when the node restarts
+	dsMtx     sync.RWMutex // Serializes changes to datastore, replicas and txnStore
 
-	clientConns map[string]*clientConn
-	connMtx     sync.RWMutex
+	watchers watchers // Watches on the keys in datastore
+	txns     txnLocks // Prepared transactions, guarded by dsMtx
 }
 
 var _ chord.ChordServer = (*Node)(nil)
@@ -50,6 +63,10 @@ type nodeOptions struct {
 	addr       string
 	serverOpts []grpc.ServerOption
 	dialOpts   []grpc.DialOption
+	config     *gmajcfg.Config
+	transport  Transport
+	clock      Clock
+	openStore  OpenStore
 }
 
 // NodeOption is a function that customizes a Node.
@@ -84,63 +101,183 @@ func WithGRPCDialOptions(opts ...grpc.DialOption) NodeOption {
 	}
 }
 
+// WithTransport makes the node send and receive RPCs with t instead of gRPC.
+// The gRPC options are ignored when a transport is set.
+func WithTransport(t Transport) NodeOption {
+	return func(o *nodeOptions) {
+		o.transport = t
+	}
+}
+
+// WithClock makes the node run its background tasks (e.g. stabilize) off c
+// instead of the wall clock. Useful for simulations.
+func WithClock(c Clock) NodeOption {
+	return func(o *nodeOptions) {
+		o.clock = c
+	}
+}
+
+// WithStore makes the node keep its keys in the stores opened by open instead
+// of in memory.
+func WithStore(open OpenStore) NodeOption {
+	return func(o *nodeOptions) {
+		o.openStore = open
+	}
+}
+
+// WithConfig makes the node use cfg instead of the configuration set by Init.
+// This allows nodes with different settings (e.g. key sizes) to run in the
+// same process.
+func WithConfig(cfg *gmajcfg.Config) NodeOption {
+	return func(o *nodeOptions) {
+		o.config = cfg
+	}
+}
+
 // NewNode creates a Chord node with a pre-defined ID (useful for
 // testing) if a non-nil id is provided.
 func NewNode(parent *gmajpb.Node, opts ...NodeOption) (*Node, error) {
-	node := &Node{
-		Node:        new(gmajpb.Node),
-		shutdownCh:  make(chan struct{}),
-		clientConns: make(map[string]*clientConn),
+	nodes, err := NewVirtualNodes(parent, 1, opts...)
+	if err != nil {
+		return nil, err
 	}
 
+	return nodes[0], nil
+}
+
+// NewVirtualNodes creates n Chord nodes that listen on the same address and
+// share connections to other nodes, but have distinct IDs, finger tables and
+// datastores. Running several virtual nodes per server evens out the
+// distribution of keys. Only the first node gets the ID set by WithID, and the
+// IDs of the others are derived from it, so that they are the same every time
+// the nodes are created with that ID.
+func NewVirtualNodes(parent *gmajpb.Node, n int, opts ...NodeOption) ([]*Node, error) {
+	if n < 1 {
+		return nil, errors.New("gmaj: must create at least one node")
+	}
+
+	var o nodeOptions
 	for _, opt := range opts {
-		opt(&node.opts)
+		opt(&o)
 	}
 
-	id := node.opts.id
-	addr := node.opts.addr
+	cfg := &config.nodeConfig
+	if o.config != nil {
+		var err error
+		if cfg, err = newNodeConfig(o.config); err != nil {
+			return nil, err
+		}
+	}
 
-	lis, err := net.Listen("tcp", addr)
+	h, err := newHost(o, cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	node.grpcs = grpc.NewServer(node.opts.serverOpts...)
-	chord.RegisterChordServer(node.grpcs, node)
-	gmajpb.RegisterGMajServer(node.grpcs, node)
+	nodes := make([]*Node, 0, n)
+	for i := 0; i < n; i++ {
+		var first *Node
+		if i > 0 {
+			first = nodes[0]
+		}
+
+		node, err := newNode(h, parent, i, first, o)
+		if err != nil {
+			for _, node := range nodes {
+				node.Shutdown()
+			}
+			if len(nodes) == 0 {
+				h.stop()
+			}
+
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+		parent = node.Node
+	}
+
+	return nodes, nil
+}
 
-	if id != nil {
-		if len(id) != config.IDLength {
+// newNode creates the i-th node running on a host. The IDs of all but the
+// first are derived from the ID of the first.
+func newNode(h *host, parent *gmajpb.Node, i int, first *Node, opts nodeOptions) (*Node, error) {
+	node := &Node{
+		Node:   &gmajpb.Node{Addr: h.addr},
+		opts:   opts,
+		config: h.config,
+		host:   h,
+		clock:  opts.clock,
+	}
+	if node.clock == nil {
+		node.clock = wallClock{}
+	}
+
+	switch {
+	case i == 0 && opts.id != nil:
+		if len(opts.id) != node.config.IDLength {
 			return nil, ErrBadIDLen
 		}
+		if !bytes.Equal(node.config.maskID(append([]byte(nil), opts.id...)), opts.id) {
+			return nil, ErrBadID
+		}
+		node.Id = opts.id
+	case i == 0:
+		id, err := node.config.hashKey(h.addr)
+		if err != nil {
+			return nil, err
+		}
 		node.Id = id
-	} else {
-		id, err := hashKey(lis.Addr().String())
+	default:
+		id, err := node.config.hashKey(fmt.Sprintf("%x#%d", first.Id, i))
 		if err != nil {
 			return nil, err
 		}
 		node.Id = id
 	}
-	node.Addr = lis.Addr().String()
-	node.datastore = make(map[string][]byte)
+	node.id = newID(node.Id)
+	node.locations = newLocationCache(node.config.LocationCacheSize)
+	if err := node.openStores(); err != nil {
+		return nil, err
+	}
+	if err := node.loadTxns(); err != nil {
+		node.closeStores()
+		return nil, err
+	}
 
 	// Populate finger table
-	node.fingerTable = newFingerTable(node.Node)
+	node.fingerTable = newFingerTable(node.config, node.Node)
+
+	// Start routing RPCs to this node
+	h.addNode(node)
+
+	if err := node.start(parent); err != nil {
+		h.removeNode(node)
+		node.closeStores()
+		return nil, err
+	}
+
+	return node, nil
+}
 
-	// Start RPC server
-	go node.grpcs.Serve(lis)
+// start joins the node to the ring and kicks off its background threads.
+func (node *Node) start(parent *gmajpb.Node) error {
+	ctx := context.Background()
 
 	// Join this node to the same chord ring as parent
 	var joinNode *gmajpb.Node
 	if parent != nil {
-		// Ask if our id exists on the ring.
-		remoteNode, err := node.findSuccessorRPC(parent, node.Id)
+		// Ask if our id exists on the ring. It may be this node, if it is
+		// restarting at the same address before the ring noticed that it was
+		// gone, in which case it takes its place again.
+		remoteNode, err := node.findSuccessorRPC(ctx, parent, node.id)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		if idsEqual(remoteNode.Id, node.Id) {
-			return nil, errors.New("node with id already exists")
+		if idsEqual(remoteNode.Id, node.Id) && remoteNode.Addr != node.Addr {
+			return errors.New("node with id already exists")
 		}
 
 		joinNode = parent
@@ -148,93 +285,239 @@ func NewNode(parent *gmajpb.Node, opts ...NodeOption) (*Node, error) {
 		joinNode = node.Node
 	}
 
-	if err := node.join(joinNode); err != nil {
-		return nil, err
+	if err := node.join(ctx, joinNode); err != nil {
+		return err
 	}
 
 	// thread 2: kick off timer to stabilize periodically
-	go func() {
-		ticker := time.NewTicker(config.StabilizeInterval)
-		for {
-			select {
-			case <-ticker.C:
-				node.stabilize()
-			case <-node.shutdownCh:
-				ticker.Stop()
-				return
-			}
-		}
-	}()
+	node.tasks = append(node.tasks,
+		node.clock.Every(node.config.StabilizeInterval, node.stabilize),
+	)
 
 	// thread 3: kick off timer to fix finger table periodically
-	go func() {
-		next := 0
-		ticker := time.NewTicker(config.FixNextFingerInterval)
-		for {
-			select {
-			case <-ticker.C:
-				next = node.fixNextFinger(next)
-			case <-node.shutdownCh:
-				ticker.Stop()
-				return
-			}
-		}
-	}()
+	next := 0
+	node.tasks = append(node.tasks,
+		node.clock.Every(node.config.FixNextFingerInterval, func() {
+			next = node.fixNextFinger(next)
+		}),
+	)
 
-	<-time.After(config.StabilizeInterval)
+	// thread 4: kick off timer to check if predecessor has failed periodically
+	node.tasks = append(node.tasks,
+		node.clock.Every(node.config.CheckPredInterval, node.checkPredecessor),
+	)
 
-	return node, nil
+	// thread 5: kick off timer to repair replicas periodically
+	node.tasks = append(node.tasks,
+		node.clock.Every(node.config.AntiEntropyInterval, node.antiEntropy),
+	)
+
+	// thread 6: kick off timer to remove expired keys periodically
+	if node.config.ReapInterval > 0 {
+		node.tasks = append(node.tasks,
+			node.clock.Every(node.config.ReapInterval, node.reap),
+		)
+	}
+
+	// thread 7: kick off timer to finish transactions that were left
+	// unfinished periodically
+	if node.config.TxnTimeout > 0 {
+		node.tasks = append(node.tasks,
+			node.clock.Every(node.config.TxnTimeout, node.resolveTxns),
+		)
+	}
+
+	<-node.clock.After(node.config.StabilizeInterval)
+
+	return nil
 }
 
 // join allows this node to join an existing ring that a remote node
 // is a part of (i.e., other).
-func (node *Node) join(other *gmajpb.Node) error {
-	succ, err := node.findSuccessorRPC(other, node.Id)
+func (node *Node) join(ctx context.Context, other *gmajpb.Node) error {
+	succ, err := node.findSuccessorRPC(ctx, other, node.id)
+	if err == nil && idsEqual(succ.Id, node.Id) && !idsEqual(other.Id, node.Id) {
+		succ, err = node.rejoinSuccessor(ctx, other)
+	}
 	if err != nil {
 		return err
 	}
+	node.setSuccessor(succ)
+
+	return node.obtainNewKeys(ctx)
+}
+
+// rejoinSuccessor finds the successor of a node that restarted before the ring
+// noticed that it was gone, and so still has it in its place. Lookups of the
+// IDs after the node would be routed to the node itself, which does not know
+// its successor yet, so it is taken from the successor list of the node's
+// predecessor instead.
+func (node *Node) rejoinSuccessor(ctx context.Context, other *gmajpb.Node) (*gmajpb.Node, error) {
+	resp, err := node.findSuccessorRecursiveRPC(ctx, other, &gmajpb.LookupRequest{
+		Id: node.Id, Finger: -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	successors, err := node.getSuccessorListRPC(ctx, resp.Pred)
+	if err != nil {
+		return nil, err
+	}
+	for _, succ := range successors {
+		if succ.Addr != "" && !idsEqual(succ.Id, node.Id) {
+			return succ, nil
+		}
+	}
+
+	// The predecessor is the only other node.
+	return resp.Pred, nil
+}
+
+// setSuccessor sets the successor and resets the successor list to only
+// contain it. The rest of the list is filled in by stabilize.
+func (node *Node) setSuccessor(succ *gmajpb.Node) {
 	node.succMtx.Lock()
 	node.successor = succ
+	node.successors = []*gmajpb.Node{succ}
 	node.succMtx.Unlock()
+}
+
+// updateSuccessors sets succ as the successor and builds the successor list
+// from it and its own successor list (succList). This is the successor list
+// maintenance described in section E.3 of the chord paper.
+func (node *Node) updateSuccessors(succ *gmajpb.Node, succList []*gmajpb.Node) {
+	successors := make([]*gmajpb.Node, 1, node.config.SuccessorListSize)
+	successors[0] = succ
+	for _, n := range succList {
+		if len(successors) == node.config.SuccessorListSize {
+			break
+		}
+
+		// Stop once the list wraps around the ring back to us, which happens
+		// when there are fewer nodes than entries in the list.
+		if n.Addr == "" || idsEqual(n.Id, node.Id) || idsEqual(n.Id, succ.Id) {
+			break
+		}
 
-	return node.obtainNewKeys()
+		successors = append(successors, n)
+	}
+
+	node.succMtx.Lock()
+	node.successor = succ
+	node.successors = successors
+	node.succMtx.Unlock()
+}
+
+// removeSuccessor removes a failed node from the successor list and fails over
+// to the next live successor. If there is none left, the node becomes its own
+// successor.
+func (node *Node) removeSuccessor(failed *gmajpb.Node) {
+	node.succMtx.Lock()
+	defer node.succMtx.Unlock()
+
+	successors := make([]*gmajpb.Node, 0, len(node.successors))
+	for _, n := range node.successors {
+		if !idsEqual(n.Id, failed.Id) {
+			successors = append(successors, n)
+		}
+	}
+
+	if len(successors) == 0 {
+		successors = append(successors, node.Node)
+	}
+
+	node.config.Log.Printf("successor %v failed, failing over to %v",
+		IDToString(failed.Id), IDToString(successors[0].Id),
+	)
+
+	node.successor = successors[0]
+	node.successors = successors
 }
 
 // stabilize attempts to stabilize a node.
-// This is an implementation of the psuedocode from figure 7 of chord paper.
+// This is an implementation of the psuedocode from figure 7 of chord paper,
+// with the successor list maintenance from section E.3.
 func (node *Node) stabilize() {
+	ctx := context.Background()
+
 	node.succMtx.RLock()
-	_succ := node.successor
-	if _succ == nil {
+	succ := node.successor
+	if succ == nil {
 		node.succMtx.RUnlock()
 		return
 	}
 	node.succMtx.RUnlock()
 
-	succ, err := node.getPredecessorRPC(_succ)
-	if succ == nil || err != nil {
-		// TODO: handle failed client
+	x, err := node.getPredecessorRPC(ctx, succ)
+	if err != nil {
+		// Our successor is unreachable, so we fall back to the next one in the
+		// list. It gets notified on the next round.
+		node.removeSuccessor(succ)
 		return
 	}
 
-	// If the predecessor of our successor is nil (succ), it means that our
+	// If the predecessor of our successor is nil (x), it means that our
 	// successor has not had the chance to update their predecessor pointer. We
 	// still want to notify them of our belief that we are its predecessor.
-	if succ.Id != nil && between(succ.Id, node.Id, _succ.Id) {
-		node.succMtx.Lock()
-		node.successor = succ
-		node.succMtx.Unlock()
+	next := succ
+	if x.Id != nil && between(newID(x.Id), node.id, newID(succ.Id)) {
+		next = x
 	}
 
+	succList, err := node.getSuccessorListRPC(ctx, next)
+	if err != nil && next != succ {
+		// Our successor may not have noticed that its predecessor failed, so
+		// we stay with our successor if x does not respond.
+		next = succ
+		succList, err = node.getSuccessorListRPC(ctx, next)
+	}
+	if err != nil {
+		node.removeSuccessor(succ)
+		return
+	}
+	succ = next
+	prevReplicas := node.replicaSet()
+	node.updateSuccessors(succ, succList)
+
+	// Membership changes may have changed where our keys should be copied.
+	node.reReplicate(ctx, prevReplicas)
+
 	// TODO(r-medina): handle error (necessary?)
-	_ = node.notifyRPC(_succ, node.Node)
+	_ = node.notifyRPC(ctx, succ, node.Node)
 
 	return
 }
 
+// checkPredecessor clears the predecessor if it has failed, so that notify can
+// accept a new one. This is an implementation of the psuedocode from figure 7
+// of chord paper.
+func (node *Node) checkPredecessor() {
+	node.predMtx.RLock()
+	pred := node.predecessor
+	node.predMtx.RUnlock()
+
+	if pred == nil {
+		return
+	}
+
+	if err := node.pingRPC(context.Background(), pred); err == nil {
+		return
+	}
+
+	node.config.Log.Printf("predecessor %v failed", IDToString(pred.Id))
+
+	node.predMtx.Lock()
+	// The predecessor may have changed while we were pinging it.
+	if node.predecessor != nil && idsEqual(node.predecessor.Id, pred.Id) {
+		node.predecessor = nil
+	}
+	node.predMtx.Unlock()
+}
+
 // notify is called when a remote node thinks its our predecessor. This is an
 // implementation of the psuedocode from figure 7 of chord paper.
-func (node *Node) notify(remoteNode *gmajpb.Node) {
+func (node *Node) notify(ctx context.Context, remoteNode *gmajpb.Node) {
 	node.predMtx.Lock()
 	defer node.predMtx.Unlock()
 
@@ -242,7 +525,7 @@ func (node *Node) notify(remoteNode *gmajpb.Node) {
 	// circle) since we are guaranteed that each node's successor link is
 	// correct.
 	if !(node.predecessor == nil ||
-		between(remoteNode.Id, node.predecessor.Id, node.Id)) {
+		between(newID(remoteNode.Id), newID(node.predecessor.Id), node.id)) {
 		return
 	}
 
@@ -254,114 +537,266 @@ func (node *Node) notify(remoteNode *gmajpb.Node) {
 	// Update predecessor and transfer keys.
 	node.predecessor = remoteNode
 
-	if between(node.predecessor.Id, prevID, node.Id) {
-		_ = node.transferKeys(prevID, node.predecessor)
+	if between(newID(node.predecessor.Id), newID(prevID), node.id) {
+		_ = node.transferKeys(ctx, prevID, node.predecessor)
 	}
+
+	// If our predecessor failed, we now own the keys we were keeping copies
+	// of on its behalf.
+	_ = node.promoteReplicas(ctx, node.predecessor.Id)
 }
 
 // findSuccessor finds the node's successor. This implements psuedocode from
 // figure 4 of chord paper.
-func (node *Node) findSuccessor(id []byte) (*gmajpb.Node, error) {
-	pred, err := node.findPredecessor(id)
+func (node *Node) findSuccessor(ctx context.Context, id ID) (*gmajpb.Node, error) {
+	_, succ, err := node.lookup(ctx, id, nil)
+	return succ, err
+}
+
+// lookup finds the predecessor and successor of id in the configured lookup
+// mode. If trace is not nil, the hops of the lookup are recorded in it.
+func (node *Node) lookup(
+	ctx context.Context, id ID, trace *lookupTrace,
+) (pred, succ *gmajpb.Node, err error) {
+	if node.config.LookupMode == gmajcfg.RecursiveLookup {
+		resp, err := node.findSuccessorRecursive(ctx, &gmajpb.LookupRequest{
+			Id: node.config.idBytes(id), Trace: trace != nil, Finger: -1,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if trace != nil {
+			trace.hops = resp.Path
+		}
+
+		return resp.Pred, resp.Node, nil
+	}
+
+	return node.findPredecessor(ctx, id, trace)
+}
+
+// findPredecessor finds the node's predecessor, along with that predecessor's
+// successor. This implements psuedocode from figure 4 of chord paper. Nodes
+// that fail to respond along the way are marked as suspect and routed around,
+// first by trying the next-best preceding finger and then by walking the
+// successor chain. If trace is not nil, the hops of the lookup are recorded in
+// it.
+func (node *Node) findPredecessor(
+	ctx context.Context, id ID, trace *lookupTrace,
+) (pred, succ *gmajpb.Node, err error) {
+	pred = node.Node
+	start := time.Now()
+	succ, err = node.getSuccessorRPC(ctx, pred)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	trace.add(pred, -1, start)
 
 	// TODO(r-medina): make an error in the rpc stuff for empty responses?
-	if pred.Addr == "" {
-		return node.Node, nil
+	if succ.Addr == "" {
+		return pred, pred, nil
 	}
 
-	succ, err := node.getSuccessorRPC(pred)
+	for !betweenRightIncl(id, newID(pred.Id), newID(succ.Id)) {
+		start := time.Now()
+		next := node.closestPrecedingFingerOf(ctx, pred, id)
+		if next != nil {
+			nextSucc, err := node.getSuccessorRPC(ctx, next.Node)
+			if err == nil && nextSucc.Addr != "" {
+				pred, succ = next.Node, nextSucc
+				trace.add(pred, int(next.Index), start)
+				continue
+			}
+
+			node.suspect(next.Node)
+		}
+
+		pred, succ, err = node.nextSuccessor(ctx, pred, succ)
+		if err != nil {
+			return nil, nil, err
+		}
+		trace.add(pred, -1, start)
+	}
+
+	return pred, succ, nil
+}
+
+// findSuccessorRecursive handles a step of a recursive lookup. If the ID falls
+// between the node and its successor, the successor is the answer. Otherwise
+// the lookup is passed on to the closest preceding finger, or, if that does not
+// respond, along the successor list.
+func (node *Node) findSuccessorRecursive(
+	ctx context.Context, req *gmajpb.LookupRequest,
+) (*gmajpb.LookupResponse, error) {
+	start := time.Now()
+	resp, err := node.forwardLookup(ctx, newID(req.Id), req.Trace)
 	if err != nil {
 		return nil, err
 	}
 
-	if succ.Addr == "" {
-		return node.Node, nil
+	if req.Trace {
+		// The latency of a hop includes the hops after it, since they happen
+		// while the node waits for the answer.
+		hop := &gmajpb.Hop{
+			Node: node.Node, Finger: req.Finger, LatencyNs: int64(time.Since(start)),
+		}
+		resp.Path = append([]*gmajpb.Hop{hop}, resp.Path...)
 	}
 
-	return succ, nil
+	return resp, nil
 }
 
-// findPredecessor finds the node's predecessor. This implements psuedocode from
-// figure 4 of chord paper.
-func (node *Node) findPredecessor(id []byte) (*gmajpb.Node, error) {
-	pred := node.Node
-	node.succMtx.Lock()
-	succ := node.successor
-	node.succMtx.Unlock()
-	if succ == nil {
-		return pred, nil
+// forwardLookup answers a recursive lookup for id or passes it on to the next
+// node.
+func (node *Node) forwardLookup(
+	ctx context.Context, id ID, trace bool,
+) (*gmajpb.LookupResponse, error) {
+	node.succMtx.RLock()
+	successors := node.successors
+	node.succMtx.RUnlock()
+
+	if len(successors) == 0 {
+		return &gmajpb.LookupResponse{Node: node.Node, Pred: node.Node}, nil
 	}
-	if !betweenRightIncl(id, pred.Id, succ.Id) {
-		pred = node.closestPrecedingFinger(id)
-	} else {
-		return pred, nil
+	if betweenRightIncl(id, node.id, newID(successors[0].Id)) {
+		return &gmajpb.LookupResponse{Node: successors[0], Pred: node.Node}, nil
 	}
 
-	// TODO(asubiotto): Handle error?
-	succ, _ = node.getSuccessorRPC(pred)
+	req := &gmajpb.LookupRequest{Id: node.config.idBytes(id), Trace: trace}
 
-	if succ == nil || succ.Addr == "" {
-		return pred, nil
+	var tried *gmajpb.Node
+	if next := node.closestPrecedingFinger(id); next.Index >= 0 {
+		req.Finger = next.Index
+		resp, err := node.findSuccessorRecursiveRPC(ctx, next.Node, req)
+		if err == nil || ctx.Err() != nil {
+			return resp, err
+		}
+
+		node.suspect(next.Node)
+		tried = next.Node
 	}
 
-	for !betweenRightIncl(id, pred.Id, succ.Id) {
-		var err error
-		pred, err = node.closestPrecedingFingerRPC(succ, id)
-		if err != nil {
-			return nil, err
+	// Walk the successor list, skipping the successors that do not respond.
+	req.Finger = -1
+	for _, n := range successors {
+		if n.Addr == "" || idsEqual(n.Id, node.Id) ||
+			(tried != nil && idsEqual(n.Id, tried.Id)) {
+			continue
 		}
 
-		if pred.Addr == "" {
-			return node.Node, nil
+		if betweenRightIncl(id, node.id, newID(n.Id)) {
+			// The successors before n failed, so n now owns id.
+			if err := node.pingRPC(ctx, n); err == nil {
+				return &gmajpb.LookupResponse{Node: n, Pred: node.Node}, nil
+			}
+		} else {
+			resp, err := node.findSuccessorRecursiveRPC(ctx, n, req)
+			if err == nil || ctx.Err() != nil {
+				return resp, err
+			}
 		}
 
-		succ, err = node.getSuccessorRPC(pred)
+		node.suspect(n)
+	}
+
+	return nil, errors.New("gmaj: no live successor")
+}
+
+// closestPrecedingFingerOf asks pred for its closest preceding finger for id.
+// It returns nil if pred does not respond or its answer would not get the
+// lookup any closer to id.
+func (node *Node) closestPrecedingFingerOf(
+	ctx context.Context, pred *gmajpb.Node, id ID,
+) *gmajpb.Finger {
+	var next *gmajpb.Finger
+	if idsEqual(pred.Id, node.Id) {
+		next = node.closestPrecedingFinger(id)
+	} else {
+		var err error
+		next, err = node.closestPrecedingFingerRPC(ctx, pred, id)
 		if err != nil {
-			return nil, err
+			return nil
+		}
+	}
+
+	if next.Node == nil || next.Node.Addr == "" ||
+		!between(newID(next.Node.Id), newID(pred.Id), id) {
+		return nil
+	}
+
+	return next
+}
+
+// nextSuccessor takes one step along the successor chain, returning succ and
+// its successor. If succ does not respond, pred's successor list is used to
+// replace it with the next live node.
+func (node *Node) nextSuccessor(
+	ctx context.Context, pred, succ *gmajpb.Node,
+) (*gmajpb.Node, *gmajpb.Node, error) {
+	nextSucc, err := node.getSuccessorRPC(ctx, succ)
+	if err == nil && nextSucc.Addr != "" {
+		return succ, nextSucc, nil
+	}
+
+	node.suspect(succ)
+
+	succList, err := node.getSuccessorListRPC(ctx, pred)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, n := range succList {
+		if idsEqual(n.Id, succ.Id) || n.Addr == "" {
+			continue
 		}
 
-		if succ.Addr == "" {
-			return node.Node, nil
+		if err := node.pingRPC(ctx, n); err == nil {
+			return pred, n, nil
 		}
+
+		node.suspect(n)
 	}
 
-	return pred, nil
+	return nil, nil, errors.New("gmaj: no live successor")
 }
 
-// closestPrecedingFinger finds the closest preceding finger in the table.
+// closestPrecedingFinger finds the closest preceding finger in the table,
+// skipping fingers that are suspected to have failed. If there is none, it
+// returns the node itself, with an index of -1.
 // This implements pseudocode from figure 4 of chord paper.
-func (node *Node) closestPrecedingFinger(id []byte) *gmajpb.Node {
+func (node *Node) closestPrecedingFinger(id ID) *gmajpb.Finger {
 	node.ftMtx.RLock()
 	defer node.ftMtx.RUnlock()
 
-	for i := config.KeySize - 1; i >= 0; i-- {
+	for i := node.config.KeySize - 1; i >= 0; i-- {
 		n := node.fingerTable[i]
-		if n.RemoteNode == nil {
+		if n.RemoteNode == nil || n.suspect {
 			continue
 		}
 
 		// Check that the node we believe is the successor for
 		// (node + 2^i) mod 2^m also precedes id.
-		if between(n.RemoteNode.Id, node.Id, id) {
-			return n.RemoteNode
+		if between(newID(n.RemoteNode.Id), node.id, id) {
+			return &gmajpb.Finger{Node: n.RemoteNode, Index: int32(i)}
 		}
 	}
 
-	return node.Node
+	return &gmajpb.Finger{Node: node.Node, Index: -1}
 }
 
 // Shutdown shuts down the Chord node (gracefully).
 func (node *Node) Shutdown() {
-	close(node.shutdownCh)
+	node.stopTasks()
 
-	node.grpcs.Stop()
+	// Stop serving RPCs for this node. The host keeps running until all of its
+	// nodes are shut down.
+	remaining := node.host.removeNode(node)
 
 	// Notify successor to change its predecessor pointer to our predecessor.
 	// Do nothing if we are our own successor (i.e. we are the only node in the
-	// ring).
+	// ring). The IDs are compared, since the successor may be another virtual
+	// node with the same address.
 	node.succMtx.RLock()
 	node.predMtx.RLock()
 	pred := node.predecessor
@@ -369,17 +804,31 @@ func (node *Node) Shutdown() {
 	node.predMtx.RUnlock()
 	node.succMtx.RUnlock()
 
-	if node.Addr != succ.Addr && pred != nil {
-		_ = node.transferKeys(pred.Id, succ)
-		_ = node.setPredecessorRPC(succ, pred)
-		_ = node.setSuccessorRPC(pred, succ)
+	// Keys locked by prepared transactions stay behind, along with the
+	// records of the transactions, for the node to finish if it restarts.
+	if !idsEqual(succ.Id, node.Id) && pred != nil {
+		ctx := context.Background()
+		_ = node.transferKeys(ctx, pred.Id, succ)
+		_ = node.setPredecessorRPC(ctx, succ, pred)
+		_ = node.setSuccessorRPC(ctx, pred, succ)
+	}
+
+	// The node's keys have moved to its successor, if it had one, so the
+	// watches on them have to be set up there.
+	node.watchers.endAll(errWatchMoved)
+
+	node.closeStores()
+
+	if remaining == 0 {
+		node.host.stop()
 	}
+}
 
-	node.connMtx.Lock()
-	for _, cc := range node.clientConns {
-		_ = cc.conn.Close()
+// stopTasks stops the background tasks of the node.
+func (node *Node) stopTasks() {
+	for _, stop := range node.tasks {
+		stop()
 	}
-	node.connMtx.Unlock()
 }
 
 // String takes a Node and generates a short semi-descriptive string.
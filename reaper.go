@@ -0,0 +1,38 @@
+//
+//  removes expired keys and tombstones from a node's stores in the background
+//
+
+package gmaj
+
+// reap removes the expired keys from the datastore and replicas. Every copy
+// of a key expires on its own, so unlike deleted keys, expired keys do not need
+// tombstones. The tombstones themselves expire after a grace period, and are
+// removed here too. Keys locked by prepared transactions are left until the
+// transactions end.
+func (node *Node) reap() {
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	now := node.clock.Now()
+
+	for _, store := range []Store{node.datastore, node.replicas} {
+		var expired []string
+		err := store.Range(node.id, node.id, func(key string, entry Entry) bool {
+			if entry.expired(now) && node.checkLock(key) == nil {
+				expired = append(expired, key)
+			}
+			return true
+		})
+		if err != nil {
+			node.config.Log.Printf("reaping expired keys failed: %v", err)
+			return
+		}
+
+		for _, key := range expired {
+			if err := store.Delete(key); err != nil {
+				node.config.Log.Printf("reaping expired key %q failed: %v", key, err)
+				return
+			}
+		}
+	}
+}
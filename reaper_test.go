@@ -0,0 +1,85 @@
+package gmaj
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+func TestReap(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	node := &Node{
+		Node: new(gmajpb.Node), config: &config.nodeConfig, clock: wallClock{},
+		datastore: newMemStore(), replicas: newMemStore(),
+	}
+
+	_ = node.datastore.Put(newID([]byte{1}), "a", Entry{Val: []byte("a")})
+	_ = node.datastore.Put(newID([]byte{2}), "b", Entry{Val: []byte("b"), Expires: now.Add(-time.Second)})
+	_ = node.datastore.Put(newID([]byte{3}), "c", Entry{Val: []byte("c"), Expires: now.Add(time.Hour)})
+	_ = node.replicas.Put(newID([]byte{4}), "d", Entry{Val: []byte("d"), Expires: now.Add(-time.Second)})
+
+	// Expired keys are hidden until they are reaped.
+	if _, err := node.getKey("b"); !isDeleted(err) {
+		t.Fatalf("Expected b to have expired, got %v", err)
+	}
+
+	node.reap()
+
+	want := map[string][]byte{"a": []byte("a"), "c": []byte("c")}
+	if got := storeContents(t, node.datastore); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected datastore to have %v, got %v", want, got)
+	}
+	if got := storeContents(t, node.replicas); len(got) != 0 {
+		t.Fatalf("Expected replicas to be empty, got %v", got)
+	}
+}
+
+func TestReapTombstones(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.nodeConfig
+	cfg.TombstoneGracePeriod = 50 * time.Millisecond
+	node := &Node{
+		Node: new(gmajpb.Node), config: &cfg, clock: wallClock{},
+		datastore: newMemStore(), replicas: newMemStore(),
+	}
+
+	ctx := context.Background()
+	if _, err := node.putKeyVal(ctx, &gmajpb.KeyVal{Key: "a", Val: []byte("a")}); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if err := node.deleteKey(ctx, "a"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+
+	// The copies of the tombstone expire along with it.
+	tombstone, ok, _ := node.datastore.Get("a")
+	if !ok || !tombstone.Deleted || tombstone.Expires.IsZero() {
+		t.Fatalf("Expected a tombstone that expires, got %v", tombstone)
+	}
+	if err := node.putReplica(entryKeyVal("a", tombstone, node.clock.Now())); err != nil {
+		t.Fatalf("Unexpected error putting replica: %v", err)
+	}
+
+	// The tombstones are kept for the grace period.
+	node.reap()
+	if _, ok, _ := node.datastore.Get("a"); !ok {
+		t.Fatal("Expected the tombstone to be kept for the grace period")
+	}
+
+	<-time.After(2 * cfg.TombstoneGracePeriod)
+
+	node.reap()
+	if _, ok, _ := node.datastore.Get("a"); ok {
+		t.Fatal("Expected the tombstone to be removed after the grace period")
+	}
+	if _, ok, _ := node.replicas.Get("a"); ok {
+		t.Fatal("Expected the copy of the tombstone to be removed after the grace period")
+	}
+}
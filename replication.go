@@ -0,0 +1,261 @@
+//
+//  keeps copies of each key on the successors of the node that owns it, so
+//  that keys survive the failure of their owner
+//
+
+package gmaj
+
+import (
+	"errors"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+// replicaSet returns the successors that should hold copies of this node's
+// keys. Successors on the same host as this node or as another replica are
+// skipped, since virtual nodes on one host fail together, so there may be
+// fewer replicas than the replication factor.
+func (node *Node) replicaSet() []*gmajpb.Node {
+	node.succMtx.RLock()
+	defer node.succMtx.RUnlock()
+
+	replicas := make([]*gmajpb.Node, 0, node.config.ReplicationFactor)
+	for _, succ := range node.successors {
+		if len(replicas) == node.config.ReplicationFactor {
+			break
+		}
+
+		if succ.Addr == node.Addr || containsAddr(replicas, succ.Addr) {
+			continue
+		}
+
+		replicas = append(replicas, succ)
+	}
+
+	return replicas
+}
+
+// replicate copies a key/value pair to the nodes in the replica set. Failures
+// are tolerated since the replica set gets repaired by stabilize.
+func (node *Node) replicate(ctx context.Context, key string, entry Entry) {
+	node.replicateTo(ctx, node.replicaSet(), map[string]Entry{key: entry})
+}
+
+// replicateTo copies key/value pairs to remote nodes.
+func (node *Node) replicateTo(
+	ctx context.Context, remoteNodes []*gmajpb.Node, entries map[string]Entry,
+) {
+	for _, remoteNode := range remoteNodes {
+		for key, entry := range entries {
+			if err := node.putReplicaRPC(ctx, remoteNode, key, entry); err != nil {
+				node.config.Log.Printf("replicating key %q to %v failed: %v",
+					key, IDToString(remoteNode.Id), err,
+				)
+				break
+			}
+		}
+	}
+}
+
+// reReplicate copies all of this node's keys to the members of the replica set
+// that were not in it before (i.e. prev).
+func (node *Node) reReplicate(ctx context.Context, prev []*gmajpb.Node) {
+	var added []*gmajpb.Node
+	for _, n := range node.replicaSet() {
+		if !containsNode(prev, n) {
+			added = append(added, n)
+		}
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	entries := make(map[string]Entry)
+	err := node.datastore.Range(node.id, node.id, func(key string, entry Entry) bool {
+		entries[key] = entry
+		return true
+	})
+	if err != nil {
+		node.config.Log.Printf("re-replicating failed: %v", err)
+		return
+	}
+
+	node.replicateTo(ctx, added, entries)
+}
+
+// promoteReplicas takes ownership of the copies of keys that now fall between
+// (fromID : node.Id], which happens when a predecessor fails.
+func (node *Node) promoteReplicas(ctx context.Context, fromID []byte) error {
+	promoted, err := node.takeReplicas(newID(fromID))
+	if err != nil {
+		return err
+	}
+
+	if len(promoted) > 0 {
+		node.replicateTo(ctx, node.replicaSet(), promoted)
+	}
+
+	return nil
+}
+
+// takeReplicas moves the copies of keys between (from : node.Id] to the
+// datastore, and returns the ones that were newer than what the datastore
+// had. Copies of keys locked by prepared transactions are left where they are,
+// so that the keys do not change before the transactions end.
+func (node *Node) takeReplicas(from ID) (map[string]Entry, error) {
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	replicas := make(map[string]Entry)
+	err := node.replicas.Range(from, node.id, func(key string, entry Entry) bool {
+		if node.checkLock(key) == nil {
+			replicas[key] = entry
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	promoted := make(map[string]Entry)
+	for key, entry := range replicas {
+		id, err := node.config.keyID(key)
+		if err != nil {
+			return nil, err
+		}
+
+		old, exists, err := node.datastore.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists || entry.newer(old) {
+			if err := node.datastore.Put(id, key, entry); err != nil {
+				return nil, err
+			}
+			promoted[key] = entry
+		}
+
+		if err := node.replicas.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return promoted, nil
+}
+
+func (node *Node) getReplica(key string) (Entry, error) {
+	if node.replicas == nil {
+		return Entry{}, errNoDatastore
+	}
+
+	node.dsMtx.RLock()
+	defer node.dsMtx.RUnlock()
+
+	entry, ok, err := node.replicas.Get(key)
+	if !ok && err == nil {
+		// We may have taken ownership of the key already.
+		entry, ok, err = node.datastore.Get(key)
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	if !ok {
+		return Entry{}, errors.New("key does not exist")
+	}
+	if entry.Deleted {
+		return Entry{}, errDeleted
+	}
+	if entry.expired(node.clock.Now()) {
+		return Entry{}, errExpired
+	}
+
+	return entry, nil
+}
+
+func (node *Node) putReplica(keyVal *gmajpb.KeyVal) error {
+	if node.replicas == nil {
+		return errNoDatastore
+	}
+
+	id, err := node.config.keyID(keyVal.Key)
+	if err != nil {
+		return err
+	}
+
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	// A locked key is one the node owns, and whose copy would be read as its
+	// latest entry if it were newer, so the copy waits for the transaction.
+	if err := node.checkLock(keyVal.Key); err != nil {
+		return err
+	}
+
+	// Copies can arrive out of order, e.g. from anti-entropy and the owner at
+	// once, so older ones are ignored.
+	entry := keyValEntry(keyVal, node.clock.Now())
+	old, exists, err := node.replicas.Get(keyVal.Key)
+	if err != nil || (exists && !entry.newer(old)) {
+		return err
+	}
+
+	return node.replicas.Put(id, keyVal.Key, entry)
+}
+
+// getFromReplicas looks for a key in the successors of the node that owns it.
+// This is useful when the owner is unreachable. Like in replicaSet, successors
+// on the same host as the owner or as a replica already tried are skipped.
+func (node *Node) getFromReplicas(
+	ctx context.Context, owner *gmajpb.Node, key string,
+) (Entry, error) {
+	prev := owner
+	var tried []*gmajpb.Node
+	for len(tried) < node.config.ReplicationFactor {
+		next := node.config.fingerMath(newID(prev.Id), 0)
+		replica, err := node.findSuccessor(ctx, next)
+		if err != nil {
+			return Entry{}, err
+		}
+
+		if idsEqual(replica.Id, owner.Id) || idsEqual(replica.Id, prev.Id) {
+			break
+		}
+		prev = replica
+
+		if replica.Addr == owner.Addr || containsAddr(tried, replica.Addr) {
+			continue
+		}
+		tried = append(tried, replica)
+
+		if entry, err := node.getReplicaRPC(ctx, replica, key); err == nil || isDeleted(err) {
+			return entry, err
+		}
+	}
+
+	return Entry{}, errors.New("key does not exist in any replica")
+}
+
+// containsAddr returns if any of nodes has the address addr.
+func containsAddr(nodes []*gmajpb.Node, addr string) bool {
+	for _, n := range nodes {
+		if n.Addr == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsNode returns if n is in nodes.
+func containsNode(nodes []*gmajpb.Node, n *gmajpb.Node) bool {
+	for _, other := range nodes {
+		if idsEqual(other.Id, n.Id) {
+			return true
+		}
+	}
+
+	return false
+}
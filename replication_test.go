@@ -0,0 +1,199 @@
+package gmaj
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReplication(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	nodes := []*Node{node1, node2, node3}
+
+	<-time.After(testTimeout)
+
+	key, want := "replicated", []byte("value")
+	if err := Put(node1, key, want); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	var owner *Node
+	for _, node := range nodes {
+		if _, err := node.getKey(key); err == nil {
+			owner = node
+			continue
+		}
+
+		if got, err := node.getReplica(key); err != nil {
+			t.Fatalf("Unexpected error getting replica from %v: %v", node, err)
+		} else if !reflect.DeepEqual(got.Val, want) {
+			t.Fatalf("Unexpected replica value. Expected %q got %q", want, got.Val)
+		}
+	}
+	if owner == nil {
+		t.Fatal("No node owns the key")
+	}
+
+	crashNode(owner)
+
+	var live *Node
+	for _, node := range nodes {
+		if node != owner {
+			live = node
+			break
+		}
+	}
+
+	// The key is available from the replicas right away.
+	if got, err := Get(live, key); err != nil {
+		t.Fatalf("Unexpected error getting value after owner failed: %v", err)
+	} else if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected value. Expected %q got %q", want, got)
+	}
+
+	<-time.After(testTimeout)
+
+	// And one of the replicas has taken ownership of it.
+	var owned bool
+	for _, node := range nodes {
+		if node == owner {
+			continue
+		}
+		if _, err := node.getKey(key); err == nil {
+			owned = true
+		}
+	}
+	if !owned {
+		t.Fatal("No live node took ownership of the key")
+	}
+}
+
+func TestReplicationAfterJoin(t *testing.T) {
+	t.Parallel()
+
+	node1 := createDefinedNode(t, nil, []byte{0})
+	if err := Put(node1, "a", []byte("1")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	node2 := createDefinedNode(t, node1.Node, []byte{0x80})
+
+	<-time.After(testTimeout)
+
+	// Whichever node owns the key, the other one should have a copy.
+	if _, err := node1.getReplica("a"); err != nil {
+		t.Fatalf("Unexpected error getting replica from node1: %v", err)
+	}
+	if _, err := node2.getReplica("a"); err != nil {
+		t.Fatalf("Unexpected error getting replica from node2: %v", err)
+	}
+}
+
+func TestDeleteReplicated(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	nodes := []*Node{node1, node2, node3}
+
+	<-time.After(testTimeout)
+
+	key := "deleted"
+	if err := Put(node1, key, []byte("value")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if err := Delete(node2, key); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+
+	var owner *Node
+	for _, node := range nodes {
+		if _, err := node.getKey(key); isDeleted(err) {
+			owner = node
+			continue
+		}
+
+		if _, err := node.getReplica(key); !isDeleted(err) {
+			t.Fatalf("Expected replica on %v to be deleted, got %v", node, err)
+		}
+	}
+	if owner == nil {
+		t.Fatal("No node owns the key")
+	}
+
+	crashNode(owner)
+
+	var live *Node
+	for _, node := range nodes {
+		if node != owner {
+			live = node
+			break
+		}
+	}
+
+	// The replica that takes over the key does not bring it back.
+	if _, err := Get(live, key); !isDeleted(err) {
+		t.Fatalf("Expected key to be deleted after owner failed, got %v", err)
+	}
+
+	<-time.After(testTimeout)
+
+	if _, err := Get(live, key); !isDeleted(err) {
+		t.Fatalf("Expected key to stay deleted, got %v", err)
+	}
+}
+
+func TestReplicationVirtualNodes(t *testing.T) {
+	t.Parallel()
+
+	// The copies of a virtual node's keys must be on other hosts, so that they
+	// survive the failure of its host.
+	network := NewMemNetwork()
+	nodes1, err := NewVirtualNodes(nil, 4, WithTransport(network.Transport()))
+	if err != nil {
+		t.Fatalf("Unable to create virtual nodes, received error:%v", err)
+	}
+	nodes2, err := NewVirtualNodes(nodes1[0].Node, 4, WithTransport(network.Transport()))
+	if err != nil {
+		t.Fatalf("Unable to create virtual nodes, received error:%v", err)
+	}
+	defer func() {
+		for _, node := range nodes2 {
+			node.Shutdown()
+		}
+	}()
+
+	<-time.After(2 * testTimeout)
+
+	for _, node := range append(nodes1, nodes2...) {
+		for _, replica := range node.replicaSet() {
+			if replica.Addr == node.Addr {
+				t.Fatalf("Expected no replicas of %v on its own host, got %v", node, replica)
+			}
+		}
+	}
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("vreplica%d", i)
+		if err := Put(nodes2[0], keys[i], []byte(keys[i])); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+
+	for _, node := range nodes1 {
+		crashNode(node)
+	}
+
+	<-time.After(testTimeout)
+
+	for _, key := range keys {
+		if got, err := Get(nodes2[0], key); err != nil {
+			t.Fatalf("Unexpected error getting %q: %v", key, err)
+		} else if string(got) != key {
+			t.Fatalf("Unexpected value. Expected %q got %q", key, got)
+		}
+	}
+}
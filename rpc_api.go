@@ -2,6 +2,7 @@ package gmaj
 
 import (
 	"errors"
+	"io"
 	"time"
 
 	"github.com/r-medina/gmaj/gmajpb"
@@ -16,177 +17,657 @@ import (
 //
 
 // getPredecessorRPC gets the predecessor ID of a remote node.
-func (node *Node) getPredecessorRPC(remoteNode *gmajpb.Node) (*gmajpb.Node, error) {
-	client, err := node.getChordClient(remoteNode)
+func (node *Node) getPredecessorRPC(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) (*gmajpb.Node, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetPredecessor(context.Background(), mt)
+	return client.GetPredecessor(ctx, mt)
 }
 
 // getSuccessorRPC the successor ID of a remote node.
-func (node *Node) getSuccessorRPC(remoteNode *gmajpb.Node) (*gmajpb.Node, error) {
-	client, err := node.getChordClient(remoteNode)
+func (node *Node) getSuccessorRPC(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) (*gmajpb.Node, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetSuccessor(ctx, mt)
+}
+
+// getSuccessorListRPC gets the successor list of a remote node.
+func (node *Node) getSuccessorListRPC(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) ([]*gmajpb.Node, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := client.GetSuccessorList(ctx, mt)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.GetSuccessor(context.Background(), mt)
+	return nodes.Nodes, nil
+}
+
+// pingRPC checks that a remote node is alive.
+func (node *Node) pingRPC(ctx context.Context, remoteNode *gmajpb.Node) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Ping(ctx, mt)
+	return err
 }
 
 // setPredecessorRPC noties a remote node that we believe we are its predecessor.
-func (node *Node) setPredecessorRPC(remoteNode, newPred *gmajpb.Node) error {
-	client, err := node.getChordClient(remoteNode)
+func (node *Node) setPredecessorRPC(
+	ctx context.Context, remoteNode, newPred *gmajpb.Node,
+) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.SetPredecessor(context.Background(), newPred)
+	_, err = client.SetPredecessor(ctx, newPred)
 	return err
 }
 
 // setSuccessorRPC sets the successor ID of a remote node.
-func (node *Node) setSuccessorRPC(remoteNode, newSucc *gmajpb.Node) error {
-	client, err := node.getChordClient(remoteNode)
+func (node *Node) setSuccessorRPC(
+	ctx context.Context, remoteNode, newSucc *gmajpb.Node,
+) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.SetSuccessor(context.Background(), newSucc)
+	_, err = client.SetSuccessor(ctx, newSucc)
 	return err
 }
 
 // notifyRPC notifies a remote node that pred is its predecessor.
-func (node *Node) notifyRPC(remoteNode, pred *gmajpb.Node) error {
-	client, err := node.getChordClient(remoteNode)
+func (node *Node) notifyRPC(ctx context.Context, remoteNode, pred *gmajpb.Node) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.Notify(context.Background(), pred)
+	_, err = client.Notify(ctx, pred)
 	return err
 }
 
 // closestPrecedingFingerRPC finds the closest preceding finger from a remote
 // node for an ID.
 func (node *Node) closestPrecedingFingerRPC(
-	remoteNode *gmajpb.Node, id []byte,
-) (*gmajpb.Node, error) {
-	client, err := node.getChordClient(remoteNode)
+	ctx context.Context, remoteNode *gmajpb.Node, id ID,
+) (*gmajpb.Finger, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.ClosestPrecedingFinger(context.Background(), &gmajpb.ID{Id: id})
+	return client.ClosestPrecedingFinger(ctx, &gmajpb.ID{Id: node.config.idBytes(id)})
 }
 
 // findSuccessorRPC finds the successor node of a given ID in the entire ring.
 func (node *Node) findSuccessorRPC(
-	remoteNode *gmajpb.Node, id []byte,
+	ctx context.Context, remoteNode *gmajpb.Node, id ID,
 ) (*gmajpb.Node, error) {
-	client, err := node.getChordClient(remoteNode)
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.FindSuccessor(ctx, &gmajpb.ID{Id: node.config.idBytes(id)})
+}
+
+// findSuccessorRecursiveRPC passes a recursive lookup on to a remote node.
+func (node *Node) findSuccessorRecursiveRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.LookupRequest,
+) (*gmajpb.LookupResponse, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return nil, err
 	}
 
-	return client.FindSuccessor(context.Background(), &gmajpb.ID{Id: id})
+	return client.FindSuccessorRecursive(ctx, req)
 }
 
 //
 // Datastore RPC API
 //
 
-// getKeyRPC gets a value from a remote node's datastore for a given key.
-func (node *Node) getKeyRPC(remoteNode *gmajpb.Node, key string) ([]byte, error) {
-	client, err := node.getChordClient(remoteNode)
+// getKeyRPC gets a value from a remote node's datastore for a given key. The
+// value is streamed, since it can be too large for one message. The remote
+// node refuses if it does not own the key.
+func (node *Node) getKeyRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, key string,
+) (Entry, error) {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
-		return nil, err
+		return Entry{}, err
+	}
+
+	stream, err := client.GetKeyStream(ctx, &gmajpb.Key{Key: key, CheckOwner: true})
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return recvEntry(func() (*gmajpb.Val, error) {
+		progress()
+		return stream.Recv()
+	}, node.config.MaxValueSize)
+}
+
+// putKeyValRPC puts a key/value into a datastore on a remote node, and returns
+// the version the key has there. If keyVal.CheckOwner is set, the remote node
+// refuses if it does not own the key.
+func (node *Node) putKeyValRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, keyVal *gmajpb.KeyVal,
+) (uint64, error) {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return 0, err
 	}
 
-	val, err := client.GetKey(context.Background(), &gmajpb.Key{Key: key})
+	var version *gmajpb.Version
+	if len(keyVal.Val) > ChunkSize {
+		version, err = putKeyValStream(ctx, progress, client, keyVal)
+	} else {
+		version, err = client.PutKeyVal(ctx, keyVal)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return version.Version, nil
+}
+
+// putKeyValStream puts a key/value with a large value over a stream, calling
+// progress as each chunk is sent.
+func putKeyValStream(
+	ctx context.Context, progress func(), client chord.ChordClient, keyVal *gmajpb.KeyVal,
+) (*gmajpb.Version, error) {
+	stream, err := client.PutKeyValStream(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return val.Val, nil
+	// If sending fails, the stream has ended and CloseAndRecv gives the
+	// reason.
+	err = sendKeyVal(func(kv *gmajpb.KeyVal) error {
+		progress()
+		return stream.Send(kv)
+	}, keyVal)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	progress()
+	return stream.CloseAndRecv()
+}
+
+// getKeyStreamRPC gets a value from a remote node's datastore for a given key
+// over a stream, and passes it on to send in chunks. It returns whether it
+// sent anything. The remote node refuses if it does not own the key.
+func (node *Node) getKeyStreamRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, key string,
+	send func(*gmajpb.GetResponse) error,
+) (bool, error) {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return false, err
+	}
+
+	stream, err := client.GetKeyStream(ctx, &gmajpb.Key{Key: key, CheckOwner: true})
+	if err != nil {
+		return false, err
+	}
+
+	// The value is passed on as it arrives, so it is only limited in size
+	// by the client.
+	for sent := false; ; sent = true {
+		progress()
+		val, err := stream.Recv()
+		if err == io.EOF {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+
+		if err := send(&gmajpb.GetResponse{Value: val.Val, Version: val.Version}); err != nil {
+			return true, err
+		}
+	}
 }
 
-// putKeyValRPC puts a key/value into a datastore on a remote node.
-func (node *Node) putKeyValRPC(remoteNode *gmajpb.Node, key string, val []byte) error {
-	client, err := node.getChordClient(remoteNode)
+// deleteKeyRPC deletes a key from a datastore on a remote node. The remote node
+// refuses if it does not own the key.
+func (node *Node) deleteKeyRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, key string,
+) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.PutKeyVal(context.Background(), &gmajpb.KeyVal{Key: key, Val: val})
+	_, err = client.DeleteKey(ctx, &gmajpb.Key{Key: key, CheckOwner: true})
 	return err
 }
 
+// getReplicaRPC gets a value from a remote node's datastore or copies of its
+// predecessors' keys. Like in getKeyRPC, the value is streamed.
+func (node *Node) getReplicaRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, key string,
+) (Entry, error) {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	stream, err := client.GetReplicaStream(ctx, &gmajpb.Key{Key: key})
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return recvEntry(func() (*gmajpb.Val, error) {
+		progress()
+		return stream.Recv()
+	}, node.config.MaxValueSize)
+}
+
+// putReplicaRPC puts a copy of a key/value on a remote node.
+func (node *Node) putReplicaRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, key string, entry Entry,
+) error {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return err
+	}
+
+	keyVal := entryKeyVal(key, entry, node.clock.Now())
+	if len(keyVal.Val) <= ChunkSize {
+		_, err = client.PutReplica(ctx, keyVal)
+		return err
+	}
+
+	stream, err := client.PutReplicaStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = sendKeyVal(func(kv *gmajpb.KeyVal) error {
+		progress()
+		return stream.Send(kv)
+	}, keyVal)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	progress()
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// getMerkleTreeRPC gets the Merkle tree of the keys a remote node has between
+// (fromID : toID].
+func (node *Node) getMerkleTreeRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, fromID, toID []byte,
+) (merkleTree, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := client.GetMerkleTree(
+		ctx, &gmajpb.KeyRange{FromId: fromID, ToId: toID},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return merkleTree(tree.Hashes), nil
+}
+
+// getBucketsRPC gets the key/values a remote node has in some buckets of the
+// Merkle tree for the range between (fromID : toID]. They are streamed, since
+// together they can be too large for one message.
+func (node *Node) getBucketsRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, fromID, toID []byte, buckets []uint32,
+) (map[string]Entry, error) {
+	ctx, progress, cancel := node.chunkContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := client.GetBucketsStream(ctx, &gmajpb.BucketsReq{
+		Range:   &gmajpb.KeyRange{FromId: fromID, ToId: toID},
+		Buckets: buckets,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := node.clock.Now()
+	entries := make(map[string]Entry)
+	recv := func() (*gmajpb.KeyVal, error) {
+		progress()
+		return stream.Recv()
+	}
+	err = recvKeyVals(recv, node.config.MaxValueSize, func(kv *gmajpb.KeyVal) {
+		entries[kv.Key] = keyValEntry(kv, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 // transferKeysRPC informs a successor node that we should now take care of IDs
 // between (node.Id : predId]. This should trigger the successor node to
 // transfer the relevant keys back to node
 func (node *Node) transferKeysRPC(
-	remoteNode *gmajpb.Node, fromID []byte, toNode *gmajpb.Node,
+	ctx context.Context, remoteNode *gmajpb.Node, fromID []byte, toNode *gmajpb.Node,
 ) error {
-	client, err := node.getChordClient(remoteNode)
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
 	if err != nil {
 		return err
 	}
 
 	_, err = client.TransferKeys(
-		context.Background(), &gmajpb.TransferKeysReq{FromId: fromID, ToNode: toNode},
+		ctx, &gmajpb.TransferKeysReq{FromId: fromID, ToNode: toNode},
 	)
 	return err
 }
 
-//
-// RPC connection map cache
-//
+// listKeysRPC gets the keys a remote node owns in part of a listing.
+func (node *Node) listKeysRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.ListKeysReq,
+) ([]*gmajpb.KeyVal, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
 
-type clientConn struct {
-	client chord.ChordClient
-	conn   *grpc.ClientConn
+	kvs, err := client.ListKeys(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs.KeyVals, nil
 }
 
-// getChordClient is a helper function to make a call to a remote node.
-func (node *Node) getChordClient(
-	remoteNode *gmajpb.Node,
-) (chord.ChordClient, error) {
-	// Dial the server if we don't already have a connection to it
-	addr := remoteNode.Addr
-	node.connMtx.RLock()
-	cc, ok := node.clientConns[addr]
-	node.connMtx.RUnlock()
-	if ok {
-		return cc.client, nil
+// getKeysRPC gets the values of keys a remote node owns, with a result for
+// each key.
+func (node *Node) getKeysRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.MultiGetRequest,
+) ([]*gmajpb.GetResult, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetKeys(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(req.Keys) {
+		return nil, errBatchResults
+	}
+
+	return resp.Results, nil
+}
+
+// putKeyValsRPC puts key/values into a datastore on a remote node, with a
+// result for each key/value.
+func (node *Node) putKeyValsRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.KeyVals,
+) ([]*gmajpb.PutResult, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
 	}
 
-	conn, err := Dial(addr, node.opts.dialOpts...)
+	resp, err := client.PutKeyVals(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.Results) != len(req.KeyVals) {
+		return nil, errBatchResults
+	}
+
+	return resp.Results, nil
+}
+
+// watchKeysRPC watches keys on a remote node, passing the changes to send
+// until the watch ends. Unlike other RPCs, it does not time out once the
+// connection is made.
+func (node *Node) watchKeysRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.WatchKeysReq,
+	send func(*gmajpb.WatchEvent) error,
+) error {
+	dialCtx, cancel := node.rpcContext(ctx, remoteNode)
+	client, err := node.getChordClient(dialCtx, remoteNode)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel = context.WithCancel(targetContext(ctx, remoteNode))
+	defer cancel()
+
+	stream, err := client.WatchKeys(ctx, req)
+	if err != nil {
+		return err
+	}
 
-	client := chord.NewChordClient(conn)
-	cc = &clientConn{client, conn}
-	node.connMtx.Lock()
-	if node.clientConns == nil {
-		node.connMtx.Unlock()
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+}
+
+// prepareRPC asks a remote node to prepare its part of a transaction.
+func (node *Node) prepareRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, req *gmajpb.PrepareReq,
+) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Prepare(ctx, req)
+	return err
+}
+
+// commitRPC tells a remote node to commit a transaction it prepared, and
+// returns the versions of its keys.
+func (node *Node) commitRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, txnID string,
+) ([]uint64, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := client.Commit(ctx, &gmajpb.TxnID{TxnId: txnID})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions.Versions, nil
+}
+
+// abortRPC tells a remote node to abort a transaction.
+func (node *Node) abortRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, txnID string,
+) error {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Abort(ctx, &gmajpb.TxnID{TxnId: txnID})
+	return err
+}
+
+// txnStatusRPC asks the coordinator of a transaction for its outcome.
+func (node *Node) txnStatusRPC(
+	ctx context.Context, remoteNode *gmajpb.Node, txnID string,
+) (gmajpb.TxnStatus_Outcome, error) {
+	ctx, cancel := node.rpcContext(ctx, remoteNode)
+	defer cancel()
+
+	client, err := node.getChordClient(ctx, remoteNode)
+	if err != nil {
+		return 0, err
+	}
+
+	status, err := client.GetTxnStatus(ctx, &gmajpb.TxnID{TxnId: txnID})
+	if err != nil {
+		return 0, err
+	}
+
+	return status.Outcome, nil
+}
+
+//
+// RPC connections
+//
+
+// rpcContext returns the context for an RPC to remoteNode. It is cancelled
+// along with ctx, or once the connection timeout in the configuration passes.
+func (node *Node) rpcContext(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) (context.Context, context.CancelFunc) {
+	ctx = targetContext(ctx, remoteNode)
+	if node.config.ConnectionTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, node.config.ConnectionTimeout)
+}
+
+// getChordClient is a helper function to make a call to a remote node.
+func (node *Node) getChordClient(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) (chord.ChordClient, error) {
+	if node.host == nil {
 		return nil, errors.New("must instantiate node before using")
 	}
-	node.clientConns[addr] = cc
-	node.connMtx.Unlock()
 
-	return client, nil
+	return node.host.transport.Dial(ctx, remoteNode.Addr)
 }
 
 // Dial wraps grpc's dial function with settings that facilitate the
 // functionality of gmaj.
 func Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
-	return grpc.Dial(addr, append(append(
-		config.DialOptions,
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return dial(ctx, &config.nodeConfig, addr, opts...)
+}
+
+// dial is like Dial, but uses the dial options in cfg and gives up once ctx is
+// done.
+func dial(
+	ctx context.Context, cfg *nodeConfig, addr string, opts ...grpc.DialOption,
+) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, addr, append(append(
+		append([]grpc.DialOption{}, cfg.DialOptions...),
 		grpc.WithBlock(),
-		grpc.WithTimeout(5*time.Second),
 		grpc.FailOnNonTempDialError(true)),
 		opts...,
 	)...)
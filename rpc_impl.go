@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/r-medina/gmaj/gmajpb"
+	"github.com/r-medina/gmaj/internal/chord"
 
 	"golang.org/x/net/context"
 )
@@ -39,6 +40,21 @@ func (node *Node) GetSuccessor(context.Context, *gmajpb.MT) (*gmajpb.Node, error
 	return succ, nil
 }
 
+// GetSuccessorList gets the successor list on the node.
+func (node *Node) GetSuccessorList(context.Context, *gmajpb.MT) (*gmajpb.Nodes, error) {
+	node.succMtx.RLock()
+	succList := make([]*gmajpb.Node, len(node.successors))
+	copy(succList, node.successors)
+	node.succMtx.RUnlock()
+
+	return &gmajpb.Nodes{Nodes: succList}, nil
+}
+
+// Ping lets other nodes check that the node is alive.
+func (node *Node) Ping(context.Context, *gmajpb.MT) (*gmajpb.MT, error) {
+	return mt, nil
+}
+
 // SetPredecessor sets the predecessor on the node.
 func (node *Node) SetPredecessor(
 	ctx context.Context, pred *gmajpb.Node,
@@ -54,9 +70,7 @@ func (node *Node) SetPredecessor(
 func (node *Node) SetSuccessor(
 	ctx context.Context, succ *gmajpb.Node,
 ) (*gmajpb.MT, error) {
-	node.succMtx.Lock()
-	node.successor = succ
-	node.succMtx.Unlock()
+	node.setSuccessor(succ)
 
 	return mt, nil
 }
@@ -65,7 +79,7 @@ func (node *Node) SetSuccessor(
 func (node *Node) Notify(
 	ctx context.Context, remoteNode *gmajpb.Node,
 ) (*gmajpb.MT, error) {
-	node.notify(remoteNode)
+	node.notify(ctx, remoteNode)
 
 	// If node.Predecessor is nil at this point, we were trying to notify
 	// ourselves. Otherwise, to succeed, we must check that the successor
@@ -83,20 +97,20 @@ func (node *Node) Notify(
 // table based on the id.
 func (node *Node) ClosestPrecedingFinger(
 	ctx context.Context, id *gmajpb.ID,
-) (*gmajpb.Node, error) {
-	remoteNode := node.closestPrecedingFinger(id.Id)
-	if remoteNode == nil {
+) (*gmajpb.Finger, error) {
+	finger := node.closestPrecedingFinger(newID(id.Id))
+	if finger == nil {
 		return nil, errors.New("gmaj: no closest preceding finger")
 	}
 
-	return remoteNode, nil
+	return finger, nil
 }
 
 // FindSuccessor finds the successor, error if nil.
 func (node *Node) FindSuccessor(
 	ctx context.Context, id *gmajpb.ID,
 ) (*gmajpb.Node, error) {
-	succ, err := node.findSuccessor(id.Id)
+	succ, err := node.findSuccessor(ctx, newID(id.Id))
 	if err != nil {
 		return emptyRemote, err
 	}
@@ -108,33 +122,268 @@ func (node *Node) FindSuccessor(
 	return succ, nil
 }
 
+// FindSuccessorRecursive handles a step of a recursive lookup.
+func (node *Node) FindSuccessorRecursive(
+	ctx context.Context, req *gmajpb.LookupRequest,
+) (*gmajpb.LookupResponse, error) {
+	return node.findSuccessorRecursive(ctx, req)
+}
+
 // GetKey returns the value of the key requested at the node.
 func (node *Node) GetKey(ctx context.Context, key *gmajpb.Key) (*gmajpb.Val, error) {
-	val, err := node.getKey(key.Key)
+	if key.CheckOwner {
+		if err := node.checkOwner(key.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := node.getKey(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.Val{Val: entry.Val, Version: entry.Version}, nil
+}
+
+// PutKeyVal stores a key value pair on the node, and returns the version the
+// key has.
+func (node *Node) PutKeyVal(ctx context.Context, kv *gmajpb.KeyVal) (*gmajpb.Version, error) {
+	if kv.CheckOwner {
+		if err := node.checkOwner(kv.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	version, err := node.putKeyVal(ctx, kv)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.Version{Version: version}, nil
+}
+
+// PutKeyValStream is PutKeyVal for values that are sent in chunks.
+func (node *Node) PutKeyValStream(stream chord.Chord_PutKeyValStreamServer) error {
+	kv, err := recvKeyVal(stream.Recv, node.config.MaxValueSize)
+	if err != nil {
+		return err
+	}
+
+	version, err := node.PutKeyVal(stream.Context(), kv)
+	if err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(version)
+}
+
+// GetKeyStream is GetKey for values that are sent in chunks.
+func (node *Node) GetKeyStream(key *gmajpb.Key, stream chord.Chord_GetKeyStreamServer) error {
+	val, err := node.GetKey(stream.Context(), key)
 	if err != nil {
+		return err
+	}
+
+	return sendEntry(stream.Send, Entry{Val: val.Val, Version: val.Version})
+}
+
+// DeleteKey deletes a key from the node.
+func (node *Node) DeleteKey(ctx context.Context, key *gmajpb.Key) (*gmajpb.MT, error) {
+	if key.CheckOwner {
+		if err := node.checkOwner(key.Key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := node.deleteKey(ctx, key.Key); err != nil {
 		return nil, err
 	}
 
-	return &gmajpb.Val{Val: val}, nil
+	return mt, nil
 }
 
-// PutKeyVal stores a key value pair on the node.
-func (node *Node) PutKeyVal(ctx context.Context, kv *gmajpb.KeyVal) (*gmajpb.MT, error) {
-	if err := node.putKeyVal(kv); err != nil {
+// GetReplica returns the value of the key requested at the node, looking in
+// the copies it keeps for its predecessors as well.
+func (node *Node) GetReplica(ctx context.Context, key *gmajpb.Key) (*gmajpb.Val, error) {
+	entry, err := node.getReplica(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.Val{Val: entry.Val, Version: entry.Version}, nil
+}
+
+// GetReplicaStream is GetReplica for values that are sent in chunks.
+func (node *Node) GetReplicaStream(
+	key *gmajpb.Key, stream chord.Chord_GetReplicaStreamServer,
+) error {
+	entry, err := node.getReplica(key.Key)
+	if err != nil {
+		return err
+	}
+
+	return sendEntry(stream.Send, entry)
+}
+
+// PutReplica stores a copy of a key value pair owned by a predecessor on the
+// node.
+func (node *Node) PutReplica(ctx context.Context, kv *gmajpb.KeyVal) (*gmajpb.MT, error) {
+	if err := node.putReplica(kv); err != nil {
 		return nil, err
 	}
 
 	return mt, nil
 }
 
+// PutReplicaStream is PutReplica for values that are sent in chunks.
+func (node *Node) PutReplicaStream(stream chord.Chord_PutReplicaStreamServer) error {
+	kv, err := recvKeyVal(stream.Recv, node.config.MaxValueSize)
+	if err != nil {
+		return err
+	}
+
+	if _, err := node.PutReplica(stream.Context(), kv); err != nil {
+		return err
+	}
+
+	return stream.SendAndClose(mt)
+}
+
+// GetMerkleTree returns the Merkle tree of the keys the node has in a range.
+func (node *Node) GetMerkleTree(
+	ctx context.Context, r *gmajpb.KeyRange,
+) (*gmajpb.MerkleTree, error) {
+	entries, err := node.keysInRange(r.FromId, r.ToId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := newMerkleTree(node.config, newID(r.FromId), newID(r.ToId), entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.MerkleTree{Hashes: tree}, nil
+}
+
+// GetBuckets returns the key value pairs the node has in buckets of the Merkle
+// tree for a range.
+func (node *Node) GetBuckets(
+	ctx context.Context, req *gmajpb.BucketsReq,
+) (*gmajpb.KeyVals, error) {
+	if req.Range == nil {
+		return nil, errors.New("gmaj: missing key range")
+	}
+
+	entries, err := node.keysInRange(req.Range.FromId, req.Range.ToId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err = inBuckets(
+		node.config, newID(req.Range.FromId), newID(req.Range.ToId), entries, req.Buckets,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	now := node.clock.Now()
+	kvs := &gmajpb.KeyVals{KeyVals: make([]*gmajpb.KeyVal, 0, len(entries))}
+	for key, entry := range entries {
+		kvs.KeyVals = append(kvs.KeyVals, entryKeyVal(key, entry, now))
+	}
+
+	return kvs, nil
+}
+
+// GetBucketsStream is GetBuckets for values that are sent in chunks.
+func (node *Node) GetBucketsStream(
+	req *gmajpb.BucketsReq, stream chord.Chord_GetBucketsStreamServer,
+) error {
+	kvs, err := node.GetBuckets(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range kvs.KeyVals {
+		if err := sendKeyVal(stream.Send, kv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // TransferKeys transfers the appropriate keys on this node
 // to the remote node specified in the request.
 func (node *Node) TransferKeys(
 	ctx context.Context, tmsg *gmajpb.TransferKeysReq,
 ) (*gmajpb.MT, error) {
-	if err := node.transferKeys(tmsg.FromId, tmsg.ToNode); err != nil {
+	if err := node.transferKeys(ctx, tmsg.FromId, tmsg.ToNode); err != nil {
 		return nil, err
 	}
 
 	return mt, nil
 }
+
+// ListKeys returns the keys the node owns in part of a listing.
+func (node *Node) ListKeys(ctx context.Context, req *gmajpb.ListKeysReq) (*gmajpb.KeyVals, error) {
+	return node.listKeys(req)
+}
+
+// GetKeys returns the values of keys the node owns.
+func (node *Node) GetKeys(
+	ctx context.Context, req *gmajpb.MultiGetRequest,
+) (*gmajpb.MultiGetResponse, error) {
+	return &gmajpb.MultiGetResponse{Results: node.getKeys(req.Keys)}, nil
+}
+
+// PutKeyVals stores key value pairs on the node.
+func (node *Node) PutKeyVals(
+	ctx context.Context, kvs *gmajpb.KeyVals,
+) (*gmajpb.MultiPutResponse, error) {
+	return &gmajpb.MultiPutResponse{Results: node.putKeyVals(ctx, kvs.KeyVals)}, nil
+}
+
+// WatchKeys sends the changes to keys the node owns as they happen.
+func (node *Node) WatchKeys(req *gmajpb.WatchKeysReq, stream chord.Chord_WatchKeysServer) error {
+	return node.watchKeys(stream.Context(), req, stream.Send)
+}
+
+// Prepare prepares the node's part of a transaction.
+func (node *Node) Prepare(ctx context.Context, req *gmajpb.PrepareReq) (*gmajpb.MT, error) {
+	if err := node.prepare(req); err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// Commit commits a transaction the node prepared.
+func (node *Node) Commit(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.Versions, error) {
+	versions, err := node.commit(ctx, req.TxnId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.Versions{Versions: versions}, nil
+}
+
+// Abort aborts a transaction the node prepared.
+func (node *Node) Abort(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.MT, error) {
+	if err := node.abort(ctx, req.TxnId); err != nil {
+		return nil, err
+	}
+
+	return mt, nil
+}
+
+// GetTxnStatus returns the outcome of a transaction the node coordinates.
+func (node *Node) GetTxnStatus(ctx context.Context, req *gmajpb.TxnID) (*gmajpb.TxnStatus, error) {
+	outcome, err := node.txnStatus(req.TxnId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gmajpb.TxnStatus{Outcome: outcome}, nil
+}
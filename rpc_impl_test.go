@@ -1,8 +1,15 @@
 package gmaj
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestGetSuccessorIsYourself(t *testing.T) {
@@ -73,12 +80,12 @@ func TestNotifySimpleCorrect(t *testing.T) {
 	node3.predecessor = node2.Node
 	node3.predMtx.Unlock()
 
-	if err := node1.notifyRPC(node1.Node, node3.Node); err != nil {
+	if err := node1.notifyRPC(context.Background(), node1.Node, node3.Node); err != nil {
 		t.Fatalf("Unexpected error notifying node: %v", err)
 	}
 
 	// Tests that notify wraps around correctly.
-	if err := node3.notifyRPC(node3.Node, node2.Node); err != nil {
+	if err := node3.notifyRPC(context.Background(), node3.Node, node2.Node); err != nil {
 		t.Fatalf("Unexpected error notifying node: %v", err)
 	}
 }
@@ -101,15 +108,15 @@ func TestNotifySimpleIncorrect(t *testing.T) {
 	node3.predecessor = node2.Node
 	node3.predMtx.Unlock()
 
-	if err := node2.notifyRPC(node2.Node, node3.Node); err == nil {
+	if err := node2.notifyRPC(context.Background(), node2.Node, node3.Node); err == nil {
 		t.Fatalf("Unexpected success notifying node1")
 	}
 
-	if err := node3.notifyRPC(node3.Node, node1.Node); err == nil {
+	if err := node3.notifyRPC(context.Background(), node3.Node, node1.Node); err == nil {
 		t.Fatalf("Unexpected success notifying node2")
 	}
 
-	if err := node1.notifyRPC(node1.Node, node2.Node); err == nil {
+	if err := node1.notifyRPC(context.Background(), node1.Node, node2.Node); err == nil {
 		t.Fatalf("Unexpected success notifying node3")
 	}
 }
@@ -117,16 +124,22 @@ func TestNotifySimpleIncorrect(t *testing.T) {
 func TestFindSuccessorSimple(t *testing.T) {
 	t.Parallel()
 
-	node := createDefinedNode(t, nil, []byte{10})
-	assertSuccessorID(t, 5, node)
-	assertSuccessorID(t, 0, node)
-	assertSuccessorID(t, 10, node)
-	assertSuccessorID(t, 12, node)
-	assertSuccessorID(t, 240, node)
+	forEachLookupMode(t, func(t *testing.T, opt NodeOption) {
+		node := createDefinedNode(t, nil, []byte{10}, opt)
+		assertSuccessorID(t, 5, node)
+		assertSuccessorID(t, 0, node)
+		assertSuccessorID(t, 10, node)
+		assertSuccessorID(t, 12, node)
+		assertSuccessorID(t, 240, node)
+	})
 }
 
 func TestFindSuccessorMultipleNodes(t *testing.T) {
-	node1, node2, node3 := create3SuccessiveNodes(t)
+	forEachLookupMode(t, testFindSuccessorMultipleNodes)
+}
+
+func testFindSuccessorMultipleNodes(t *testing.T, opt NodeOption) {
+	node1, node2, node3 := create3SuccessiveNodes(t, opt)
 
 	<-time.After(testTimeout << 1)
 
@@ -148,7 +161,7 @@ func TestFindSuccessorMultipleNodes(t *testing.T) {
 	assertSuccessorID(t, 0xa9, node3)
 	assertSuccessorID(t, 0xbb, node2)
 
-	node4 := createDefinedNode(t, node3.Node, []byte{0xbb})
+	node4 := createDefinedNode(t, node3.Node, []byte{0xbb}, opt)
 
 	<-time.After(testTimeout)
 
@@ -200,7 +213,7 @@ func TestClosestPrecedingFingerComplicated(t *testing.T) {
 // Helper for GetSuccessor tests. Issues an RPC to check if node2 is a successor
 // of node1.
 func assertSuccessor(t *testing.T, node1, node2 *Node) {
-	if remoteNode, err := node1.getSuccessorRPC(node1.Node); err != nil {
+	if remoteNode, err := node1.getSuccessorRPC(context.Background(), node1.Node); err != nil {
 		t.Fatalf("Unexpected error:%v", err)
 	} else if remoteNode.Addr != node2.Addr {
 		t.Fatalf(
@@ -214,7 +227,7 @@ func assertSuccessor(t *testing.T, node1, node2 *Node) {
 // Helper for FindSuccessor tests. Issues an RPC to check that node is id's
 // successor.
 func assertSuccessorID(t *testing.T, id byte, node *Node) {
-	if remoteNode, err := node.findSuccessorRPC(node.Node, []byte{id}); err != nil {
+	if remoteNode, err := node.findSuccessorRPC(context.Background(), node.Node, newID([]byte{id})); err != nil {
 		t.Fatalf("Unexpected error:%v", err)
 	} else if remoteNode.Addr != node.Addr {
 		t.Fatalf("Unexpected successor. Expected %v got %v",
@@ -226,11 +239,11 @@ func assertSuccessorID(t *testing.T, id byte, node *Node) {
 // Helper for closest preceding finger. Asserts that closest is the closest
 // preceding finger to id according to node.
 func assertClosest(t *testing.T, node, closest *Node, id byte) {
-	remoteNode, err := node.closestPrecedingFingerRPC(node.Node, []byte{id})
+	finger, err := node.closestPrecedingFingerRPC(context.Background(), node.Node, newID([]byte{id}))
 	if err != nil {
 		t.Fatalf("Unexpected error while getting closest:%v", err)
-	} else if !idsEqual(remoteNode.Id, closest.Id) {
-		t.Fatalf("Expected %v, got %v", closest.Id, remoteNode.Id)
+	} else if !idsEqual(finger.Node.Id, closest.Id) {
+		t.Fatalf("Expected %v, got %v", closest.Id, finger.Node.Id)
 	}
 }
 
@@ -241,3 +254,172 @@ func assertCloseBombardment(t *testing.T, rangeStart, rangeEnd int, nodes []*Nod
 		}
 	}
 }
+
+func TestSuccessorList(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+
+	<-time.After(testTimeout)
+
+	assertSuccessorList(t, node1, node2, node3)
+	assertSuccessorList(t, node2, node3, node1)
+	assertSuccessorList(t, node3, node1, node2)
+}
+
+func TestSuccessorListFailover(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+
+	<-time.After(testTimeout)
+
+	crashNode(node2)
+
+	<-time.After(testTimeout)
+
+	assertSuccessor(t, node1, node3)
+	assertSuccessorList(t, node1, node3)
+}
+
+// Helper for successor list tests. Issues an RPC to check that node's
+// successor list matches succs.
+func assertSuccessorList(t *testing.T, node *Node, succs ...*Node) {
+	client, err := node.getChordClient(context.Background(), node.Node)
+	if err != nil {
+		t.Fatalf("Unexpected error:%v", err)
+	}
+
+	resp, err := client.GetSuccessorList(context.Background(), mt)
+	if err != nil {
+		t.Fatalf("Unexpected error:%v", err)
+	}
+
+	if len(resp.Nodes) != len(succs) {
+		t.Fatalf("Expected %d successors, got %v", len(succs), resp.Nodes)
+	}
+
+	for i, succ := range succs {
+		if got := resp.Nodes[i]; !idsEqual(got.Id, succ.Id) {
+			t.Fatalf("Unexpected successor %d. Expected %v got %v", i, succ.Node, got)
+		}
+	}
+}
+
+func TestCheckPredecessor(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+
+	<-time.After(testTimeout)
+
+	crashNode(node2)
+
+	<-time.After(testTimeout)
+
+	assertPredecessor(t, node3, node1)
+	assertPredecessor(t, node1, node3)
+}
+
+// Helper for predecessor tests. Issues an RPC to check if node2 is the
+// predecessor of node1.
+func assertPredecessor(t *testing.T, node1, node2 *Node) {
+	if remoteNode, err := node1.getPredecessorRPC(context.Background(), node1.Node); err != nil {
+		t.Fatalf("Unexpected error:%v", err)
+	} else if remoteNode.Addr != node2.Addr {
+		t.Fatalf(
+			"Unexpected predecessor. Expected %v got %v",
+			node2.Node,
+			remoteNode,
+		)
+	}
+}
+
+func TestFindSuccessorAroundFailedNode(t *testing.T) {
+	t.Parallel()
+
+	forEachLookupMode(t, testFindSuccessorAroundFailedNode)
+}
+
+func testFindSuccessorAroundFailedNode(t *testing.T, opt NodeOption) {
+	node1, node2, node3 := create3SuccessiveNodes(t, opt)
+
+	<-time.After(testTimeout)
+
+	crashNode(node2)
+
+	// node1's fingers still point to node2 since the ring has not had the
+	// chance to stabilize.
+	for _, id := range []byte{0x60, 0xaa} {
+		succ, err := node1.findSuccessor(context.Background(), newID([]byte{id}))
+		if err != nil {
+			t.Fatalf("Unexpected error finding successor of %v: %v", id, err)
+		} else if !idsEqual(succ.Id, node3.Id) {
+			t.Fatalf("Unexpected successor of %v. Expected %v got %v", id, node3.Node, succ)
+		}
+	}
+}
+
+func TestRPCTimeout(t *testing.T) {
+	t.Parallel()
+
+	// node2 stops answering RPCs once hang is set, as if it were stuck.
+	var hang int32
+	interceptor := func(
+		ctx context.Context, req interface{},
+		_ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if atomic.LoadInt32(&hang) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+
+		return handler(ctx, req)
+	}
+	streamInterceptor := func(
+		srv interface{}, stream grpc.ServerStream,
+		_ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+	) error {
+		if atomic.LoadInt32(&hang) == 1 {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+
+		return handler(srv, stream)
+	}
+
+	node1, err := NewNode(nil)
+	if err != nil {
+		t.Fatalf("Unable to create node, received error:%v", err)
+	}
+	node2, err := NewNode(
+		node1.Node, WithGRPCServerOptions(
+			grpc.UnaryInterceptor(interceptor), grpc.StreamInterceptor(streamInterceptor),
+		),
+	)
+	if err != nil {
+		t.Fatalf("Unable to create node, received error:%v", err)
+	}
+
+	<-time.After(testTimeout)
+
+	atomic.StoreInt32(&hang, 1)
+	defer atomic.StoreInt32(&hang, 0)
+
+	start := time.Now()
+	_, err = node1.getKeyRPC(context.Background(), node2.Node, "key")
+	if code := grpc.Code(err); code != codes.DeadlineExceeded {
+		t.Fatalf("Expected %v, got %v", codes.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*config.ConnectionTimeout {
+		t.Fatalf("RPC took %v, longer than the timeout of %v", elapsed, config.ConnectionTimeout)
+	}
+
+	// Requests that are cancelled by the client give up on their lookups.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = node1.Get(ctx, &gmajpb.GetRequest{Key: "key"})
+	if code := grpc.Code(err); code != codes.Canceled {
+		t.Fatalf("Expected %v, got %v", codes.Canceled, err)
+	}
+}
@@ -0,0 +1,165 @@
+package sim
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/r-medina/gmaj"
+)
+
+// Clock is a virtual clock. Time only passes when the clock is advanced, and
+// the tasks that become due run one at a time, in the order they are due.
+type Clock struct {
+	now     time.Time
+	tasks   []*task
+	nextSeq uint64
+	running bool // whether a task is running
+	mtx     sync.Mutex
+}
+
+var _ gmaj.Clock = (*Clock)(nil)
+
+// task is a function scheduled to run periodically.
+type task struct {
+	f        func()
+	interval time.Duration
+	next     time.Time // when the task is next due
+	seq      uint64    // breaks ties between tasks that are due at once
+}
+
+// NewClock returns a virtual clock that starts at the Unix epoch.
+func NewClock() *Clock {
+	return &Clock{now: time.Unix(0, 0)}
+}
+
+// Now returns the current virtual time.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+// Every schedules f to run every d.
+func (c *Clock) Every(d time.Duration, f func()) func() {
+	c.mtx.Lock()
+	t := &task{f: f, interval: d, next: c.now.Add(d), seq: c.nextSeq}
+	c.nextSeq++
+	c.tasks = append(c.tasks, t)
+	c.mtx.Unlock()
+
+	return func() {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		for i, other := range c.tasks {
+			if other == t {
+				c.tasks = append(c.tasks[:i], c.tasks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// After advances the clock by d and returns a channel with the new time. When
+// it is called from a task, the clock is not advanced, since the tasks run one
+// at a time.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	running := c.running
+	c.mtx.Unlock()
+
+	if !running {
+		c.Advance(d)
+	}
+
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+// Advance moves the clock forward by d, running the tasks that become due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	end := c.now.Add(d)
+	c.mtx.Unlock()
+
+	for {
+		c.mtx.Lock()
+		t := c.nextTask()
+		if t == nil || t.next.After(end) {
+			c.now = end
+			c.mtx.Unlock()
+			return
+		}
+		c.mtx.Unlock()
+
+		c.Step()
+	}
+}
+
+// Step moves the clock forward to when the next task is due and runs it. It
+// returns false if there are no tasks.
+func (c *Clock) Step() bool {
+	c.mtx.Lock()
+	t := c.nextTask()
+	if t == nil {
+		c.mtx.Unlock()
+		return false
+	}
+
+	c.now = t.next
+	t.next = t.next.Add(t.interval)
+	c.running = true
+	c.mtx.Unlock()
+
+	t.f()
+
+	c.mtx.Lock()
+	c.running = false
+	c.mtx.Unlock()
+
+	return true
+}
+
+// nextTask returns the task that is due first. It must be called with the lock
+// held.
+func (c *Clock) nextTask() *task {
+	if len(c.tasks) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(c.tasks, func(i, j int) bool {
+		a, b := c.tasks[i], c.tasks[j]
+		if !a.next.Equal(b.next) {
+			return a.next.Before(b.next)
+		}
+
+		return a.seq < b.seq
+	})
+
+	return c.tasks[0]
+}
+
+// nodeClock is the view of the clock that a node gets, which keeps track of
+// its tasks so that they can be stopped when the node fails.
+type nodeClock struct {
+	*Clock
+	stops []func()
+}
+
+// Every schedules f to run every d.
+func (c *nodeClock) Every(d time.Duration, f func()) func() {
+	stop := c.Clock.Every(d, f)
+	c.stops = append(c.stops, stop)
+
+	return stop
+}
+
+// stop stops all of the node's tasks.
+func (c *nodeClock) stop() {
+	for _, stop := range c.stops {
+		stop()
+	}
+}
@@ -0,0 +1,337 @@
+// Package sim runs deterministic simulations of gmaj rings. The nodes of a
+// simulation talk over an in-memory network and run their background tasks
+// (stabilize, fixNextFinger, etc.) off a virtual clock, so a simulation with a
+// given seed always plays out the same way. This makes it possible to
+// reproduce bugs that only show up under churn, and to check invariants of the
+// ring after every step.
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/r-medina/gmaj"
+	"github.com/r-medina/gmaj/gmajcfg"
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+// Event is what happens in a step of a simulation.
+type Event int
+
+// The events in a simulation.
+const (
+	Tick  Event = iota // a background task of a node runs
+	Join               // a node joins the ring
+	Leave              // a node shuts down gracefully
+	Fail               // a node fails
+)
+
+func (e Event) String() string {
+	switch e {
+	case Tick:
+		return "tick"
+	case Join:
+		return "join"
+	case Leave:
+		return "leave"
+	case Fail:
+		return "fail"
+	}
+
+	return fmt.Sprintf("Event(%d)", int(e))
+}
+
+// Options configure a simulation.
+type Options struct {
+	// Seed seeds the random choices of the simulation. Simulations with the
+	// same options play out the same way.
+	Seed int64
+	// Config is the configuration of the nodes. It defaults to
+	// gmajcfg.DefaultConfig.
+	Config *gmajcfg.Config
+	// JoinRate, LeaveRate and FailRate are the chances that a step of the
+	// simulation is a node joining, leaving or failing instead of a tick.
+	JoinRate, LeaveRate, FailRate float64
+}
+
+// Sim is a simulation of a ring.
+type Sim struct {
+	opts    Options
+	rand    *rand.Rand
+	clock   *Clock
+	network *gmaj.MemNetwork
+
+	nodes  []*simNode      // live nodes, sorted by ID
+	joined map[string]bool // IDs of all the nodes that ever joined
+}
+
+// simNode is a node in a simulation along with what is needed to make it fail.
+type simNode struct {
+	*gmaj.Node
+	transport gmaj.Transport
+	clock     *nodeClock
+}
+
+// New creates a simulation without any nodes.
+func New(opts Options) *Sim {
+	if opts.Config == nil {
+		opts.Config = gmajcfg.DefaultConfig
+	}
+	cfg := *opts.Config
+	cfg.SetDefaults() // Settle steps the clock by StabilizeInterval
+	opts.Config = &cfg
+
+	return &Sim{
+		opts:    opts,
+		rand:    rand.New(rand.NewSource(opts.Seed)),
+		clock:   NewClock(),
+		network: gmaj.NewMemNetwork(),
+		joined:  make(map[string]bool),
+	}
+}
+
+// Clock returns the virtual clock of the simulation.
+func (s *Sim) Clock() *Clock {
+	return s.clock
+}
+
+// Nodes returns the live nodes, sorted by ID.
+func (s *Sim) Nodes() []*gmaj.Node {
+	nodes := make([]*gmaj.Node, len(s.nodes))
+	for i, n := range s.nodes {
+		nodes[i] = n.Node
+	}
+
+	return nodes
+}
+
+// Join adds a node with a random ID to the ring, through a random live node.
+// The first node creates the ring.
+func (s *Sim) Join() (*gmaj.Node, error) {
+	cfg := s.opts.Config
+	id := make([]byte, cfg.IDLength)
+	for {
+		s.rand.Read(id)
+		id[0] &= 0xff >> uint(8*cfg.IDLength-cfg.KeySize) // fit the key size
+		if s.find(id) < 0 {
+			break
+		}
+	}
+
+	var parent *gmajpb.Node
+	if len(s.nodes) > 0 {
+		parent = s.nodes[s.rand.Intn(len(s.nodes))].Node.Node
+	}
+
+	n := &simNode{
+		transport: s.network.Transport(),
+		clock:     &nodeClock{Clock: s.clock},
+	}
+
+	var err error
+	n.Node, err = gmaj.NewNode(parent,
+		gmaj.WithID(id),
+		gmaj.WithConfig(s.opts.Config),
+		gmaj.WithTransport(n.transport),
+		gmaj.WithClock(n.clock),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.joined[string(id)] = true
+	s.nodes = append(s.nodes, n)
+	sort.Slice(s.nodes, func(i, j int) bool {
+		return bytes.Compare(s.nodes[i].Id, s.nodes[j].Id) < 0
+	})
+
+	return n.Node, nil
+}
+
+// Leave shuts down a random live node. It returns nil if there are none.
+func (s *Sim) Leave() *gmaj.Node {
+	n := s.remove()
+	if n == nil {
+		return nil
+	}
+
+	n.Shutdown()
+
+	return n.Node
+}
+
+// Fail makes a random live node stop responding and running its background
+// tasks, without any of the cleanup done by Shutdown. It returns nil if there
+// are no live nodes.
+func (s *Sim) Fail() *gmaj.Node {
+	n := s.remove()
+	if n == nil {
+		return nil
+	}
+
+	n.clock.stop()
+	_ = n.transport.Close()
+
+	return n.Node
+}
+
+// remove removes a random node from the live nodes.
+func (s *Sim) remove() *simNode {
+	if len(s.nodes) == 0 {
+		return nil
+	}
+
+	i := s.rand.Intn(len(s.nodes))
+	n := s.nodes[i]
+	s.nodes = append(s.nodes[:i], s.nodes[i+1:]...)
+
+	return n
+}
+
+// find returns the index of the live node with id, or -1 if there is none.
+func (s *Sim) find(id []byte) int {
+	for i, n := range s.nodes {
+		if bytes.Equal(n.Id, id) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Step takes a random step of the simulation. If there are no nodes, a node
+// joins.
+func (s *Sim) Step() (Event, error) {
+	r := s.rand.Float64()
+	switch {
+	case len(s.nodes) == 0 || r < s.opts.JoinRate:
+		_, err := s.Join()
+		return Join, err
+	case r < s.opts.JoinRate+s.opts.LeaveRate:
+		s.Leave()
+		return Leave, nil
+	case r < s.opts.JoinRate+s.opts.LeaveRate+s.opts.FailRate:
+		s.Fail()
+		return Fail, nil
+	}
+
+	s.clock.Step()
+
+	return Tick, nil
+}
+
+// Run takes n steps of the simulation, calling check (if it is not nil) after
+// each of them. It stops at the first step that fails or does not pass the
+// check.
+func (s *Sim) Run(n int, check func(*Sim) error) error {
+	for i := 0; i < n; i++ {
+		ev, err := s.Step()
+		if err == nil && check != nil {
+			err = check(s)
+		}
+		if err != nil {
+			return fmt.Errorf("sim: seed %d, step %d (%v): %v", s.opts.Seed, i, ev, err)
+		}
+	}
+
+	return nil
+}
+
+// Close stops all the live nodes.
+func (s *Sim) Close() {
+	for _, n := range s.nodes {
+		n.clock.stop()
+		_ = n.transport.Close()
+	}
+	s.nodes = nil
+}
+
+// Settle lets the nodes run their background tasks until the ring is
+// consistent (see CheckRing), or until max passes on the virtual clock.
+func (s *Sim) Settle(max time.Duration) error {
+	end := s.clock.Now().Add(max)
+	for {
+		err := CheckRing(s)
+		if err == nil || !s.clock.Now().Before(end) {
+			return err
+		}
+
+		s.clock.Advance(s.opts.Config.StabilizeInterval)
+	}
+}
+
+// CheckRing checks that the successor and predecessor of every live node are
+// the live nodes right after and before it. This holds once the ring has had
+// the time to stabilize after the last join or failure.
+func CheckRing(s *Sim) error {
+	for i, n := range s.nodes {
+		next := s.nodes[(i+1)%len(s.nodes)]
+		prev := s.nodes[(i+len(s.nodes)-1)%len(s.nodes)]
+
+		succ, err := n.GetSuccessor(context.Background(), &gmajpb.MT{})
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(succ.Id, next.Id) {
+			return fmt.Errorf("node %v has successor %v instead of %v",
+				gmaj.IDToString(n.Id), gmaj.IDToString(succ.Id), gmaj.IDToString(next.Id),
+			)
+		}
+
+		pred, err := n.GetPredecessor(context.Background(), &gmajpb.MT{})
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(pred.Id, prev.Id) {
+			return fmt.Errorf("node %v has predecessor %v instead of %v",
+				gmaj.IDToString(n.Id), gmaj.IDToString(pred.Id), gmaj.IDToString(prev.Id),
+			)
+		}
+	}
+
+	return nil
+}
+
+// CheckPointers checks that the successor lists and predecessors of the live
+// nodes only point to nodes that joined the ring, and that successor lists do
+// not have duplicates. This should hold after every step.
+func CheckPointers(s *Sim) error {
+	for _, n := range s.nodes {
+		pred, err := n.GetPredecessor(context.Background(), &gmajpb.MT{})
+		if err != nil {
+			return err
+		}
+		if pred.Addr != "" && !s.joined[string(pred.Id)] {
+			return fmt.Errorf("node %v has unknown predecessor %v",
+				gmaj.IDToString(n.Id), gmaj.IDToString(pred.Id),
+			)
+		}
+
+		succs, err := n.GetSuccessorList(context.Background(), &gmajpb.MT{})
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[string]bool)
+		for _, succ := range succs.Nodes {
+			if !s.joined[string(succ.Id)] {
+				return fmt.Errorf("node %v has unknown successor %v",
+					gmaj.IDToString(n.Id), gmaj.IDToString(succ.Id),
+				)
+			}
+			if seen[string(succ.Id)] {
+				return fmt.Errorf("node %v has successor %v twice",
+					gmaj.IDToString(n.Id), gmaj.IDToString(succ.Id),
+				)
+			}
+			seen[string(succ.Id)] = true
+		}
+	}
+
+	return nil
+}
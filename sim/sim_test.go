@@ -0,0 +1,131 @@
+package sim
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj"
+	"github.com/r-medina/gmaj/gmajcfg"
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+func testConfig() *gmajcfg.Config {
+	cfg := *gmajcfg.DefaultConfig
+	cfg.KeySize = 16
+	cfg.IDLength = 2
+	cfg.Log = log.New(ioutil.Discard, "", 0)
+
+	return &cfg
+}
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	c := NewClock()
+	var ran []string
+	stopA := c.Every(2*time.Second, func() { ran = append(ran, "a") })
+	c.Every(3*time.Second, func() { ran = append(ran, "b") })
+
+	c.Advance(6 * time.Second)
+	want := []string{"a", "b", "a", "a", "b"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Fatalf("ran %v, expected %v", ran, want)
+	}
+	if got := c.Now().Sub(time.Unix(0, 0)); got != 6*time.Second {
+		t.Fatalf("clock is at %v, expected 6s", got)
+	}
+
+	stopA()
+	ran = nil
+	if !c.Step() {
+		t.Fatal("expected a task to run")
+	}
+	if !reflect.DeepEqual(ran, []string{"b"}) {
+		t.Fatalf("ran %v, expected [b]", ran)
+	}
+}
+
+func TestChurn(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []gmajcfg.LookupMode{gmajcfg.IterativeLookup, gmajcfg.RecursiveLookup} {
+		mode := mode
+		t.Run(mode.String(), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testConfig()
+			cfg.LookupMode = mode
+			s := New(Options{
+				Seed:      1,
+				Config:    cfg,
+				JoinRate:  0.05,
+				LeaveRate: 0.01,
+				FailRate:  0.01,
+			})
+			defer s.Close()
+
+			for i := 0; i < 8; i++ {
+				if _, err := s.Join(); err != nil {
+					t.Fatalf("unexpected error joining: %v", err)
+				}
+			}
+
+			if err := s.Run(500, CheckPointers); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Settle(time.Minute); err != nil {
+				t.Fatalf("ring did not settle: %v", err)
+			}
+		})
+	}
+}
+
+// TestDeterminism checks that two simulations with the same seed play out the
+// same way.
+func TestDeterminism(t *testing.T) {
+	t.Parallel()
+
+	trace := func() []string {
+		s := New(Options{
+			Seed:     42,
+			Config:   testConfig(),
+			JoinRate: 0.05,
+			FailRate: 0.02,
+		})
+		defer s.Close()
+
+		var trace []string
+		for i := 0; i < 300; i++ {
+			ev, err := s.Step()
+			if err != nil {
+				t.Fatalf("step %d: %v", i, err)
+			}
+
+			line := fmt.Sprintf("%v %v:", s.Clock().Now().UnixNano(), ev)
+			for _, node := range s.Nodes() {
+				succ, err := node.GetSuccessor(context.Background(), &gmajpb.MT{})
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				line += fmt.Sprintf(" %v->%v", gmaj.IDToString(node.Id), gmaj.IDToString(succ.Id))
+			}
+			trace = append(trace, line)
+		}
+
+		return trace
+	}
+
+	a, b := trace(), trace()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("simulations diverged at step %d:\n%v\n%v", i, a[i], b[i])
+		}
+	}
+}
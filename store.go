@@ -0,0 +1,208 @@
+//
+//  the storage engines behind a node's datastore, and the in-memory one nodes
+//  use by default
+//
+
+package gmaj
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// Entry is what a Store holds for a key.
+type Entry struct {
+	Val []byte
+
+	// Version starts at 1 when a key is created, and is incremented by every
+	// write, including deletes. It orders the copies of a key on different
+	// nodes.
+	Version uint64
+
+	// Deleted marks a tombstone, which is kept in place of a deleted key so
+	// that the delete reaches the other nodes with copies of the key, instead
+	// of them copying the key back.
+	Deleted bool
+
+	// Expires is when the key expires, or zero if it does not. Expired keys
+	// are hidden until the reaper removes them. Tombstones expire after the
+	// configured grace period.
+	Expires time.Time
+}
+
+// expired returns if the entry has expired at now.
+func (entry Entry) expired(now time.Time) bool {
+	return !entry.Expires.IsZero() && !now.Before(entry.Expires)
+}
+
+// expiryPrecision is how closely copies of an entry agree on when it expires.
+// They are sent with TTLs rather than times, so each copy expires later than
+// the one it was sent from by the time it took to send.
+const expiryPrecision = time.Second
+
+// expiry returns when the entry expires, to expiryPrecision, in Unix
+// nanoseconds, or 0 if it does not.
+func (entry Entry) expiry() int64 {
+	if entry.Expires.IsZero() {
+		return 0
+	}
+
+	return entry.Expires.Truncate(expiryPrecision).UnixNano()
+}
+
+// newer returns if entry should replace other. Entries with the same version
+// are only written concurrently, e.g. by nodes on either side of a partition,
+// so ties are broken arbitrarily but the same way on every node.
+func (entry Entry) newer(other Entry) bool {
+	switch {
+	case entry.Version != other.Version:
+		return entry.Version > other.Version
+	case entry.Deleted != other.Deleted:
+		return entry.Deleted
+	case !bytes.Equal(entry.Val, other.Val):
+		return bytes.Compare(entry.Val, other.Val) > 0
+	}
+
+	// A copy that does not expire wins over one that does, and otherwise the
+	// one that expires last.
+	expiry, otherExpiry := entry.expiry(), other.expiry()
+	return expiry != otherExpiry && (expiry == 0 || otherExpiry != 0 && expiry > otherExpiry)
+}
+
+// equal returns if entry and other are the same.
+func (entry Entry) equal(other Entry) bool {
+	return entry.Version == other.Version && entry.Deleted == other.Deleted &&
+		entry.Expires.Equal(other.Expires) && bytes.Equal(entry.Val, other.Val)
+}
+
+// Store is a storage engine for the key/value pairs a node holds. Keys are
+// stored along with their hashed IDs so that the keys in a range of the ring
+// can be found. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the entry for key, and whether it exists.
+	Get(key string) (Entry, bool, error)
+
+	// Put sets the entry for key, whose hashed ID is id.
+	Put(id ID, key string, entry Entry) error
+
+	// Delete removes key. Deleting a key that does not exist is not an error.
+	Delete(key string) error
+
+	// Range calls f for the keys with IDs in (from : to], in no particular
+	// order, until f returns false. The whole ring is (id : id] for any id.
+	// f must not modify the store.
+	Range(from, to ID, f func(key string, entry Entry) bool) error
+
+	// Close releases the resources held by the store.
+	Close() error
+}
+
+// Names of the stores a node opens.
+const (
+	DatastoreStore = "datastore" // keys the node owns
+	ReplicaStore   = "replicas"  // copies of keys owned by predecessors
+	TxnStore       = "txns"      // transactions the node has prepared or decided to commit
+)
+
+// OpenStore opens the store called name (DatastoreStore, ReplicaStore or
+// TxnStore) for the node with ID id.
+type OpenStore func(id []byte, name string) (Store, error)
+
+// openMemStore is the OpenStore nodes use by default.
+func openMemStore([]byte, string) (Store, error) {
+	return newMemStore(), nil
+}
+
+// memStore keeps key/value pairs in a map.
+type memStore struct {
+	entries map[string]memEntry
+	mtx     sync.RWMutex
+}
+
+type memEntry struct {
+	id ID
+	Entry
+}
+
+var _ Store = (*memStore)(nil)
+
+func newMemStore() *memStore {
+	return &memStore{entries: make(map[string]memEntry)}
+}
+
+func (s *memStore) Get(key string) (Entry, bool, error) {
+	s.mtx.RLock()
+	entry, ok := s.entries[key]
+	s.mtx.RUnlock()
+
+	return entry.Entry, ok, nil
+}
+
+func (s *memStore) Put(id ID, key string, entry Entry) error {
+	s.mtx.Lock()
+	s.entries[key] = memEntry{id: id, Entry: entry}
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mtx.Lock()
+	delete(s.entries, key)
+	s.mtx.Unlock()
+
+	return nil
+}
+
+func (s *memStore) Range(from, to ID, f func(key string, entry Entry) bool) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for key, entry := range s.entries {
+		if entry.id.InRange(from, to) && !f(key, entry.Entry) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *memStore) Close() error {
+	return nil
+}
+
+// openStores opens the datastore, replicas and transactions of the node.
+func (node *Node) openStores() error {
+	open := node.opts.openStore
+	if open == nil {
+		open = openMemStore
+	}
+
+	names := []string{DatastoreStore, ReplicaStore, TxnStore}
+	stores := make([]Store, 0, len(names))
+	for _, name := range names {
+		store, err := open(node.Id, name)
+		if err != nil {
+			for _, store := range stores {
+				_ = store.Close()
+			}
+			return err
+		}
+		stores = append(stores, store)
+	}
+
+	node.datastore, node.replicas, node.txnStore = stores[0], stores[1], stores[2]
+
+	return nil
+}
+
+// closeStores closes the datastore, replicas and transactions of the node,
+// logging any errors.
+func (node *Node) closeStores() {
+	for _, store := range []Store{node.datastore, node.replicas, node.txnStore} {
+		if err := store.Close(); err != nil {
+			node.config.Log.Printf("closing store failed: %v", err)
+		}
+	}
+}
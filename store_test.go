@@ -0,0 +1,109 @@
+package gmaj
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestMemStore(t *testing.T) {
+	t.Parallel()
+
+	store := newMemStore()
+	for _, id := range []byte{10, 20, 200} {
+		key := string([]byte{'k', id})
+		if err := store.Put(newID([]byte{id}), key, Entry{Val: []byte{id}}); err != nil {
+			t.Fatalf("Unexpected error putting %q: %v", key, err)
+		}
+	}
+
+	if entry, ok, err := store.Get("k\x14"); err != nil || !ok || !reflect.DeepEqual(entry.Val, []byte{20}) {
+		t.Fatalf("Unexpected result getting key: %v %v %v", entry, ok, err)
+	}
+	if err := store.Delete("k\x14"); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	if _, ok, _ := store.Get("k\x14"); ok {
+		t.Fatal("Unexpected key after deleting it")
+	}
+	if err := store.Delete("k\x14"); err != nil {
+		t.Fatalf("Unexpected error deleting missing key: %v", err)
+	}
+
+	tests := []struct {
+		from, to byte
+		exp      []byte
+	}{
+		{from: 0, to: 10, exp: []byte{10}},
+		{from: 10, to: 199, exp: nil},
+		{from: 150, to: 10, exp: []byte{10, 200}},
+		{from: 200, to: 200, exp: []byte{10, 200}},
+	}
+	for i, test := range tests {
+		var got []byte
+		err := store.Range(newID([]byte{test.from}), newID([]byte{test.to}), func(key string, entry Entry) bool {
+			got = append(got, entry.Val...)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Fatalf("[%02d] expected %v in (%v : %v], got %v", i, test.exp, test.from, test.to, got)
+		}
+	}
+}
+
+// countingStore is a Store that counts the keys put in it.
+type countingStore struct {
+	Store
+	puts int
+	mtx  sync.Mutex
+}
+
+func (s *countingStore) Put(id ID, key string, entry Entry) error {
+	s.mtx.Lock()
+	s.puts++
+	s.mtx.Unlock()
+
+	return s.Store.Put(id, key, entry)
+}
+
+func TestWithStore(t *testing.T) {
+	t.Parallel()
+
+	var opened []string
+	stores := make(map[string]*countingStore)
+	open := func(id []byte, name string) (Store, error) {
+		opened = append(opened, name)
+		stores[name] = &countingStore{Store: newMemStore()}
+		return stores[name], nil
+	}
+
+	node := createDefinedNode(t, nil, []byte{0x20}, WithStore(open))
+	defer node.Shutdown()
+
+	if want := []string{DatastoreStore, ReplicaStore, TxnStore}; !reflect.DeepEqual(opened, want) {
+		t.Fatalf("Expected stores %v to be opened, got %v", want, opened)
+	}
+
+	if err := Put(node, "key", []byte("val")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	if got := stores[DatastoreStore].puts; got != 1 {
+		t.Fatalf("Expected 1 key in the datastore, got %v", got)
+	}
+
+	errOpen := errors.New("cannot open")
+	_, err := NewNode(nil,
+		WithTransport(testNetwork.Transport()),
+		WithStore(func([]byte, string) (Store, error) { return nil, errOpen }),
+	)
+	if err != errOpen {
+		t.Fatalf("Expected error %v, got %v", errOpen, err)
+	}
+}
@@ -0,0 +1,333 @@
+//
+//  splits large values into chunks, so that they can be sent over streams
+//  instead of in single messages, which gRPC limits the size of
+//
+
+package gmaj
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// ChunkSize is the most bytes of a value that are sent in one message of a
+// stream. Values larger than this are streamed between nodes.
+const ChunkSize = 1 << 20
+
+var (
+	errEmptyStream     = errors.New("gmaj: stream ended before any messages")
+	errTruncatedStream = errors.New("gmaj: stream ended in the middle of a value")
+
+	// errValueTooLarge is returned for values larger than the MaxValueSize in
+	// the configuration.
+	errValueTooLarge = grpc.Errorf(codes.ResourceExhausted, "gmaj: value is larger than the maximum value size")
+)
+
+// checkValueSize returns errValueTooLarge if a value of size bytes is larger
+// than max, unless max is 0.
+func checkValueSize(size, max int) error {
+	if max > 0 && size > max {
+		return errValueTooLarge
+	}
+
+	return nil
+}
+
+// chunks splits val into pieces of at most ChunkSize bytes. There is always at
+// least one piece, so that the first message of a stream can carry the other
+// fields even if the value is empty.
+func chunks(val []byte) [][]byte {
+	pieces := make([][]byte, 0, len(val)/ChunkSize+1)
+	for len(val) > ChunkSize {
+		pieces = append(pieces, val[:ChunkSize])
+		val = val[ChunkSize:]
+	}
+
+	return append(pieces, val)
+}
+
+// sendKeyVal sends keyVal in chunks. The first message has all of its fields,
+// and every message but the last has more set.
+func sendKeyVal(send func(*gmajpb.KeyVal) error, keyVal *gmajpb.KeyVal) error {
+	pieces := chunks(keyVal.Val)
+	for i, chunk := range pieces {
+		msg := &gmajpb.KeyVal{Val: chunk}
+		if i == 0 {
+			first := *keyVal
+			first.Val = chunk
+			msg = &first
+		}
+		msg.More = i < len(pieces)-1
+
+		if err := send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recvKeyVal receives a key/value sent by sendKeyVal. It gives up once the
+// value is larger than max bytes, unless max is 0, so that a sender cannot make
+// it buffer any amount.
+func recvKeyVal(recv func() (*gmajpb.KeyVal, error), max int) (*gmajpb.KeyVal, error) {
+	keyVal, err := recv()
+	if err == io.EOF {
+		return nil, errEmptyStream
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := checkValueSize(len(keyVal.Val), max); err != nil {
+			return nil, err
+		}
+
+		msg, err := recv()
+		if err == io.EOF {
+			return keyVal, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keyVal.Val = append(keyVal.Val, msg.Val...)
+	}
+}
+
+// recvKeyVals receives the key/values sent one after another by sendKeyVal,
+// and passes each on to f once all of its value has arrived. Like recvKeyVal,
+// it gives up on values larger than max bytes.
+func recvKeyVals(recv func() (*gmajpb.KeyVal, error), max int, f func(*gmajpb.KeyVal)) error {
+	var keyVal *gmajpb.KeyVal
+	for {
+		msg, err := recv()
+		if err == io.EOF {
+			if keyVal != nil {
+				return errTruncatedStream
+			}
+
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if keyVal == nil {
+			keyVal = msg
+		} else {
+			keyVal.Val = append(keyVal.Val, msg.Val...)
+		}
+		if err := checkValueSize(len(keyVal.Val), max); err != nil {
+			return err
+		}
+
+		if !msg.More {
+			f(keyVal)
+			keyVal = nil
+		}
+	}
+}
+
+// sendEntry sends the value and version of entry in chunks. The version is in
+// the first message.
+func sendEntry(send func(*gmajpb.Val) error, entry Entry) error {
+	for i, chunk := range chunks(entry.Val) {
+		msg := &gmajpb.Val{Val: chunk}
+		if i == 0 {
+			msg.Version = entry.Version
+		}
+
+		if err := send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recvEntry receives an entry sent by sendEntry. Like recvKeyVal, it gives up
+// on values larger than max bytes.
+func recvEntry(recv func() (*gmajpb.Val, error), max int) (Entry, error) {
+	val, err := recv()
+	if err == io.EOF {
+		return Entry{}, errEmptyStream
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{Val: val.Val, Version: val.Version}
+	for {
+		if err := checkValueSize(len(entry.Val), max); err != nil {
+			return Entry{}, err
+		}
+
+		msg, err := recv()
+		if err == io.EOF {
+			return entry, nil
+		}
+		if err != nil {
+			return Entry{}, err
+		}
+
+		entry.Val = append(entry.Val, msg.Val...)
+	}
+}
+
+// recvPutRequest receives a put that a client sent in chunks. The fields of
+// the put are taken from the first request. Like recvKeyVal, it gives up on
+// values larger than max bytes.
+func recvPutRequest(recv func() (*gmajpb.PutRequest, error), max int) (*gmajpb.PutRequest, error) {
+	req, err := recv()
+	if err == io.EOF {
+		return nil, errEmptyStream
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := checkValueSize(len(req.Value), max); err != nil {
+			return nil, err
+		}
+
+		msg, err := recv()
+		if err == io.EOF {
+			return req, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		req.Value = append(req.Value, msg.Value...)
+	}
+}
+
+// chunkContext is the context of an RPC that streams a value in chunks. Its
+// deadline is the connection timeout from when the last chunk was sent or
+// received, rather than from when the RPC started, so that large values are not
+// cut off while they are still moving.
+type chunkContext struct {
+	context.Context // the parent, for its values
+
+	timeout time.Duration
+	timer   *time.Timer
+	done    chan struct{}
+
+	mtx sync.Mutex
+	err error
+}
+
+// chunkContext returns the context for an RPC to remoteNode that streams
+// chunks. Like rpcContext, it is cancelled along with ctx. progress is to be
+// called as each chunk is sent or received, and moves the deadline back.
+func (node *Node) chunkContext(
+	ctx context.Context, remoteNode *gmajpb.Node,
+) (_ context.Context, progress func(), cancel context.CancelFunc) {
+	c := &chunkContext{
+		Context: targetContext(ctx, remoteNode),
+		timeout: node.config.ConnectionTimeout,
+		done:    make(chan struct{}),
+	}
+	if c.timeout > 0 {
+		c.timer = time.AfterFunc(c.timeout, func() { c.cancel(context.DeadlineExceeded) })
+	}
+
+	go func() {
+		select {
+		case <-c.Context.Done():
+			c.cancel(c.Context.Err())
+		case <-c.done:
+		}
+	}()
+
+	return c, c.progress, func() { c.cancel(context.Canceled) }
+}
+
+// progress moves the deadline to the connection timeout from now.
+func (c *chunkContext) progress() {
+	if c.timer != nil {
+		c.timer.Reset(c.timeout)
+	}
+}
+
+func (c *chunkContext) cancel(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// Deadline returns the deadline of the parent, if any, since its own moves.
+func (c *chunkContext) Deadline() (time.Time, bool) {
+	return c.Context.Deadline()
+}
+
+func (c *chunkContext) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *chunkContext) Err() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.err
+}
+
+// getStream gets the value of a key from the node that owns it, and sends it
+// on to the client in chunks as they arrive. Like get, if the owner fails
+// before sending anything, it falls back to the copies on the owner's
+// successors, and then looks the owner up again and retries.
+func (node *Node) getStream(
+	ctx context.Context, key string, send func(*gmajpb.GetResponse) error,
+) error {
+	remoteNode, err := node.locate(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	sent, err := node.getKeyStreamRPC(ctx, remoteNode, key, send)
+	if err == nil || sent || isDeleted(err) {
+		return err
+	}
+
+	node.locations.invalidate(remoteNode)
+	if entry, err := node.getFromReplicas(ctx, remoteNode, key); err == nil {
+		return sendEntry(func(val *gmajpb.Val) error {
+			return send(&gmajpb.GetResponse{Value: val.Val, Version: val.Version})
+		}, entry)
+	} else if isDeleted(err) {
+		return err
+	}
+
+	select {
+	case <-node.clock.After(node.config.RetryInterval):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	remoteNode, err = node.locate(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = node.getKeyStreamRPC(ctx, remoteNode, key, send)
+	return err
+}
@@ -0,0 +1,275 @@
+package gmaj
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestChunks(t *testing.T) {
+	t.Parallel()
+
+	val := make([]byte, 2*ChunkSize+1)
+	rand.Read(val)
+
+	tests := []struct {
+		val     []byte
+		nChunks int
+	}{
+		{val: nil, nChunks: 1},
+		{val: val[:ChunkSize], nChunks: 1},
+		{val: val, nChunks: 3},
+	}
+	for i, test := range tests {
+		var msgs []*gmajpb.KeyVal
+		err := sendKeyVal(func(kv *gmajpb.KeyVal) error {
+			msgs = append(msgs, kv)
+			return nil
+		}, &gmajpb.KeyVal{Key: "key", Val: test.val, Version: 3})
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if len(msgs) != test.nChunks {
+			t.Fatalf("[%02d] expected %v chunks, got %v", i, test.nChunks, len(msgs))
+		}
+
+		got, err := recvKeyVal(func() (*gmajpb.KeyVal, error) {
+			if len(msgs) == 0 {
+				return nil, io.EOF
+			}
+			msg := msgs[0]
+			msgs = msgs[1:]
+			return msg, nil
+		}, 0)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if got.Key != "key" || got.Version != 3 || !bytes.Equal(got.Val, test.val) {
+			t.Fatalf("[%02d] unexpected key/value %q at version %v", i, got.Key, got.Version)
+		}
+	}
+
+	if _, err := recvKeyVal(func() (*gmajpb.KeyVal, error) { return nil, io.EOF }, 0); err != errEmptyStream {
+		t.Fatalf("Expected error %v, got %v", errEmptyStream, err)
+	}
+}
+
+func TestStreamLargeValue(t *testing.T) {
+	t.Parallel()
+	key := "large"
+	hashedKey, err := config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+
+	hashedKey[0] += 2
+	node1 := createDefinedNode(t, nil, hashedKey)
+	defer node1.Shutdown()
+
+	// The value is larger than the largest message.
+	val := make([]byte, memMaxMsgSize+5)
+	rand.Read(val)
+
+	// The value is too large for one message, so it gets to the owner, and
+	// then to node2 when node2 takes over the key, over streams.
+	version, err := Update(node1, key, val)
+	if err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	hashedKey, err = config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+	hashedKey[0]++
+	node2 := createDefinedNode(t, node1.Node, hashedKey)
+	defer node2.Shutdown()
+
+	<-time.After(testTimeout)
+
+	if got, err := node2.getKey(key); err != nil {
+		t.Fatalf("Expected node2 to own %q: %v", key, err)
+	} else if !bytes.Equal(got.Val, val) || got.Version != version {
+		t.Fatal("Unexpected value after transfer")
+	}
+	if got, err := node1.getReplica(key); err != nil {
+		t.Fatalf("Expected node1 to have a copy of %q: %v", key, err)
+	} else if !bytes.Equal(got.Val, val) {
+		t.Fatal("Unexpected value of replica")
+	}
+
+	var resps []*gmajpb.GetResponse
+	err = node1.getStream(context.Background(), key, func(resp *gmajpb.GetResponse) error {
+		resps = append(resps, resp)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error streaming value: %v", err)
+	}
+
+	var got []byte
+	for _, resp := range resps {
+		got = append(got, resp.Value...)
+	}
+	if len(resps) != 5 || resps[0].Version != version || !bytes.Equal(got, val) {
+		t.Fatalf("Unexpected streamed value in %v chunks", len(resps))
+	}
+
+	// Reads between nodes are streamed too.
+	ctx := context.Background()
+	if entry, err := node1.get(ctx, key); err != nil {
+		t.Fatalf("Unexpected error getting value: %v", err)
+	} else if !bytes.Equal(entry.Val, val) || entry.Version != version {
+		t.Fatal("Unexpected value from owner")
+	}
+	if entry, err := node2.getReplicaRPC(ctx, node1.Node, key); err != nil {
+		t.Fatalf("Unexpected error getting replica: %v", err)
+	} else if !bytes.Equal(entry.Val, val) {
+		t.Fatal("Unexpected value of replica")
+	}
+	bucket, err := merkleBucket(node1.config, node1.id, node2.id, key)
+	if err != nil {
+		t.Fatalf("Unexpected error finding bucket: %v", err)
+	}
+	entries, err := node1.getBucketsRPC(ctx, node2.Node, node1.Id, node2.Id, []uint32{bucket})
+	if err != nil {
+		t.Fatalf("Unexpected error getting buckets: %v", err)
+	} else if len(entries) != 1 || !bytes.Equal(entries[key].Val, val) {
+		t.Fatalf("Unexpected buckets with %v keys", len(entries))
+	}
+
+	// If the node the key is thought to be at cannot send it, it comes from
+	// the copies on that node's successors.
+	from := append([]byte(nil), hashedKey...)
+	from[0] -= 2
+	node1.locations.add(newID(from), node1.Node)
+	got = nil
+	err = node1.getStream(ctx, key, func(resp *gmajpb.GetResponse) error {
+		got = append(got, resp.Value...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error streaming value: %v", err)
+	} else if !bytes.Equal(got, val) {
+		t.Fatal("Unexpected value streamed from replica")
+	}
+
+	if err := Delete(node1, key); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	err = node1.getStream(context.Background(), key, func(*gmajpb.GetResponse) error { return nil })
+	if !isDeleted(err) {
+		t.Fatalf("Expected key to be deleted, got %v", err)
+	}
+}
+
+func TestMaxValueSize(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.MaxValueSize = memMaxMsgSize + ChunkSize
+	node := createDefinedNode(t, nil, nil, WithConfig(&cfg))
+	defer node.Shutdown()
+
+	// Values are streamed to their owners, which stop receiving ones that
+	// are too large.
+	val := make([]byte, cfg.MaxValueSize+1)
+	if _, err := Update(node, "large", val); grpc.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected %v, got %v", codes.ResourceExhausted, err)
+	}
+	if _, err := Update(node, "large", val[:cfg.MaxValueSize]); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+}
+
+func TestRecvPutRequest(t *testing.T) {
+	t.Parallel()
+
+	recv := func(reqs ...*gmajpb.PutRequest) func() (*gmajpb.PutRequest, error) {
+		return func() (*gmajpb.PutRequest, error) {
+			if len(reqs) == 0 {
+				return nil, io.EOF
+			}
+			req := reqs[0]
+			reqs = reqs[1:]
+			return req, nil
+		}
+	}
+
+	got, err := recvPutRequest(recv(
+		&gmajpb.PutRequest{Key: "key", Value: []byte("a"), Mode: gmajpb.PutMode_UPDATE},
+		&gmajpb.PutRequest{Value: []byte("b")},
+		&gmajpb.PutRequest{Value: []byte("c")},
+	), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := &gmajpb.PutRequest{Key: "key", Value: []byte("abc"), Mode: gmajpb.PutMode_UPDATE}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	// Values larger than the maximum are refused as soon as they are.
+	var sent int
+	_, err = recvPutRequest(func() (*gmajpb.PutRequest, error) {
+		if sent++; sent > 3 {
+			t.Fatal("Unexpected request received after the maximum size")
+		}
+		return &gmajpb.PutRequest{Key: "key", Value: []byte("ab")}, nil
+	}, 5)
+	if err != errValueTooLarge {
+		t.Fatalf("Expected %v, got %v", errValueTooLarge, err)
+	}
+}
+
+func TestChunkContext(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.Config
+	cfg.ConnectionTimeout = 200 * time.Millisecond
+	nodeCfg, err := newNodeConfig(&cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error validating configuration: %v", err)
+	}
+	node := &Node{config: nodeCfg}
+
+	// The deadline moves as long as chunks keep moving.
+	ctx, progress, cancel := node.chunkContext(context.Background(), &gmajpb.Node{})
+	defer cancel()
+	for i := 0; i < 5; i++ {
+		time.Sleep(cfg.ConnectionTimeout / 4)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("Unexpected error after %d chunks: %v", i, err)
+		}
+		progress()
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(testTimeout):
+		t.Fatal("Expected the context to time out once chunks stopped")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// It is cancelled along with its parent.
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, _, cancel = node.chunkContext(parent, &gmajpb.Node{})
+	defer cancel()
+	cancelParent()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+}
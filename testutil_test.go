@@ -3,6 +3,7 @@ package gmaj
 import (
 	"io/ioutil"
 	"log"
+	"sort"
 	"testing"
 	"time"
 
@@ -15,6 +16,13 @@ import (
 
 var testTimeout = 1000 * time.Millisecond
 
+// waitTimeout is how long waitFor waits before failing the test.
+var waitTimeout = 10 * testTimeout
+
+// testNetwork is the in-memory network the nodes created by the helpers below
+// run on.
+var testNetwork = NewMemNetwork()
+
 func init() {
 	grpclog.SetLogger(log.New(ioutil.Discard, "", 0))
 
@@ -23,7 +31,15 @@ func init() {
 		IDLength:              1, // key length bytes
 		FixNextFingerInterval: 25 * time.Millisecond,
 		StabilizeInterval:     50 * time.Millisecond,
+		CheckPredInterval:     50 * time.Millisecond,
+		AntiEntropyInterval:   100 * time.Millisecond,
+		ReapInterval:          50 * time.Millisecond,
+		TxnTimeout:            5 * time.Second,
+		ConnectionTimeout:     500 * time.Millisecond,
 		RetryInterval:         75 * time.Millisecond,
+		SuccessorListSize:     3,
+		ReplicationFactor:     2,
+		LocationCacheSize:     16,
 		DialOptions: []grpc.DialOption{
 			grpc.WithInsecure(),
 		},
@@ -37,15 +53,15 @@ func init() {
 
 // Generally useful testing helper functions. Creates three successive nodes
 // with ids 0 (node1), 10 (node2) and 20 (node3).
-func create3SuccessiveNodes(t *testing.T) (*Node, *Node, *Node) {
+func create3SuccessiveNodes(t *testing.T, opts ...NodeOption) (*Node, *Node, *Node) {
 	definedID := make([]byte, config.IDLength)
-	node1 := createDefinedNode(t, nil, definedID)
+	node1 := createDefinedNode(t, nil, definedID, opts...)
 	definedID = make([]byte, config.IDLength)
 	definedID[0] = 55
-	node2 := createDefinedNode(t, node1.Node, definedID)
+	node2 := createDefinedNode(t, node1.Node, definedID, opts...)
 	definedID = make([]byte, config.IDLength)
 	definedID[0] = 0xaa
-	node3 := createDefinedNode(t, node1.Node, definedID)
+	node3 := createDefinedNode(t, node1.Node, definedID, opts...)
 	return node1, node2, node3
 }
 
@@ -53,11 +69,111 @@ func createSimpleNode(t *testing.T, ring *gmajpb.Node) *Node {
 	return createDefinedNode(t, ring, nil)
 }
 
-func createDefinedNode(t *testing.T, ring *gmajpb.Node, id []byte) *Node {
-	node, err := NewNode(ring, WithID(id))
+func createDefinedNode(t *testing.T, ring *gmajpb.Node, id []byte, opts ...NodeOption) *Node {
+	opts = append([]NodeOption{WithID(id), WithTransport(testNetwork.Transport())}, opts...)
+	node, err := NewNode(ring, opts...)
 	if err != nil {
 		t.Fatalf("Unable to create node, received error:%v", err)
 	}
 
 	return node
 }
+
+// forEachLookupMode runs f in parallel subtests, once for each lookup mode,
+// with an option that makes nodes use it.
+func forEachLookupMode(t *testing.T, f func(t *testing.T, opt NodeOption)) {
+	for _, mode := range []gmajcfg.LookupMode{gmajcfg.IterativeLookup, gmajcfg.RecursiveLookup} {
+		cfg := config.Config
+		cfg.LookupMode = mode
+		t.Run(mode.String(), func(t *testing.T) {
+			t.Parallel()
+			f(t, WithConfig(&cfg))
+		})
+	}
+}
+
+// storeContents returns all the key/value pairs in store, leaving out
+// tombstones.
+func storeContents(t *testing.T, store Store) map[string][]byte {
+	contents := make(map[string][]byte)
+	err := store.Range(ID{}, ID{}, func(key string, entry Entry) bool {
+		if !entry.Deleted {
+			contents[key] = entry.Val
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error reading store: %v", err)
+	}
+
+	return contents
+}
+
+// crashNode stops a node without any of the cleanup done by Shutdown,
+// simulating a failure.
+func crashNode(node *Node) {
+	node.stopTasks()
+	node.host.stop()
+}
+
+// waitFor polls cond until it is true, and fails the test if that takes longer
+// than waitTimeout. Unlike sleeping for a fixed time, it does not depend on how
+// fast the tests run, e.g. under the race detector.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(waitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %v", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForRing waits until nodes, which must be all the nodes of a ring, have
+// the right successors, predecessors and fingers.
+func waitForRing(t *testing.T, nodes ...*Node) {
+	waitFor(t, "the ring to stabilize", func() bool { return ringStable(nodes) })
+}
+
+// ringStable returns if the successors, predecessors and fingers of nodes are
+// the ones they would be in a ring of just those nodes.
+func ringStable(nodes []*Node) bool {
+	sorted := append([]*Node(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].id.cmp(sorted[j].id) < 0 })
+
+	// successor returns the node that owns id.
+	successor := func(id ID) *Node {
+		for _, node := range sorted {
+			if node.id.cmp(id) >= 0 {
+				return node
+			}
+		}
+		return sorted[0]
+	}
+
+	for i, node := range sorted {
+		succ := sorted[(i+1)%len(sorted)]
+		pred := sorted[(i+len(sorted)-1)%len(sorted)]
+
+		node.succMtx.RLock()
+		ok := node.successor != nil && idsEqual(node.successor.Id, succ.Id)
+		node.succMtx.RUnlock()
+
+		node.predMtx.RLock()
+		ok = ok && node.predecessor != nil && idsEqual(node.predecessor.Id, pred.Id)
+		node.predMtx.RUnlock()
+
+		node.ftMtx.RLock()
+		for j, finger := range node.fingerTable {
+			want := successor(node.config.fingerMath(node.id, j))
+			ok = ok && !finger.suspect && idsEqual(finger.RemoteNode.Id, want.Id)
+		}
+		node.ftMtx.RUnlock()
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
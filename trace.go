@@ -0,0 +1,31 @@
+//
+//  tracing of the hops lookups take around the ring
+//
+
+package gmaj
+
+import (
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+)
+
+// lookupTrace records the hops of a lookup. A nil *lookupTrace records
+// nothing, so lookups that are not traced don't pay for it.
+type lookupTrace struct {
+	hops []*gmajpb.Hop
+}
+
+// add records that the lookup got to node through the given finger (-1 for a
+// successor pointer) in a step that began at start.
+func (trace *lookupTrace) add(node *gmajpb.Node, finger int, start time.Time) {
+	if trace == nil {
+		return
+	}
+
+	trace.hops = append(trace.hops, &gmajpb.Hop{
+		Node:      node,
+		Finger:    int32(finger),
+		LatencyNs: int64(time.Since(start)),
+	})
+}
@@ -0,0 +1,130 @@
+//
+//  abstracts how RPCs get from one node to another, so that nodes can talk
+//  over gRPC or, in tests, over an in-memory network
+//
+
+package gmaj
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/r-medina/gmaj/gmajpb"
+	"github.com/r-medina/gmaj/internal/chord"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Server handles the RPCs that a Transport receives.
+type Server interface {
+	chord.ChordServer
+	gmajpb.GMajServer
+}
+
+// Transport carries RPCs between nodes. Each host (i.e. each call to NewNode or
+// NewVirtualNodes) needs a transport of its own.
+type Transport interface {
+	// Listen starts passing the RPCs sent to addr on to srv. It returns the
+	// address it listens on, which may differ from addr (e.g. when addr does
+	// not have a port).
+	Listen(addr string, srv Server) (string, error)
+	// Dial returns a client for the server listening on addr.
+	Dial(ctx context.Context, addr string) (chord.ChordClient, error)
+	// Close stops serving RPCs and closes the connections made by Dial.
+	Close() error
+}
+
+var errTransportClosed = errors.New("gmaj: transport has been closed")
+
+// grpcTransport is the default transport, which serves and dials gRPC over
+// TCP.
+type grpcTransport struct {
+	config     *nodeConfig
+	serverOpts []grpc.ServerOption
+	dialOpts   []grpc.DialOption
+
+	grpcs *grpc.Server
+
+	clientConns map[string]*clientConn
+	connMtx     sync.RWMutex
+}
+
+var _ Transport = (*grpcTransport)(nil)
+
+type clientConn struct {
+	client chord.ChordClient
+	conn   *grpc.ClientConn
+}
+
+func newGRPCTransport(cfg *nodeConfig, opts nodeOptions) *grpcTransport {
+	return &grpcTransport{
+		config:      cfg,
+		serverOpts:  opts.serverOpts,
+		dialOpts:    opts.dialOpts,
+		clientConns: make(map[string]*clientConn),
+	}
+}
+
+// Listen starts a gRPC server on addr.
+func (t *grpcTransport) Listen(addr string, srv Server) (string, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	t.grpcs = grpc.NewServer(t.serverOpts...)
+	chord.RegisterChordServer(t.grpcs, srv)
+	gmajpb.RegisterGMajServer(t.grpcs, srv)
+
+	go t.grpcs.Serve(lis)
+
+	return lis.Addr().String(), nil
+}
+
+// Dial returns a client for addr, reusing the connection to it if there
+// already is one.
+func (t *grpcTransport) Dial(ctx context.Context, addr string) (chord.ChordClient, error) {
+	// Dial the server if we don't already have a connection to it
+	t.connMtx.RLock()
+	cc, ok := t.clientConns[addr]
+	t.connMtx.RUnlock()
+	if ok {
+		return cc.client, nil
+	}
+
+	conn, err := dial(ctx, t.config, addr, t.dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := chord.NewChordClient(conn)
+	cc = &clientConn{client, conn}
+	t.connMtx.Lock()
+	if t.clientConns == nil {
+		t.connMtx.Unlock()
+		_ = conn.Close()
+		return nil, errTransportClosed
+	}
+	t.clientConns[addr] = cc
+	t.connMtx.Unlock()
+
+	return client, nil
+}
+
+// Close stops the gRPC server and closes all the client connections.
+func (t *grpcTransport) Close() error {
+	if t.grpcs != nil {
+		t.grpcs.Stop()
+	}
+
+	t.connMtx.Lock()
+	for _, cc := range t.clientConns {
+		_ = cc.conn.Close()
+	}
+	t.clientConns = nil
+	t.connMtx.Unlock()
+
+	return nil
+}
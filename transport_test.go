@@ -0,0 +1,69 @@
+package gmaj
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+// TestGRPCTransport runs a ring over real gRPC connections, which the other
+// tests replace with the in-memory transport. It does not run in parallel with
+// the other tests, so that its RPCs are not slowed down past their timeouts.
+func TestGRPCTransport(t *testing.T) {
+	newGRPCNode := func(ring *gmajpb.Node, id byte) *Node {
+		node, err := NewNode(ring, WithID([]byte{id}), WithAddress("127.0.0.1:0"))
+		if err != nil {
+			t.Fatalf("Unable to create node, received error:%v", err)
+		}
+
+		return node
+	}
+
+	node1 := newGRPCNode(nil, 0)
+	defer node1.Shutdown()
+	node2 := newGRPCNode(node1.Node, 55)
+	node3 := newGRPCNode(node1.Node, 0xaa)
+	defer node3.Shutdown()
+
+	waitForRing(t, node1, node2, node3)
+
+	ctx := context.Background()
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("grpc%d", i)
+		if err := Put(node1, keys[i], []byte(keys[i])); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+
+	if version, err := Update(node2, keys[0], []byte("new")); err != nil || version != 2 {
+		t.Fatalf("Expected update to succeed, got %v, %v", version, err)
+	}
+	if err := Delete(node3, keys[1]); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	if _, err := Get(node1, keys[1]); !isDeleted(err) {
+		t.Fatalf("Expected %q to be deleted, got %v", keys[1], err)
+	}
+
+	// The keys of a node that shuts down move to its successor.
+	node2.Shutdown()
+	waitForRing(t, node1, node3)
+
+	if err := node1.pingRPC(ctx, node2.Node); err == nil {
+		t.Fatal("Unexpected success pinging node that was shut down")
+	}
+	for _, key := range keys[2:] {
+		if got, err := Get(node3, key); err != nil {
+			t.Fatalf("Unexpected error getting %q: %v", key, err)
+		} else if string(got) != key {
+			t.Fatalf("Unexpected value. Expected %q got %q", key, got)
+		}
+	}
+	if got, version, err := GetVersion(node1, keys[0]); err != nil || string(got) != "new" || version != 2 {
+		t.Fatalf("Expected updated value, got %q, %v, %v", got, version, err)
+	}
+}
@@ -0,0 +1,693 @@
+//
+//  writes several keys atomically with two-phase commit. The node that a
+//  client asks coordinates the transaction, and the nodes that own the keys
+//  lock them while their parts of it are prepared
+//
+
+package gmaj
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	errEmptyTxn = grpc.Errorf(codes.InvalidArgument, "gmaj: transaction has no operations")
+
+	// errLocked is returned for writes to keys that a prepared transaction
+	// is about to write.
+	errLocked = grpc.Errorf(codes.Aborted, "gmaj: key is locked by a transaction")
+
+	// errNotPrepared is returned when committing a transaction that the node
+	// has not prepared, or that was aborted.
+	errNotPrepared = grpc.Errorf(codes.Aborted, "gmaj: transaction is not prepared")
+)
+
+// The records in a node's TxnStore are kept under these prefixes of the IDs of
+// their transactions. A prepared record is the PrepareReq of a transaction the
+// node has prepared its part of, with the versions its keys had then set on
+// their key/values. A committed record is the participants of a
+// transaction the node coordinates and has decided to commit.
+const (
+	preparedPrefix  = "prepared/"
+	committedPrefix = "committed/"
+)
+
+// txnLocks are the transactions a node has prepared, and the keys they lock,
+// as well as the transactions it coordinates. They are guarded by dsMtx.
+type txnLocks struct {
+	prepared map[string]*preparedTxn // by transaction ID
+	locks    map[string]string       // transaction IDs, by key
+	resolved map[string][]uint64     // versions of transactions committed before being told to
+	running  map[string]bool         // transactions the node is coordinating
+}
+
+// preparedTxn is a node's part of a transaction.
+type preparedTxn struct {
+	keyVals     []*gmajpb.KeyVal // with the versions the keys had when prepared
+	ids         []ID             // the IDs of the keys
+	coordinator *gmajpb.Node
+	check       time.Time // when to ask the coordinator for the outcome, if ever
+}
+
+// add locks the keys of a transaction.
+func (txns *txnLocks) add(txnID string, txn *preparedTxn) {
+	if txns.prepared == nil {
+		txns.prepared = make(map[string]*preparedTxn)
+		txns.locks = make(map[string]string)
+	}
+
+	txns.prepared[txnID] = txn
+	for _, keyVal := range txn.keyVals {
+		txns.locks[keyVal.Key] = txnID
+	}
+}
+
+// remove unlocks the keys of a transaction, and returns it. It returns nil if
+// there is no such transaction.
+func (txns *txnLocks) remove(txnID string) *preparedTxn {
+	txn, ok := txns.prepared[txnID]
+	if !ok {
+		return nil
+	}
+
+	delete(txns.prepared, txnID)
+	for _, keyVal := range txn.keyVals {
+		delete(txns.locks, keyVal.Key)
+	}
+
+	return txn
+}
+
+// setRunning records whether the node is coordinating a transaction.
+func (txns *txnLocks) setRunning(txnID string, running bool) {
+	if txns.running == nil {
+		txns.running = make(map[string]bool)
+	}
+
+	if running {
+		txns.running[txnID] = true
+	} else {
+		delete(txns.running, txnID)
+	}
+}
+
+// checkLock returns errLocked if a prepared transaction locks key. A prepared
+// transaction never times out on its own, since the node has promised its
+// coordinator to do the writes; only the coordinator can unlock the keys. It
+// must be called with dsMtx held.
+func (node *Node) checkLock(key string) error {
+	if _, ok := node.txns.locks[key]; ok {
+		return errLocked
+	}
+
+	return nil
+}
+
+// newPreparedTxn returns the node's part of the transaction that req prepares.
+func (node *Node) newPreparedTxn(req *gmajpb.PrepareReq) (*preparedTxn, error) {
+	txn := &preparedTxn{
+		keyVals:     req.KeyVals,
+		ids:         make([]ID, len(req.KeyVals)),
+		coordinator: req.Coordinator,
+	}
+	for i, keyVal := range req.KeyVals {
+		id, err := node.config.keyID(keyVal.Key)
+		if err != nil {
+			return nil, err
+		}
+		txn.ids[i] = id
+	}
+
+	return txn, nil
+}
+
+// prepare checks that the writes of the node's part of a transaction can be
+// done, and locks their keys until the transaction is committed or aborted.
+// The transaction is recorded in the TxnStore first, so that its keys stay
+// locked if the node restarts.
+func (node *Node) prepare(req *gmajpb.PrepareReq) error {
+	if node.datastore == nil {
+		return errNoDatastore
+	}
+
+	for _, keyVal := range req.KeyVals {
+		if keyVal.CheckOwner {
+			if err := node.checkOwner(keyVal.Key); err != nil {
+				return err
+			}
+		}
+	}
+
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	if _, ok := node.txns.prepared[req.TxnId]; ok {
+		// The prepare was retried.
+		return nil
+	}
+
+	// The versions the keys have are kept, so that a commit that fails part
+	// of the way can tell which keys it wrote when it is tried again.
+	req = proto.Clone(req).(*gmajpb.PrepareReq)
+	now := node.clock.Now()
+	for _, keyVal := range req.KeyVals {
+		if err := node.checkLock(keyVal.Key); err != nil {
+			return err
+		}
+		if err := checkValueSize(len(keyVal.Val), node.config.MaxValueSize); err != nil {
+			return err
+		}
+
+		old, exists, err := node.latest(keyVal.Key)
+		if err != nil {
+			return err
+		}
+		if _, _, err := checkWrite(keyVal, old, exists, now); err != nil {
+			return err
+		}
+		keyVal.Version = old.Version
+	}
+
+	txn, err := node.newPreparedTxn(req)
+	if err != nil {
+		return err
+	}
+
+	record, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := node.txnStore.Put(node.id, preparedPrefix+req.TxnId, Entry{Val: record}); err != nil {
+		return err
+	}
+
+	if txn.coordinator != nil && node.config.TxnTimeout > 0 {
+		txn.check = now.Add(node.config.TxnTimeout)
+	}
+	node.txns.add(req.TxnId, txn)
+
+	return nil
+}
+
+// loadTxns locks the keys of the transactions the node had prepared before it
+// restarted. Their coordinators are asked for their outcomes right away, since
+// they may have been decided while the node was down.
+func (node *Node) loadTxns() error {
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	now := node.clock.Now()
+	var loadErr error
+	err := node.txnStore.Range(node.id, node.id, func(key string, entry Entry) bool {
+		if !strings.HasPrefix(key, preparedPrefix) {
+			return true
+		}
+
+		req := &gmajpb.PrepareReq{}
+		if loadErr = proto.Unmarshal(entry.Val, req); loadErr != nil {
+			return false
+		}
+
+		var txn *preparedTxn
+		if txn, loadErr = node.newPreparedTxn(req); loadErr != nil {
+			return false
+		}
+		if txn.coordinator != nil && node.config.TxnTimeout > 0 {
+			txn.check = now
+		}
+		node.txns.add(req.TxnId, txn)
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return loadErr
+}
+
+// commit does the writes of a prepared transaction, and returns the versions
+// of its keys. Keys that moved to another node while they were locked are
+// handed over to it afterwards.
+func (node *Node) commit(ctx context.Context, txnID string) ([]uint64, error) {
+	return node.commitTxn(ctx, txnID, false)
+}
+
+// commitTxn is commit. If keep is set, the versions are kept for when the
+// coordinator tells the node to commit, since the node is committing without
+// having been told to.
+func (node *Node) commitTxn(ctx context.Context, txnID string, keep bool) ([]uint64, error) {
+	node.dsMtx.Lock()
+	if versions, ok := node.txns.resolved[txnID]; ok {
+		delete(node.txns.resolved, txnID)
+		node.dsMtx.Unlock()
+		return versions, nil
+	}
+
+	txn, ok := node.txns.prepared[txnID]
+	if !ok {
+		node.dsMtx.Unlock()
+		return nil, errNotPrepared
+	}
+
+	entries, written, err := node.writeTxn(txn)
+	if err == nil {
+		err = node.txnStore.Delete(preparedPrefix + txnID)
+	}
+	if err != nil {
+		// The transaction stays prepared, with its keys locked, so that it
+		// can be committed again.
+		node.dsMtx.Unlock()
+		return nil, err
+	}
+
+	node.txns.remove(txnID)
+	versions := make([]uint64, len(entries))
+	for i, entry := range entries {
+		versions[i] = entry.Version
+	}
+	if keep {
+		if node.txns.resolved == nil {
+			node.txns.resolved = make(map[string][]uint64)
+		}
+		node.txns.resolved[txnID] = versions
+	}
+	node.dsMtx.Unlock()
+
+	// The node may have stopped owning some of the keys since they were
+	// prepared, in which case the owner gets them rather than copies.
+	for i, keyVal := range txn.keyVals {
+		if node.checkOwner(keyVal.Key) != nil {
+			node.handOff(ctx, keyVal.Key)
+		} else if written[i] {
+			node.replicate(ctx, keyVal.Key, entries[i])
+		}
+	}
+
+	return versions, nil
+}
+
+// writeTxn does the writes of a prepared transaction, and returns the entries
+// its keys end up with and which of them the transaction wrote. The keys have
+// been locked since the writes were checked, so only the transaction writes
+// them: a key whose version is not the one it was prepared with was written by
+// an earlier commit that failed part of the way. Every entry is worked out
+// before any is written, so that only the datastore can fail the writes. It
+// must be called with dsMtx held.
+func (node *Node) writeTxn(txn *preparedTxn) ([]Entry, []bool, error) {
+	now := node.clock.Now()
+	entries := make([]Entry, len(txn.keyVals))
+	written := make([]bool, len(txn.keyVals))
+	toWrite := make([]bool, len(txn.keyVals))
+	replaced := make([]bool, len(txn.keyVals))
+	for i, keyVal := range txn.keyVals {
+		old, exists, err := node.latest(keyVal.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[i] = old
+		if exists && old.Version != keyVal.Version {
+			written[i] = true
+			continue
+		}
+
+		// The write passed the check when it was prepared, and it can only
+		// fail it now if the key has expired since. It is done as of then.
+		var retry bool
+		replaced[i], retry, _ = checkWrite(keyVal, old, exists, now)
+		if !retry {
+			entries[i] = node.nextEntry(keyVal, old, now)
+			written[i], toWrite[i] = true, true
+		}
+	}
+
+	for i, keyVal := range txn.keyVals {
+		if !toWrite[i] {
+			continue
+		}
+		if err := node.storeEntry(txn.ids[i], keyVal.Key, entries[i], true, replaced[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return entries, written, nil
+}
+
+// abort forgets a prepared transaction and unlocks its keys. Keys that moved
+// to another node while they were locked are handed over to it.
+func (node *Node) abort(ctx context.Context, txnID string) error {
+	node.dsMtx.Lock()
+	txn := node.txns.remove(txnID)
+	var err error
+	if txn != nil {
+		err = node.txnStore.Delete(preparedPrefix + txnID)
+	}
+	node.dsMtx.Unlock()
+	if txn == nil || err != nil {
+		return err
+	}
+
+	for _, keyVal := range txn.keyVals {
+		if node.checkOwner(keyVal.Key) != nil {
+			node.handOff(ctx, keyVal.Key)
+		}
+	}
+
+	return nil
+}
+
+// handOff transfers a key the node no longer owns to the node that does. Keys
+// locked by a transaction are not transferred with the rest of their range, so
+// that the transaction can still write them, and are handed off once it ends.
+func (node *Node) handOff(ctx context.Context, key string) {
+	node.dsMtx.RLock()
+	entry, ok, err := node.datastore.Get(key)
+	node.dsMtx.RUnlock()
+	if err == nil && ok {
+		var owner *gmajpb.Node
+		owner, err = node.locate(ctx, key)
+		if err == nil && !idsEqual(owner.Id, node.Id) {
+			err = node.transferKey(ctx, key, entry, owner)
+		}
+	}
+	if err != nil {
+		node.config.Log.Printf("handing off key %q failed: %v", key, err)
+	}
+}
+
+// txnStatus returns the outcome of a transaction the node coordinates. The
+// decision to commit is recorded before any part of a transaction commits, so
+// a transaction that is neither running nor recorded as committed was
+// aborted.
+func (node *Node) txnStatus(txnID string) (gmajpb.TxnStatus_Outcome, error) {
+	node.dsMtx.RLock()
+	defer node.dsMtx.RUnlock()
+
+	if _, ok, err := node.txnStore.Get(committedPrefix + txnID); err != nil {
+		return 0, err
+	} else if ok {
+		return gmajpb.TxnStatus_COMMITTED, nil
+	}
+
+	if node.txns.running[txnID] {
+		return gmajpb.TxnStatus_PENDING, nil
+	}
+
+	return gmajpb.TxnStatus_ABORTED, nil
+}
+
+// resolveTxns finishes the transactions that were left unfinished: the ones
+// the node prepared and has not been told the outcomes of in time, which it
+// asks their coordinators about, and the ones it decided to commit as their
+// coordinator but has not committed on every participant.
+func (node *Node) resolveTxns() {
+	ctx := context.Background()
+	now := node.clock.Now()
+
+	unresolved := make(map[string]*gmajpb.Node)
+	committing := make(map[string][]*gmajpb.Node)
+	var decodeErr error
+
+	node.dsMtx.Lock()
+	for txnID, txn := range node.txns.prepared {
+		if !txn.check.IsZero() && !now.Before(txn.check) {
+			unresolved[txnID] = txn.coordinator
+			txn.check = now.Add(node.config.TxnTimeout)
+		}
+	}
+	err := node.txnStore.Range(node.id, node.id, func(key string, entry Entry) bool {
+		txnID := strings.TrimPrefix(key, committedPrefix)
+		if txnID == key || node.txns.running[txnID] {
+			return true
+		}
+
+		participants := &gmajpb.Nodes{}
+		if decodeErr = proto.Unmarshal(entry.Val, participants); decodeErr != nil {
+			return false
+		}
+		committing[txnID] = participants.Nodes
+
+		return true
+	})
+	node.dsMtx.Unlock()
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		node.config.Log.Printf("reading committed transactions failed: %v", err)
+	}
+
+	for txnID, coordinator := range unresolved {
+		node.resolveTxn(ctx, txnID, coordinator)
+	}
+
+	for txnID, participants := range committing {
+		if node.commitParticipants(ctx, txnID, participants) {
+			node.forgetCommitted(txnID)
+		}
+	}
+}
+
+// resolveTxn asks the coordinator of a transaction the node prepared for its
+// outcome, and commits or aborts it accordingly. If the coordinator cannot be
+// reached or has not decided, the transaction stays prepared and is asked
+// about again later.
+func (node *Node) resolveTxn(ctx context.Context, txnID string, coordinator *gmajpb.Node) {
+	outcome, err := node.txnStatusRPC(ctx, coordinator, txnID)
+	switch {
+	case err != nil:
+	case outcome == gmajpb.TxnStatus_COMMITTED:
+		_, err = node.commitTxn(ctx, txnID, true)
+	case outcome == gmajpb.TxnStatus_ABORTED:
+		err = node.abort(ctx, txnID)
+	}
+	if err != nil {
+		node.config.Log.Printf("resolving transaction %v with %v failed: %v",
+			txnID, coordinator.Addr, err,
+		)
+	}
+}
+
+// commitParticipants tells the participants of a transaction that was
+// decided to commit to commit it, and returns whether they all have. A
+// participant that no longer has the transaction prepared committed it
+// already, after asking the coordinator.
+func (node *Node) commitParticipants(
+	ctx context.Context, txnID string, participants []*gmajpb.Node,
+) bool {
+	done := true
+	for _, participant := range participants {
+		_, err := node.commitRPC(ctx, participant, txnID)
+		if err != nil && grpc.Code(err) != codes.Aborted {
+			node.config.Log.Printf("committing transaction %v on %v failed: %v",
+				txnID, participant.Addr, err,
+			)
+			done = false
+		}
+	}
+
+	return done
+}
+
+// forgetCommitted removes the record that the node decided to commit a
+// transaction, once every participant has committed it.
+func (node *Node) forgetCommitted(txnID string) {
+	node.dsMtx.Lock()
+	err := node.txnStore.Delete(committedPrefix + txnID)
+	node.dsMtx.Unlock()
+	if err != nil {
+		node.config.Log.Printf("forgetting transaction %v failed: %v", txnID, err)
+	}
+}
+
+// txn runs a transaction of writes from a client with two-phase commit, with
+// the node as the coordinator: the nodes that own the keys prepare their parts
+// of it, and only if they all do are they told to commit. It returns the
+// versions of the keys. Like put, it tries again if the owners of the keys may
+// have changed.
+func (node *Node) txn(ctx context.Context, keyVals []*gmajpb.KeyVal) ([]uint64, error) {
+	if len(keyVals) == 0 {
+		return nil, errEmptyTxn
+	}
+
+	keys := make([]string, len(keyVals))
+	seen := make(map[string]bool)
+	for i, keyVal := range keyVals {
+		if seen[keyVal.Key] {
+			return nil, grpc.Errorf(codes.InvalidArgument,
+				"gmaj: key %q is in the transaction more than once", keyVal.Key,
+			)
+		}
+		seen[keyVal.Key] = true
+
+		keyVal.CheckOwner = true
+		keys[i] = keyVal.Key
+	}
+
+	versions, prepared, err := node.tryTxn(ctx, keys, keyVals)
+	if err == nil || prepared || isConflict(err) {
+		return versions, err
+	}
+
+	versions, _, err = node.tryTxn(ctx, keys, keyVals)
+	return versions, err
+}
+
+// tryTxn runs a transaction once. It returns whether the transaction was
+// prepared, after which it cannot be tried again.
+func (node *Node) tryTxn(
+	ctx context.Context, keys []string, keyVals []*gmajpb.KeyVal,
+) ([]uint64, bool, error) {
+	txnID, err := newTxnID()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// While the transaction runs, participants that ask about it are told to
+	// wait for the outcome.
+	node.dsMtx.Lock()
+	node.txns.setRunning(txnID, true)
+	node.dsMtx.Unlock()
+	defer func() {
+		node.dsMtx.Lock()
+		node.txns.setRunning(txnID, false)
+		node.dsMtx.Unlock()
+	}()
+
+	var (
+		firstErr error
+		errMtx   sync.Mutex
+	)
+	setErr := func(err error) {
+		errMtx.Lock()
+		// Conflicts are the errors the client can do something about.
+		if firstErr == nil || (isConflict(err) && !isConflict(firstErr)) {
+			firstErr = err
+		}
+		errMtx.Unlock()
+	}
+
+	batches := node.batchKeys(ctx, keys, func(_ int, err error) { setErr(err) })
+	if firstErr != nil {
+		return nil, false, firstErr
+	}
+
+	eachBatch(batches, func(b *batch) {
+		req := &gmajpb.PrepareReq{
+			TxnId:       txnID,
+			KeyVals:     make([]*gmajpb.KeyVal, len(b.indexes)),
+			Coordinator: node.Node,
+		}
+		for j, i := range b.indexes {
+			req.KeyVals[j] = keyVals[i]
+		}
+
+		err := node.prepareRPC(ctx, b.owner, req)
+		if err != nil && !isConflict(err) {
+			node.locations.invalidate(b.owner)
+		}
+		if err != nil {
+			setErr(err)
+		}
+	})
+	if firstErr == nil {
+		// Every part of the transaction is prepared, so it commits. The
+		// decision is recorded first, so that it outlives the node.
+		if err := node.decideCommit(txnID, batches); err != nil {
+			setErr(err)
+		}
+	}
+	if firstErr != nil {
+		// Abort on every node, since a node may have prepared its part even
+		// if the RPC failed. Nodes that are not told ask the coordinator.
+		eachBatch(batches, func(b *batch) {
+			if err := node.abortRPC(ctx, b.owner, txnID); err != nil {
+				node.config.Log.Printf("aborting transaction %v on %v failed: %v",
+					txnID, b.owner.Addr, err,
+				)
+			}
+		})
+
+		return nil, false, firstErr
+	}
+
+	// The participants cannot back out of the transaction, so the commits
+	// are retried until they succeed. If the client gives up first, they are
+	// finished by resolveTxns.
+	versions := make([]uint64, len(keys))
+	eachBatch(batches, func(b *batch) {
+		for {
+			got, err := node.commitRPC(ctx, b.owner, txnID)
+			if err == nil && len(got) != len(b.indexes) {
+				err = errBatchResults
+			}
+			if err == nil {
+				for j, i := range b.indexes {
+					versions[i] = got[j]
+				}
+				return
+			}
+
+			if grpc.Code(err) == codes.Aborted {
+				// The node committed the transaction after asking about
+				// it, and then restarted, losing the versions.
+				setErr(fmt.Errorf("gmaj: versions of transaction %v are not known: %v", txnID, err))
+				return
+			}
+			if !node.sleep(ctx, node.config.RetryInterval) {
+				setErr(fmt.Errorf("gmaj: transaction %v is not committed on every node yet: %v",
+					txnID, err,
+				))
+				return
+			}
+		}
+	})
+	if firstErr != nil {
+		return nil, true, firstErr
+	}
+
+	node.forgetCommitted(txnID)
+
+	return versions, true, nil
+}
+
+// decideCommit records that the node, as the coordinator of a transaction,
+// decided to commit it, along with the participants to commit it on.
+func (node *Node) decideCommit(txnID string, batches []*batch) error {
+	participants := &gmajpb.Nodes{Nodes: make([]*gmajpb.Node, len(batches))}
+	for i, b := range batches {
+		participants.Nodes[i] = b.owner
+	}
+
+	record, err := proto.Marshal(participants)
+	if err != nil {
+		return err
+	}
+
+	node.dsMtx.Lock()
+	defer node.dsMtx.Unlock()
+
+	return node.txnStore.Put(node.id, committedPrefix+txnID, Entry{Val: record})
+}
+
+// newTxnID returns a random ID for a transaction.
+func newTxnID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
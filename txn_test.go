@@ -0,0 +1,357 @@
+package gmaj
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestTxn(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	<-time.After(testTimeout)
+
+	ctx := context.Background()
+	req := &gmajpb.TxnRequest{}
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("txn%d", i)
+		req.Ops = append(req.Ops, &gmajpb.TxnOp{Key: key, Value: []byte(key)})
+	}
+	resp, err := node1.Txn(ctx, req)
+	if err != nil {
+		t.Fatalf("Unexpected error running transaction: %v", err)
+	}
+	want := []uint64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	if !reflect.DeepEqual(resp.Versions, want) {
+		t.Fatalf("Expected versions %v, got %v", want, resp.Versions)
+	}
+
+	// A transaction with an operation that fails changes nothing.
+	_, err = node2.Txn(ctx, &gmajpb.TxnRequest{Ops: []*gmajpb.TxnOp{
+		{Key: "txn1", Value: []byte("new"), Mode: gmajpb.PutMode_UPDATE},
+		{Key: "txn2", Delete: true},
+		{Key: "txn3", Value: []byte("new"), Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 2},
+	}})
+	if grpc.Code(err) != codes.Aborted {
+		t.Fatalf("Expected transaction to be aborted, got %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		key := fmt.Sprintf("txn%d", i)
+		val, version, err := GetVersion(node1, key)
+		if err != nil || string(val) != key || version != 1 {
+			t.Fatalf("Expected %q to be unchanged, got %q, %v, %v", key, val, version, err)
+		}
+	}
+
+	resp, err = node2.Txn(ctx, &gmajpb.TxnRequest{Ops: []*gmajpb.TxnOp{
+		{Key: "txn1", Value: []byte("new"), Mode: gmajpb.PutMode_UPDATE},
+		{Key: "txn2", Delete: true},
+		{Key: "txn3", Value: []byte("new"), Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error running transaction: %v", err)
+	}
+	if want := []uint64{2, 2, 2}; !reflect.DeepEqual(resp.Versions, want) {
+		t.Fatalf("Expected versions %v, got %v", want, resp.Versions)
+	}
+	if _, err := Get(node1, "txn2"); !isDeleted(err) {
+		t.Fatalf("Expected txn2 to be deleted, got %v", err)
+	}
+	if val, err := Get(node1, "txn3"); err != nil || string(val) != "new" {
+		t.Fatalf("Expected txn3 to be updated, got %q, %v", val, err)
+	}
+
+	_, err = node3.Txn(ctx, &gmajpb.TxnRequest{Ops: []*gmajpb.TxnOp{
+		{Key: "txn4", Delete: true}, {Key: "txn4", Delete: true},
+	}})
+	if grpc.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected a key twice to be refused, got %v", err)
+	}
+}
+
+func TestTxnLocks(t *testing.T) {
+	t.Parallel()
+
+	node := createDefinedNode(t, nil, nil)
+	defer node.Shutdown()
+
+	ctx := context.Background()
+	if err := Put(node, "locked", []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+
+	prepare := func(txnID string) {
+		err := node.prepare(&gmajpb.PrepareReq{TxnId: txnID, KeyVals: []*gmajpb.KeyVal{
+			{Key: "locked", Val: []byte(txnID), Mode: gmajpb.PutMode_UPDATE},
+		}})
+		if err != nil {
+			t.Fatalf("Unexpected error preparing transaction: %v", err)
+		}
+	}
+
+	// Prepared keys cannot be written until the transaction is aborted.
+	prepare("abort")
+	if _, err := Update(node, "locked", []byte("b")); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	if err := Delete(node, "locked"); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	err := node.prepare(&gmajpb.PrepareReq{TxnId: "other", KeyVals: []*gmajpb.KeyVal{
+		{Key: "locked", Mode: gmajpb.PutMode_UPDATE},
+	}})
+	if err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	if err := node.abort(ctx, "abort"); err != nil {
+		t.Fatalf("Unexpected error aborting transaction: %v", err)
+	}
+	if _, err := node.commit(ctx, "abort"); err != errNotPrepared {
+		t.Fatalf("Expected %v, got %v", errNotPrepared, err)
+	}
+	if version, err := Update(node, "locked", []byte("b")); err != nil || version != 2 {
+		t.Fatalf("Expected update to succeed, got %v, %v", version, err)
+	}
+
+	// Or committed.
+	prepare("commit")
+	if _, err := Update(node, "locked", []byte("c")); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	versions, err := node.commit(ctx, "commit")
+	if err != nil || !reflect.DeepEqual(versions, []uint64{3}) {
+		t.Fatalf("Expected commit to succeed, got %v, %v", versions, err)
+	}
+	if val, err := Get(node, "locked"); err != nil || string(val) != "commit" {
+		t.Fatalf("Expected committed value, got %q, %v", val, err)
+	}
+
+	// A transaction that is not told its outcome keeps its locks until its
+	// coordinator says what the outcome is. The node is the coordinator here,
+	// and it has no record of the transaction, so it was aborted.
+	prepare("unknown")
+	node.dsMtx.Lock()
+	node.txns.prepared["unknown"].coordinator = node.Node
+	node.dsMtx.Unlock()
+	if _, err := Update(node, "locked", []byte("d")); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	node.resolveTxn(ctx, "unknown", node.Node)
+	if version, err := Update(node, "locked", []byte("d")); err != nil || version != 4 {
+		t.Fatalf("Expected update to succeed, got %v, %v", version, err)
+	}
+
+	// If the coordinator decided to commit, the node commits without being
+	// told to, and keeps the versions for when it is.
+	prepare("decided")
+	if err := node.decideCommit("decided", []*batch{{owner: node.Node}}); err != nil {
+		t.Fatalf("Unexpected error deciding to commit: %v", err)
+	}
+	node.resolveTxn(ctx, "decided", node.Node)
+	if val, err := Get(node, "locked"); err != nil || string(val) != "decided" {
+		t.Fatalf("Expected committed value, got %q, %v", val, err)
+	}
+	versions, err = node.commit(ctx, "decided")
+	if err != nil || !reflect.DeepEqual(versions, []uint64{5}) {
+		t.Fatalf("Expected commit to succeed, got %v, %v", versions, err)
+	}
+
+	// And the coordinator finishes the commits it did not get to, and then
+	// forgets the transaction.
+	prepare("unfinished")
+	if err := node.decideCommit("unfinished", []*batch{{owner: node.Node}}); err != nil {
+		t.Fatalf("Unexpected error deciding to commit: %v", err)
+	}
+	node.resolveTxns()
+	if val, err := Get(node, "locked"); err != nil || string(val) != "unfinished" {
+		t.Fatalf("Expected committed value, got %q, %v", val, err)
+	}
+	if outcome, err := node.txnStatus("unfinished"); err != nil || outcome != gmajpb.TxnStatus_ABORTED {
+		t.Fatalf("Expected transaction to be forgotten, got %v, %v", outcome, err)
+	}
+}
+
+// failingStore is a Store that fails to put a key.
+type failingStore struct {
+	Store
+	failKey string
+	mtx     sync.Mutex
+}
+
+var errPutFailed = errors.New("put failed")
+
+func (s *failingStore) Put(id ID, key string, entry Entry) error {
+	s.mtx.Lock()
+	fail := key == s.failKey
+	s.mtx.Unlock()
+	if fail {
+		return errPutFailed
+	}
+
+	return s.Store.Put(id, key, entry)
+}
+
+func (s *failingStore) setFailKey(key string) {
+	s.mtx.Lock()
+	s.failKey = key
+	s.mtx.Unlock()
+}
+
+func TestTxnPartialCommit(t *testing.T) {
+	t.Parallel()
+
+	var datastore *failingStore
+	open := func(id []byte, name string) (Store, error) {
+		if name != DatastoreStore {
+			return newMemStore(), nil
+		}
+		datastore = &failingStore{Store: newMemStore()}
+		return datastore, nil
+	}
+	node := createDefinedNode(t, nil, nil, WithStore(open))
+	defer node.Shutdown()
+
+	for _, key := range []string{"a", "b"} {
+		if err := Put(node, key, []byte(key)); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+	err := node.prepare(&gmajpb.PrepareReq{TxnId: "partial", KeyVals: []*gmajpb.KeyVal{
+		{Key: "a", Val: []byte("new"), Mode: gmajpb.PutMode_UPDATE},
+		{Key: "b", Val: []byte("new"), Mode: gmajpb.PutMode_UPDATE, ExpectedVersion: 1},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error preparing transaction: %v", err)
+	}
+
+	// Copies of locked keys are refused, like writes from clients.
+	ctx := context.Background()
+	transfer := &gmajpb.KeyVal{Key: "b", Val: []byte("copy"), Version: 5, Transfer: true}
+	if _, err := node.putKeyVal(ctx, transfer); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	if err := node.putReplica(transfer); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+
+	// A commit that fails part of the way leaves the transaction prepared.
+	datastore.setFailKey("b")
+	if _, err := node.commit(ctx, "partial"); err != errPutFailed {
+		t.Fatalf("Expected %v, got %v", errPutFailed, err)
+	}
+	if _, err := Update(node, "b", []byte("c")); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+
+	// Committing it again writes the rest, without writing the first key
+	// twice.
+	datastore.setFailKey("")
+	versions, err := node.commit(ctx, "partial")
+	if err != nil || !reflect.DeepEqual(versions, []uint64{2, 2}) {
+		t.Fatalf("Expected commit to succeed, got %v, %v", versions, err)
+	}
+	for _, key := range []string{"a", "b"} {
+		val, version, err := GetVersion(node, key)
+		if err != nil || string(val) != "new" || version != 2 {
+			t.Fatalf("Expected %q to be committed, got %q, %v, %v", key, val, version, err)
+		}
+	}
+}
+
+func TestTxnRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	id := []byte{0x43}
+
+	node := createDefinedNode(t, nil, id, WithStore(DiskStore(dir)))
+	if err := Put(node, "key", []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	err := node.prepare(&gmajpb.PrepareReq{TxnId: "restart", KeyVals: []*gmajpb.KeyVal{
+		{Key: "key", Val: []byte("b"), Mode: gmajpb.PutMode_UPDATE},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error preparing transaction: %v", err)
+	}
+	crashNode(node)
+	node.closeStores()
+
+	// The node still has the transaction prepared after it restarts, so that
+	// it can commit it when it is told to.
+	node = createDefinedNode(t, nil, id, WithStore(DiskStore(dir)))
+	defer node.Shutdown()
+
+	if _, err := Update(node, "key", []byte("c")); err != errLocked {
+		t.Fatalf("Expected %v, got %v", errLocked, err)
+	}
+	versions, err := node.commit(context.Background(), "restart")
+	if err != nil || !reflect.DeepEqual(versions, []uint64{2}) {
+		t.Fatalf("Expected commit to succeed, got %v, %v", versions, err)
+	}
+	if val, err := Get(node, "key"); err != nil || string(val) != "b" {
+		t.Fatalf("Expected committed value, got %q, %v", val, err)
+	}
+}
+
+func TestTxnMovedKey(t *testing.T) {
+	t.Parallel()
+
+	key := "moved"
+	hashedKey, err := config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+
+	hashedKey[0] += 2
+	node1 := createDefinedNode(t, nil, hashedKey)
+	defer node1.Shutdown()
+
+	if err := Put(node1, key, []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	err = node1.prepare(&gmajpb.PrepareReq{TxnId: "moved", KeyVals: []*gmajpb.KeyVal{
+		{Key: key, Val: []byte("b"), Mode: gmajpb.PutMode_UPDATE},
+	}})
+	if err != nil {
+		t.Fatalf("Unexpected error preparing transaction: %v", err)
+	}
+
+	// node2 takes over the key while it is locked.
+	hashedKey, err = config.hashKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error hashing key: %v", err)
+	}
+	hashedKey[0]++
+	node2 := createDefinedNode(t, node1.Node, hashedKey)
+	defer node2.Shutdown()
+
+	<-time.After(testTimeout)
+
+	// Committing hands the key over, rather than writing it to node1, which
+	// no longer owns it.
+	ctx := context.Background()
+	if versions, err := node1.commit(ctx, "moved"); err != nil || !reflect.DeepEqual(versions, []uint64{2}) {
+		t.Fatalf("Expected commit to succeed, got %v, %v", versions, err)
+	}
+	if entry, err := node2.getKey(key); err != nil || string(entry.Val) != "b" || entry.Version != 2 {
+		t.Fatalf("Expected node2 to have the committed key, got %q, %v", entry.Val, err)
+	}
+	if _, ok, err := node1.datastore.Get(key); err != nil || ok {
+		t.Fatalf("Expected node1 to no longer own the key, got %v, %v", ok, err)
+	}
+}
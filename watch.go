@@ -0,0 +1,396 @@
+//
+//  sends the changes to keys to the clients that watch them, following the
+//  keys as they move between nodes
+//
+
+package gmaj
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// watchBuffer is how many changes a watch can fall behind by before it fails.
+const watchBuffer = 256
+
+// errWatchMoved ends the watches on a node when keys they cover move to
+// another node.
+var errWatchMoved = grpc.Errorf(codes.FailedPrecondition, "gmaj: watched keys moved to another node")
+
+// errWatchBehind ends a watch that does not keep up with the changes.
+var errWatchBehind = grpc.Errorf(codes.ResourceExhausted, "gmaj: watch fell behind the changes")
+
+//
+// watches on the keys a node owns
+//
+
+// watcher is a watch on keys that a node owns.
+type watcher struct {
+	req      *gmajpb.WatchKeysReq
+	from, to ID // the range of IDs watched
+	events   chan *gmajpb.WatchEvent
+	done     chan struct{} // closed when the watch is ended
+	err      error         // why the watch was ended
+}
+
+// matches returns if the watch covers key, which has the given ID.
+func (w *watcher) matches(id ID, key string) bool {
+	if w.req.Prefix {
+		if !strings.HasPrefix(key, w.req.Key) {
+			return false
+		}
+	} else if key != w.req.Key {
+		return false
+	}
+
+	return betweenRightIncl(id, w.from, w.to)
+}
+
+// watchers are the watches on the keys of a node. The zero value has none.
+type watchers struct {
+	all map[*watcher]struct{}
+	mtx sync.Mutex
+}
+
+func (ws *watchers) add(w *watcher) {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	if ws.all == nil {
+		ws.all = make(map[*watcher]struct{})
+	}
+	ws.all[w] = struct{}{}
+}
+
+func (ws *watchers) remove(w *watcher) {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	delete(ws.all, w)
+}
+
+// notify passes a change to a key on to the watches that cover it. A watch
+// that has fallen too far behind is ended rather than holding up the write.
+// It is called with dsMtx held, so that the changes are in order.
+func (ws *watchers) notify(id ID, key string, typ gmajpb.EventType, entry Entry) {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	for w := range ws.all {
+		if !w.matches(id, key) {
+			continue
+		}
+
+		select {
+		case w.events <- watchEvent(key, typ, entry):
+		default:
+			ws.end(w, errWatchBehind)
+		}
+	}
+}
+
+// moved ends the watches that cover any of (from : to], whose keys have moved
+// to another node.
+func (ws *watchers) moved(from, to ID) {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	for w := range ws.all {
+		// Two ranges overlap if either contains the end of the other.
+		if betweenRightIncl(w.to, from, to) || betweenRightIncl(to, w.from, w.to) {
+			ws.end(w, errWatchMoved)
+		}
+	}
+}
+
+// endAll ends all the watches with err.
+func (ws *watchers) endAll(err error) {
+	ws.mtx.Lock()
+	defer ws.mtx.Unlock()
+
+	for w := range ws.all {
+		ws.end(w, err)
+	}
+}
+
+// end ends a watch with err. It must be called with mtx held.
+func (ws *watchers) end(w *watcher, err error) {
+	delete(ws.all, w)
+	w.err = err
+	close(w.done)
+}
+
+func watchEvent(key string, typ gmajpb.EventType, entry Entry) *gmajpb.WatchEvent {
+	return &gmajpb.WatchEvent{Type: typ, Key: key, Value: entry.Val, Version: entry.Version}
+}
+
+// watchKeys sends the changes to the keys that req covers to send, until ctx
+// is done or the watch is ended.
+func (node *Node) watchKeys(
+	ctx context.Context, req *gmajpb.WatchKeysReq, send func(*gmajpb.WatchEvent) error,
+) error {
+	w := &watcher{
+		req:    req,
+		from:   newID(req.FromId),
+		to:     newID(req.ToId),
+		events: make(chan *gmajpb.WatchEvent, watchBuffer),
+		done:   make(chan struct{}),
+	}
+
+	// The watch starts before ownership is checked and the watcher catches
+	// up, so that no changes are missed in between.
+	node.watchers.add(w)
+	defer node.watchers.remove(w)
+
+	if !node.ownsRange(w.from, w.to) {
+		return grpc.Errorf(codes.FailedPrecondition, "gmaj: node %v does not own the watched keys",
+			IDToString(node.Id),
+		)
+	}
+
+	if req.Resume {
+		if err := node.catchUp(req, send); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case event := <-w.events:
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-w.done:
+			// Send the changes from before the watch ended first.
+			for {
+				select {
+				case event := <-w.events:
+					if err := send(event); err != nil {
+						return err
+					}
+				default:
+					return w.err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// catchUp sends the current state of the watched key, if it is newer than the
+// version the watcher has seen. The versions seen of the keys with a prefix
+// are not kept, so a watch of a prefix is told to read its keys again instead.
+func (node *Node) catchUp(req *gmajpb.WatchKeysReq, send func(*gmajpb.WatchEvent) error) error {
+	if req.Prefix {
+		return send(&gmajpb.WatchEvent{Type: gmajpb.EventType_RESYNC, Key: req.Key})
+	}
+
+	node.dsMtx.RLock()
+	entry, ok, err := node.latest(req.Key)
+	node.dsMtx.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !ok || entry.Version <= req.Version || entry.expired(node.clock.Now()) {
+		return nil
+	}
+
+	typ := gmajpb.EventType_UPDATED
+	if entry.Deleted {
+		typ = gmajpb.EventType_DELETED
+	} else if req.Version == 0 {
+		typ = gmajpb.EventType_CREATED
+	}
+
+	return send(watchEvent(req.Key, typ, entry))
+}
+
+// ownsRange returns if the node owns all of (from : to], as far as it knows.
+// Like in checkOwner, a node that does not know its predecessor assumes that
+// it does.
+func (node *Node) ownsRange(from, to ID) bool {
+	node.predMtx.RLock()
+	pred := node.predecessor
+	node.predMtx.RUnlock()
+
+	if pred == nil || pred.Addr == "" {
+		return true
+	}
+
+	predID := newID(pred.Id)
+	switch {
+	case predID == node.id:
+		// The node is the only one in the ring.
+		return true
+	case from == to:
+		// The range is the whole ring.
+		return false
+	}
+
+	return betweenRightIncl(to, predID, node.id) && (from == predID || between(from, predID, to))
+}
+
+//
+// watches on behalf of clients
+//
+
+// watch is a client's watch, which may be split over several nodes.
+type watch struct {
+	req    *gmajpb.WatchRequest
+	events chan *gmajpb.WatchEvent
+	errs   chan error // gets the error that ends the watch
+
+	version uint64 // the last version sent of a single key
+	mtx     sync.Mutex
+}
+
+// fail ends the watch with err.
+func (w *watch) fail(err error) {
+	select {
+	case w.errs <- err:
+	default:
+	}
+}
+
+// lastVersion returns the last version sent of the watched key.
+func (w *watch) lastVersion() uint64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	return w.version
+}
+
+// watch sends the changes to the keys req covers to send, until ctx is done
+// or the watch fails. It watches each part of the ring that the keys may be in
+// on the node that owns it.
+func (node *Node) watch(
+	ctx context.Context, req *gmajpb.WatchRequest, send func(*gmajpb.WatchEvent) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := &watch{
+		req:    req,
+		events: make(chan *gmajpb.WatchEvent),
+		errs:   make(chan error, 1),
+	}
+
+	if req.Prefix {
+		// Keys with the prefix may be anywhere in the ring.
+		go node.watchRange(ctx, w, node.id, node.id, false)
+	} else {
+		id, err := node.config.keyID(req.Key)
+		if err != nil {
+			return err
+		}
+
+		before := id.add(node.config.mask).and(node.config.mask)
+		go node.watchRange(ctx, w, before, id, false)
+	}
+
+	for {
+		select {
+		case event := <-w.events:
+			if !req.Prefix {
+				// A watch that moved may get a change again when it catches
+				// up.
+				w.mtx.Lock()
+				old := event.Version <= w.version
+				if !old {
+					w.version = event.Version
+				}
+				w.mtx.Unlock()
+				if old {
+					continue
+				}
+			}
+
+			if err := send(event); err != nil {
+				return err
+			}
+		case err := <-w.errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// watchRange watches the keys in (from : to] on the nodes that own them.
+func (node *Node) watchRange(ctx context.Context, w *watch, from, to ID, resume bool) {
+	for {
+		owner, err := node.findSuccessor(ctx, node.config.fingerMath(from, 0))
+		if err != nil {
+			// The ring may be changing, so try again.
+			if !node.sleep(ctx, node.config.RetryInterval) {
+				return
+			}
+			continue
+		}
+
+		end := newID(owner.Id)
+		if !betweenRightIncl(end, from, to) {
+			end = to
+		}
+
+		go node.watchOwner(ctx, w, owner, from, end, resume)
+		if end == to {
+			return
+		}
+		from = end
+	}
+}
+
+// watchOwner watches the keys in (from : to] on owner. When the watch there
+// ends because the keys moved or owner failed, it watches the range again on
+// the nodes that own it then.
+func (node *Node) watchOwner(
+	ctx context.Context, w *watch, owner *gmajpb.Node, from, to ID, resume bool,
+) {
+	err := node.watchKeysRPC(ctx, owner, &gmajpb.WatchKeysReq{
+		Key:     w.req.Key,
+		Prefix:  w.req.Prefix,
+		FromId:  node.config.idBytes(from),
+		ToId:    node.config.idBytes(to),
+		Resume:  resume,
+		Version: w.lastVersion(),
+	}, func(event *gmajpb.WatchEvent) error {
+		select {
+		case w.events <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	if grpc.Code(err) == codes.ResourceExhausted {
+		w.fail(err)
+		return
+	}
+
+	node.locations.invalidate(owner)
+	if node.sleep(ctx, node.config.RetryInterval) {
+		node.watchRange(ctx, w, from, to, true)
+	}
+}
+
+// sleep waits for d to pass on the node's clock. It returns false if ctx is
+// done first.
+func (node *Node) sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-node.clock.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
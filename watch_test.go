@@ -0,0 +1,135 @@
+package gmaj
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/r-medina/gmaj/gmajpb"
+
+	"golang.org/x/net/context"
+)
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	node1, node2, node3 := create3SuccessiveNodes(t)
+	defer node1.Shutdown()
+	defer node2.Shutdown()
+	defer node3.Shutdown()
+
+	// Find a key that a node can be added right on top of, to take it over.
+	var (
+		key string
+		id  ID
+	)
+	for i := 0; ; i++ {
+		key = fmt.Sprintf("watch%d", i)
+		var err error
+		if id, err = config.keyID(key); err != nil {
+			t.Fatal(err)
+		}
+		if id != node1.id && id != node2.id && id != node3.id {
+			break
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watch := func(node *Node, req *gmajpb.WatchRequest) <-chan *gmajpb.WatchEvent {
+		events := make(chan *gmajpb.WatchEvent, 16)
+		go func() {
+			_ = node.watch(ctx, req, func(event *gmajpb.WatchEvent) error {
+				events <- event
+				return nil
+			})
+		}()
+
+		return events
+	}
+	keyEvents := watch(node2, &gmajpb.WatchRequest{Key: key})
+	prefixEvents := watch(node3, &gmajpb.WatchRequest{Key: "pfx/", Prefix: true})
+
+	expect := func(events <-chan *gmajpb.WatchEvent, want *gmajpb.WatchEvent) {
+		select {
+		case got := <-events:
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Expected event %v, got %v", want, got)
+			}
+		case <-time.After(2 * testTimeout):
+			t.Fatalf("Expected event %v, got none", want)
+		}
+	}
+
+	<-time.After(testTimeout)
+
+	if err := Put(node1, key, []byte("a")); err != nil {
+		t.Fatalf("Unexpected error putting value: %v", err)
+	}
+	expect(keyEvents, &gmajpb.WatchEvent{
+		Type: gmajpb.EventType_CREATED, Key: key, Value: []byte("a"), Version: 1,
+	})
+	if _, err := Update(node1, key, []byte("b")); err != nil {
+		t.Fatalf("Unexpected error updating value: %v", err)
+	}
+	expect(keyEvents, &gmajpb.WatchEvent{
+		Type: gmajpb.EventType_UPDATED, Key: key, Value: []byte("b"), Version: 2,
+	})
+
+	for _, k := range []string{"pfx/a", "other", "pfx/b"} {
+		if err := Put(node1, k, []byte(k)); err != nil {
+			t.Fatalf("Unexpected error putting value: %v", err)
+		}
+	}
+	// The prefix watch may have been told to resync while the ring settled.
+	got := make(map[string]bool)
+	for len(got) < 2 {
+		select {
+		case event := <-prefixEvents:
+			if event.Type != gmajpb.EventType_RESYNC {
+				got[event.Key] = true
+			}
+		case <-time.After(2 * testTimeout):
+			t.Fatalf("Expected two events for the prefix, got %v", got)
+		}
+	}
+	if want := map[string]bool{"pfx/a": true, "pfx/b": true}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected events for %v, got %v", want, got)
+	}
+	for len(prefixEvents) > 0 { // resyncs from while the ring settled
+		<-prefixEvents
+	}
+
+	// The new node takes over the key, and the watch has to follow it.
+	node4 := createDefinedNode(t, node1.Node, config.idBytes(id))
+	defer node4.Shutdown()
+
+	<-time.After(2 * testTimeout)
+
+	if _, err := node4.getKey(key); err != nil {
+		t.Fatalf("Expected new node to have the key, got %v", err)
+	}
+
+	// Part of the ring the prefix watch covers moved too, and it is told that
+	// it may have missed changes.
+	expect(prefixEvents, &gmajpb.WatchEvent{Type: gmajpb.EventType_RESYNC, Key: "pfx/"})
+
+	if _, err := Update(node1, key, []byte("c")); err != nil {
+		t.Fatalf("Unexpected error updating value: %v", err)
+	}
+	expect(keyEvents, &gmajpb.WatchEvent{
+		Type: gmajpb.EventType_UPDATED, Key: key, Value: []byte("c"), Version: 3,
+	})
+	if err := Delete(node1, key); err != nil {
+		t.Fatalf("Unexpected error deleting key: %v", err)
+	}
+	expect(keyEvents, &gmajpb.WatchEvent{Type: gmajpb.EventType_DELETED, Key: key, Version: 4})
+
+	select {
+	case event := <-keyEvents:
+		t.Fatalf("Unexpected event %v", event)
+	default:
+	}
+}